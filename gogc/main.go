@@ -1,21 +1,27 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
 	"log"
+	"log/slog"
 	"math/rand"
 	"net/http"
 	_ "net/http/pprof" // 导入 pprof，它会自动注册 HTTP 处理程序
 	"os"
-	"runtime"
+	"os/signal"
 	"runtime/debug"
+	"strings"
+	"syscall"
 	"time"
 
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/shirou/gopsutil/process"
+	"github.com/xyzbit/go-tuning-practice/gogctuner"
 )
 
 // 定义 prometheus 指标
@@ -118,6 +124,9 @@ type temporaryObject struct {
 // 一组长期存活的对象，模拟老一代对象
 var longLivedObjects []*temporaryObject
 
+// 内存压舱石，保存在包级变量中，保证在整个进程生命周期内可达
+var ballast *gogctuner.Ballast
+
 // 创建并管理一个临时对象
 func createObject(objSize int, longLivedRatio float64) {
 	// 创建指定大小的临时对象
@@ -151,7 +160,10 @@ func main() {
 	objSize := flag.Int("obj-size", 1024, "对象大小 (字节)")
 	longLivedRatio := flag.Float64("long-lived", 0.05, "长期存活对象比例 (0.0-1.0)")
 	memlimit := flag.Int("memlimit", 0, "内存限制 (MB), 默认不限制")
-	ballast := flag.Int("ballast", 0, " ballast 大小 (MB), 默认不限制")
+	ballastMB := flag.Int("ballast", 0, "ballast 大小 (MB), 默认不使用; 启用 -tuner 时请改用 -tuner-mode ballast")
+	enableTuner := flag.Bool("tuner", false, "是否启用 GOGCTuner 动态调整 GOGC (启用后 -gogc 仅作为初始值)")
+	tunerMode := flag.String("tuner-mode", "gogc", "GOGCTuner 调优模式: gogc|memory_limit|gc_cpu|ballast")
+	adminToken := flag.String("tuner-admin-token", os.Getenv("GOGCTUNER_ADMIN_TOKEN"), "GOGCTuner 管理接口令牌, 为空时只读 (默认读取 GOGCTUNER_ADMIN_TOKEN)")
 	flag.Parse()
 
 	// 在main函数中启动
@@ -166,13 +178,32 @@ func main() {
 		log.Printf("设置内存限制: %d MB", *memlimit)
 		debug.SetMemoryLimit(int64(*memlimit * 1024 * 1024))
 	}
-	if ballast != nil && *ballast > 0 {
-		log.Printf("设置 ballast: %d MB", *ballast)
-		ballastBucket := make([]byte, *ballast*1024*1024)
-		runtime.KeepAlive(ballastBucket)
+	if ballastMB != nil && *ballastMB > 0 {
+		log.Printf("设置 ballast: %d MB", *ballastMB)
+		ballast = gogctuner.NewBallast(int64(*ballastMB) << 20)
 	}
 	log.Printf("GOGC 设置为: %d", *gcPercent)
 
+	var tuner *gogctuner.Tuner
+	if *enableTuner {
+		var err error
+		tuner, err = gogctuner.NewTuner(gogctuner.Config{
+			Logger: slog.Default(),
+			Mode:   gogctuner.TuningMode(*tunerMode),
+		})
+		if err != nil {
+			log.Fatalf("GOGCTuner 初始化失败: %v", err)
+		}
+		// 退出时显式调用Stop，以删除协调注册表条目并恢复GOGC和软内存限制
+		tuner.Start()
+		prometheus.MustRegister(gogctuner.NewCollector(tuner))
+		http.Handle(gogctuner.DefaultAdminPath, http.StripPrefix(
+			strings.TrimSuffix(gogctuner.DefaultAdminPath, "/"),
+			gogctuner.NewAdminHandler(tuner, *adminToken),
+		))
+		log.Printf("GOGCTuner 已启动, 内存限制: %d MB", tuner.MemoryLimit()>>20)
+	}
+
 	// 启动 HTTP 服务
 	http.Handle("/metrics", promhttp.Handler())
 	http.Handle("/", metricsMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -185,6 +216,7 @@ func main() {
 		fmt.Fprintf(w, "- 访问 /debug/pprof/ 获取性能分析数据\n")
 		fmt.Fprintf(w, "- 访问 /debug/pprof/heap 查看内存分配情况\n")
 		fmt.Fprintf(w, "- 访问 /debug/pprof/goroutine 查看 goroutine 信息\n")
+		fmt.Fprintf(w, "- 访问 %s 查看 GOGCTuner 状态 (需启用 -tuner)\n", gogctuner.DefaultAdminPath)
 
 		time.Sleep(10 * time.Millisecond)
 
@@ -208,5 +240,38 @@ func main() {
 	log.Printf("当前 GOGC 值: %d", *gcPercent)
 	log.Printf("HTTP 服务启动在 :%d", *port)
 	log.Printf("性能分析服务: http://localhost:%d/debug/pprof/", *port)
-	log.Fatal(http.ListenAndServe(fmt.Sprintf(":%d", *port), nil))
+
+	// 收到退出信号时关闭HTTP服务，再停止GOGCTuner
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	server := &http.Server{Addr: fmt.Sprintf(":%d", *port)}
+	serverDone := make(chan error, 1)
+	go func() {
+		serverDone <- server.ListenAndServe()
+	}()
+
+	exitCode := 0
+	select {
+	case err := <-serverDone:
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("HTTP 服务异常退出: %v", err)
+			exitCode = 1
+		}
+	case <-ctx.Done():
+		log.Printf("收到退出信号, 正在关闭 HTTP 服务")
+		shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+		if err := server.Shutdown(shutdownCtx); err != nil {
+			log.Printf("HTTP 服务关闭失败: %v", err)
+		}
+		cancel()
+	}
+
+	if tuner != nil {
+		tuner.Stop()
+		log.Printf("GOGCTuner 已停止")
+	}
+	if exitCode != 0 {
+		os.Exit(exitCode)
+	}
 }
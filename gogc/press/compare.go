@@ -0,0 +1,342 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"text/tabwriter"
+	"time"
+)
+
+// 95%双侧置信区间的t分布分位数，下标为自由度-1
+var tQuantiles975 = []float64{
+	12.706, 4.303, 3.182, 2.776, 2.571, 2.447, 2.365, 2.306, 2.262, 2.228,
+	2.201, 2.179, 2.160, 2.145, 2.131, 2.120, 2.110, 2.101, 2.093, 2.086,
+	2.080, 2.074, 2.069, 2.064, 2.060, 2.056, 2.052, 2.048, 2.045, 2.042,
+}
+
+// tQuantile975 自由度为df的t分布的0.975分位数，超出表格时使用Cornish-Fisher展开近似
+func tQuantile975(df float64) float64 {
+	if df < 1 {
+		df = 1
+	}
+	if i := int(math.Floor(df)); i <= len(tQuantiles975) {
+		return tQuantiles975[i-1]
+	}
+	const z = 1.959964
+	return z + (z*z*z+z)/(4*df)
+}
+
+// batch 对比时的统计单元：连续若干秒的请求
+// 相邻秒之间的延迟相关（例如一次GC暂停影响连续的请求），按批次均值估计方差比直接按请求计算更可靠
+type batch struct {
+	seconds float64
+	counts  requestCounts
+	latency *histogram
+}
+
+func (b *batch) add(p secondStats, seconds float64) {
+	b.seconds += seconds
+	b.counts.Total += p.Total
+	b.counts.Successful += p.Successful
+	b.counts.Failed += p.Failed
+	b.counts.TimedOut += p.TimedOut
+	b.counts.Dropped += p.Dropped
+	if p.Histogram != nil {
+		b.latency.Merge(p.Histogram)
+	}
+}
+
+// compareMetric 对比的指标
+type compareMetric struct {
+	name string
+	// 是否越大越好
+	higherIsBetter bool
+	// 阈值按绝对值（百分点）而不是相对变化比较
+	absolute bool
+	// 返回批次的指标值，ok为false表示该批次没有数据
+	value func(b *batch) (v float64, ok bool)
+}
+
+func latencyMetric(name string, q float64) compareMetric {
+	return compareMetric{name: name, value: func(b *batch) (float64, bool) {
+		if b.latency.Count() == 0 {
+			return 0, false
+		}
+		d := b.latency.Percentile(q)
+		if q == 0 {
+			d = b.latency.Mean()
+		}
+		return float64(d) / float64(time.Millisecond), true
+	}}
+}
+
+var compareMetrics = []compareMetric{
+	{name: "吞吐(RPS)", higherIsBetter: true, value: func(b *batch) (float64, bool) {
+		return float64(b.counts.Successful) / b.seconds, b.seconds > 0
+	}},
+	{name: "错误率(%)", absolute: true, value: func(b *batch) (float64, bool) {
+		if b.counts.Total == 0 {
+			return 0, false
+		}
+		return float64(b.counts.Errors()) / float64(b.counts.Total) * 100, true
+	}},
+	latencyMetric("平均延迟(ms)", 0),
+	latencyMetric("p50(ms)", 50),
+	latencyMetric("p90(ms)", 90),
+	latencyMetric("p99(ms)", 99),
+	latencyMetric("p99.9(ms)", 99.9),
+}
+
+// runBatches 一次压测去掉预热后的批次和整体
+type runBatches struct {
+	name string
+	path string
+	// 所有批次的时长和请求数，不合并延迟
+	overall *batch
+	batches []*batch
+}
+
+// splitBatches 去掉前warmup秒，按batchSize秒划分批次，不足半个批次的尾部丢弃
+func splitBatches(res runResult, warmup, batchSize time.Duration) (runBatches, error) {
+	run := runBatches{overall: &batch{}}
+	var prev float64
+	for _, p := range res.Series {
+		start, seconds := prev, p.Elapsed-prev
+		prev = p.Elapsed
+		if start < warmup.Seconds() {
+			continue
+		}
+		if p.Histogram == nil && p.Successful > 0 {
+			return run, fmt.Errorf("时间序列缺少延迟直方图")
+		}
+		index := int((start - warmup.Seconds()) / batchSize.Seconds())
+		for len(run.batches) <= index {
+			run.batches = append(run.batches, &batch{latency: newHistogram()})
+		}
+		run.batches[index].add(p, seconds)
+	}
+	if n := len(run.batches); n > 0 && run.batches[n-1].seconds < batchSize.Seconds()/2 {
+		run.batches = run.batches[:n-1]
+	}
+	for _, b := range run.batches {
+		run.overall.seconds += b.seconds
+		run.overall.counts = addCounts(run.overall.counts, b.counts)
+	}
+	if len(run.batches) == 0 {
+		return run, fmt.Errorf("去掉%v预热后没有数据", warmup)
+	}
+	return run, nil
+}
+
+func addCounts(a, b requestCounts) requestCounts {
+	return requestCounts{
+		Total:      a.Total + b.Total,
+		Successful: a.Successful + b.Successful,
+		Failed:     a.Failed + b.Failed,
+		TimedOut:   a.TimedOut + b.TimedOut,
+		Dropped:    a.Dropped + b.Dropped,
+	}
+}
+
+// estimate 按批次均值法估计的指标：批次值的均值和样本方差
+// 置信区间由批次值的方差得到，因此以批次均值为中心，而不是合并所有批次后的整体值；
+// 延迟百分位是各批次百分位的均值，与合并直方图后的百分位可能略有差异
+type estimate struct {
+	value float64
+	// 批次值的样本方差和批次数
+	variance float64
+	n        int
+}
+
+// estimateMetric 按有数据的批次估计指标，所有批次都没有数据时ok为false
+func estimateMetric(run runBatches, m compareMetric) (estimate, bool) {
+	var samples []float64
+	for _, b := range run.batches {
+		if v, ok := m.value(b); ok {
+			samples = append(samples, v)
+		}
+	}
+	return sampleEstimate(samples), len(samples) > 0
+}
+
+// sampleEstimate 样本的均值和样本方差，少于2个样本时方差为0
+func sampleEstimate(samples []float64) estimate {
+	est := estimate{n: len(samples)}
+	if est.n == 0 {
+		return est
+	}
+	for _, v := range samples {
+		est.value += v
+	}
+	est.value /= float64(est.n)
+	if est.n < 2 {
+		return est
+	}
+	for _, v := range samples {
+		est.variance += (v - est.value) * (v - est.value)
+	}
+	est.variance /= float64(est.n - 1)
+	return est
+}
+
+// halfWidth 95%置信区间的半宽，批次不足2个时为NaN
+func (e estimate) halfWidth() float64 {
+	if e.n < 2 {
+		return math.NaN()
+	}
+	return tQuantile975(float64(e.n-1)) * math.Sqrt(e.variance/float64(e.n))
+}
+
+// diffHalfWidth 两次压测差值的95%置信区间半宽（Welch t区间）
+func diffHalfWidth(base, other estimate) float64 {
+	if base.n < 2 || other.n < 2 {
+		return math.NaN()
+	}
+	a := base.variance / float64(base.n)
+	b := other.variance / float64(other.n)
+	if a+b == 0 {
+		return 0
+	}
+	df := (a + b) * (a + b) / (a*a/float64(base.n-1) + b*b/float64(other.n-1))
+	return tQuantile975(df) * math.Sqrt(a+b)
+}
+
+// runCompare compare子命令：以第一个结果文件为基线，与其余结果逐一对比，存在超过阈值的退化时返回1
+func runCompare(args []string) int {
+	fs := flag.NewFlagSet("compare", flag.ExitOnError)
+	threshold := fs.Float64("threshold", 5, "吞吐和延迟的相对变化超过该百分比且统计显著时判定为退化")
+	errorThreshold := fs.Float64("error-threshold", 0.1, "错误率增加超过该百分点且统计显著时判定为退化")
+	batchSize := fs.Duration("batch", 10*time.Second, "批次长度，按批次估计置信区间")
+	warmup := fs.Duration("warmup", 0, "忽略每次压测开始的预热时间")
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "使用方法: press compare [参数] 基线.json 对比.json [对比2.json ...]")
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+	if fs.NArg() < 2 || *batchSize <= 0 {
+		fs.Usage()
+		return 2
+	}
+
+	runs := make([]runBatches, fs.NArg())
+	for i, path := range fs.Args() {
+		res, err := readResultJSON(path)
+		if err == nil {
+			runs[i], err = splitBatches(res, *warmup, *batchSize)
+		}
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "%s: %v\n", path, err)
+			return 2
+		}
+		runs[i].name = runName(res, path)
+		runs[i].path = path
+	}
+
+	regressed := false
+	for _, run := range runs[1:] {
+		if compareRuns(runs[0], run, *threshold, *errorThreshold) {
+			regressed = true
+		}
+	}
+	if regressed {
+		fmt.Println("\n存在超过阈值的退化")
+		return 1
+	}
+	return 0
+}
+
+// runName 结果的显示名称：标签，没有标签时为文件名
+func runName(res runResult, path string) string {
+	if len(res.Labels) > 0 {
+		return labelFlag(res.Labels).String()
+	}
+	return filepath.Base(path)
+}
+
+// compareRuns 输出other相对base的差异，返回是否存在超过阈值的退化
+func compareRuns(base, other runBatches, threshold, errorThreshold float64) bool {
+	fmt.Printf("\n基线: %s (%s), %.0f秒, %d个批次, %d个请求\n", base.name, base.path, base.overall.seconds, len(base.batches), base.overall.counts.Total)
+	fmt.Printf("对比: %s (%s), %.0f秒, %d个批次, %d个请求\n\n", other.name, other.path, other.overall.seconds, len(other.batches), other.overall.counts.Total)
+
+	regressed := false
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "指标\t基线\t对比\t差异\t95%置信区间\t结论")
+	for _, m := range compareMetrics {
+		b, okBase := estimateMetric(base, m)
+		o, okOther := estimateMetric(other, m)
+		if !okBase || !okOther {
+			fmt.Fprintf(w, "%s\t-\t-\t-\t-\t无数据\n", m.name)
+			continue
+		}
+
+		c := judge(m, b, o, threshold, errorThreshold)
+		if c.regressed {
+			regressed = true
+		}
+		interval := "-"
+		if !math.IsNaN(c.half) {
+			interval = fmt.Sprintf("[%+.2f%s, %+.2f%s]", (c.diff-c.half)*c.scale, c.unit, (c.diff+c.half)*c.scale, c.unit)
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%+.2f%s\t%s\t%s\n",
+			m.name, formatEstimate(b), formatEstimate(o), c.diff*c.scale, c.unit, interval, c.verdict)
+	}
+	w.Flush()
+	return regressed
+}
+
+// comparison 一项指标的对比结论
+type comparison struct {
+	// 差值及其95%置信区间半宽，批次不足时半宽为NaN
+	diff, half float64
+	// 输出时差值乘以scale，单位为unit
+	scale float64
+	unit  string
+	// 结论，regressed表示统计显著且超过阈值的退化
+	verdict   string
+	regressed bool
+}
+
+// judge 判断other相对base的变化
+// 相对指标以基线的百分比表示，错误率以百分点表示；
+// 基线为0时无法计算相对变化，差值按原单位输出，显著的退化视为超过阈值
+func judge(m compareMetric, base, other estimate, threshold, errorThreshold float64) comparison {
+	c := comparison{diff: other.value - base.value, half: diffHalfWidth(base, other), scale: 1}
+	relative := !m.absolute && base.value != 0
+	limit := errorThreshold
+	switch {
+	case m.absolute:
+		c.unit = "pp"
+	case relative:
+		c.scale, c.unit, limit = 100/base.value, "%", threshold
+	}
+
+	worse := (c.diff < 0) == m.higherIsBetter && c.diff != 0
+	switch {
+	case math.IsNaN(c.half):
+		c.verdict = "批次不足"
+	case math.Abs(c.diff) <= c.half:
+		c.verdict = "无显著差异"
+	case !worse:
+		c.verdict = "改善"
+	case !m.absolute && !relative:
+		c.verdict = "退化 !! (基线为0，无法计算相对变化)"
+		c.regressed = true
+	case math.Abs(c.diff*c.scale) > limit:
+		c.verdict = "退化 !!"
+		c.regressed = true
+	default:
+		c.verdict = "退化(未超过阈值)"
+	}
+	return c
+}
+
+// formatEstimate 输出 值 ± 置信区间半宽
+func formatEstimate(e estimate) string {
+	if half := e.halfWidth(); !math.IsNaN(half) {
+		return fmt.Sprintf("%.2f ± %.2f", e.value, half)
+	}
+	return fmt.Sprintf("%.2f", e.value)
+}
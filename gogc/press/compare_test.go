@@ -0,0 +1,191 @@
+package main
+
+import (
+	"math"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestTQuantile975(t *testing.T) {
+	tests := []struct {
+		df   float64
+		want float64
+	}{
+		// 自由度小于1按1计算，非整数自由度向下取整，结果偏保守
+		{0.5, 12.706},
+		{1, 12.706},
+		{2.9, 4.303},
+		{8, 2.306},
+		{30, 2.042},
+		// 超出表格时的近似值与t分布表相差不超过0.002
+		{40, 2.021},
+		{60, 2.000},
+		{120, 1.980},
+		{1e9, 1.960},
+	}
+	for _, tt := range tests {
+		if got := tQuantile975(tt.df); math.Abs(got-tt.want) > 0.002 {
+			t.Errorf("tQuantile975(%v) = %.4f, want %.3f", tt.df, got, tt.want)
+		}
+	}
+}
+
+func TestDiffHalfWidth(t *testing.T) {
+	tests := []struct {
+		name        string
+		base, other estimate
+		want        float64
+	}{
+		// 方差和批次数相同时自由度为2(n-1)=8
+		{"等方差", estimate{variance: 4, n: 5}, estimate{variance: 4, n: 5}, 2.306 * math.Sqrt(1.6)},
+		// 自由度 (0.1+2.25)²/(0.1²/9+2.25²/3) ≈ 3.27，按3查表
+		{"不等方差", estimate{variance: 1, n: 10}, estimate{variance: 9, n: 4}, 3.182 * math.Sqrt(2.35)},
+		{"方差为0", estimate{value: 1, n: 5}, estimate{value: 2, n: 5}, 0},
+		{"批次不足", estimate{variance: 4, n: 5}, estimate{n: 1}, math.NaN()},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := diffHalfWidth(tt.base, tt.other)
+			if math.IsNaN(tt.want) != math.IsNaN(got) || math.Abs(got-tt.want) > 1e-9 {
+				t.Fatalf("diffHalfWidth = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+// testSeries 每秒一个点的时间序列，每秒successful个请求，延迟为latency
+func testSeries(seconds int, successful int64, latency time.Duration) runResult {
+	var res runResult
+	for i := 1; i <= seconds; i++ {
+		h := newHistogram()
+		for j := int64(0); j < successful; j++ {
+			h.Record(latency)
+		}
+		res.Series = append(res.Series, secondStats{
+			Elapsed:       float64(i),
+			requestCounts: requestCounts{Total: successful, Successful: successful},
+			Histogram:     h,
+		})
+	}
+	return res
+}
+
+func TestSplitBatches(t *testing.T) {
+	tests := []struct {
+		name    string
+		seconds int
+		warmup  time.Duration
+		// 每个批次的秒数
+		want []float64
+	}{
+		{"整数个批次", 10, 0, []float64{5, 5}},
+		// 预热后剩余12秒，尾部2秒不足半个批次
+		{"丢弃尾部", 15, 3 * time.Second, []float64{5, 5}},
+		// 预热后剩余13秒，尾部3秒超过半个批次
+		{"保留尾部", 16, 3 * time.Second, []float64{5, 5, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			run, err := splitBatches(testSeries(tt.seconds, 10, time.Millisecond), tt.warmup, 5*time.Second)
+			if err != nil {
+				t.Fatalf("splitBatches: %v", err)
+			}
+			if len(run.batches) != len(tt.want) {
+				t.Fatalf("批次数 = %d, want %d", len(run.batches), len(tt.want))
+			}
+			var total float64
+			for i, b := range run.batches {
+				if b.seconds != tt.want[i] || b.counts.Successful != int64(tt.want[i])*10 || b.latency.Count() != uint64(tt.want[i])*10 {
+					t.Fatalf("第%d个批次 = %v秒 %d个请求 %d个延迟, want %v秒", i+1, b.seconds, b.counts.Successful, b.latency.Count(), tt.want[i])
+				}
+				total += b.seconds
+			}
+			if run.overall.seconds != total || run.overall.counts.Successful != int64(total)*10 {
+				t.Fatalf("整体 = %v秒 %d个请求, want %v秒", run.overall.seconds, run.overall.counts.Successful, total)
+			}
+		})
+	}
+}
+
+func TestSplitBatchesErrors(t *testing.T) {
+	if _, err := splitBatches(testSeries(10, 10, time.Millisecond), 10*time.Second, 5*time.Second); err == nil || !strings.Contains(err.Error(), "没有数据") {
+		t.Fatalf("预热覆盖整个压测时错误 = %v", err)
+	}
+	res := testSeries(10, 10, time.Millisecond)
+	res.Series[5].Histogram = nil
+	if _, err := splitBatches(res, 0, 5*time.Second); err == nil || !strings.Contains(err.Error(), "直方图") {
+		t.Fatalf("缺少直方图时错误 = %v", err)
+	}
+}
+
+func TestEstimateMetric(t *testing.T) {
+	// 两个5秒的批次吞吐为10，3秒的尾部批次吞吐为20
+	res := testSeries(10, 10, time.Millisecond)
+	for _, p := range testSeries(3, 20, 3*time.Millisecond).Series {
+		p.Elapsed += 10
+		res.Series = append(res.Series, p)
+	}
+	run, err := splitBatches(res, 0, 5*time.Second)
+	if err != nil {
+		t.Fatalf("splitBatches: %v", err)
+	}
+
+	// 以批次均值为中心，而不是整体的 160/13 RPS
+	est, ok := estimateMetric(run, compareMetrics[0])
+	if !ok || est.n != 3 || math.Abs(est.value-40.0/3) > 1e-9 || math.Abs(est.variance-100.0/3) > 1e-9 {
+		t.Fatalf("吞吐 = %+v, want 均值40/3 方差100/3", est)
+	}
+	est, ok = estimateMetric(run, latencyMetric("平均延迟(ms)", 0))
+	if !ok || math.Abs(est.value-5.0/3) > 1e-9 {
+		t.Fatalf("平均延迟 = %+v, want 批次均值5/3", est)
+	}
+
+	// 所有批次都没有延迟数据
+	run, _ = splitBatches(testSeries(10, 0, 0), 0, 5*time.Second)
+	if _, ok := estimateMetric(run, latencyMetric("p99(ms)", 99)); ok {
+		t.Fatal("没有延迟数据时应返回ok=false")
+	}
+}
+
+func TestJudge(t *testing.T) {
+	throughput, errorRate, p99 := compareMetrics[0], compareMetrics[1], latencyMetric("p99(ms)", 99)
+	// n=5、方差为4的两组批次，差值的置信区间半宽约为2.92
+	est := func(value float64) estimate { return estimate{value: value, variance: 4, n: 5} }
+	tests := []struct {
+		name        string
+		metric      compareMetric
+		base, other estimate
+		verdict     string
+		regressed   bool
+		unit        string
+	}{
+		{"批次不足", p99, est(100), estimate{value: 200, n: 1}, "批次不足", false, "%"},
+		{"无显著差异", p99, est(100), est(102), "无显著差异", false, "%"},
+		{"延迟退化", p99, est(100), est(110), "退化 !!", true, "%"},
+		{"延迟退化未超过阈值", p99, est(100), est(104), "退化(未超过阈值)", false, "%"},
+		{"延迟改善", p99, est(100), est(90), "改善", false, "%"},
+		{"吞吐下降", throughput, est(100), est(90), "退化 !!", true, "%"},
+		{"吞吐提升", throughput, est(100), est(110), "改善", false, "%"},
+		{"错误率增加", errorRate, estimate{value: 0.1, variance: 0.0004, n: 5}, estimate{value: 0.5, variance: 0.0004, n: 5}, "退化 !!", true, "pp"},
+		{"错误率增加未超过阈值", errorRate, estimate{value: 0.1, variance: 0.0004, n: 5}, estimate{value: 0.15, variance: 0.0004, n: 5}, "退化(未超过阈值)", false, "pp"},
+		{"基线错误率为0", errorRate, estimate{n: 5}, estimate{value: 0.5, variance: 0.0004, n: 5}, "退化 !!", true, "pp"},
+		{"基线为0时退化", p99, estimate{n: 5}, est(5), "退化 !! (基线为0，无法计算相对变化)", true, ""},
+		{"基线为0时改善", throughput, estimate{n: 5}, est(10), "改善", false, ""},
+		{"基线为0时无显著差异", p99, estimate{n: 5}, est(1), "无显著差异", false, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			c := judge(tt.metric, tt.base, tt.other, 5, 0.1)
+			if c.verdict != tt.verdict || c.regressed != tt.regressed || c.unit != tt.unit {
+				t.Fatalf("judge = %q regressed=%v unit=%q, want %q %v %q", c.verdict, c.regressed, c.unit, tt.verdict, tt.regressed, tt.unit)
+			}
+		})
+	}
+
+	// 相对变化以基线的百分比输出
+	c := judge(p99, est(200), est(220), 5, 0.1)
+	if got := c.diff * c.scale; math.Abs(got-10) > 1e-9 {
+		t.Fatalf("相对变化 = %v%%, want 10%%", got)
+	}
+}
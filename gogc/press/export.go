@@ -0,0 +1,178 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runResult 一次压测的结果，-json-out输出的内容，也是compare命令读取的格式
+type runResult struct {
+	// 负载类型或场景名称
+	Name string `json:"name"`
+	// -label指定的标签，例如gogc=200
+	Labels map[string]string `json:"labels,omitempty"`
+	Target string            `json:"target"`
+	Start  time.Time         `json:"start"`
+	End    time.Time         `json:"end"`
+	// 压测时长(秒)
+	Duration float64       `json:"duration_seconds"`
+	Requests requestCounts `json:"requests"`
+	// 每秒成功的请求数
+	Throughput float64 `json:"throughput_rps"`
+	// 失败、超时和丢弃的请求占比
+	ErrorRate float64 `json:"error_rate"`
+	// 得到响应的请求的延迟
+	Latency   latencySummary   `json:"latency"`
+	Endpoints []endpointResult `json:"endpoints"`
+	// 每秒的时间序列
+	Series []secondStats `json:"series"`
+}
+
+// endpointResult 按端点和状态码统计的延迟
+type endpointResult struct {
+	Endpoint string         `json:"endpoint"`
+	Status   string         `json:"status"`
+	Latency  latencySummary `json:"latency"`
+}
+
+// buildResult 汇总压测结果
+func buildResult(rep *reporter, series seriesSet, end time.Time) runResult {
+	res := runResult{
+		Name:     loadName,
+		Labels:   labels,
+		Target:   fmt.Sprintf("%s:%d", *host, *port),
+		Start:    rep.Start(),
+		End:      end,
+		Duration: end.Sub(rep.Start()).Seconds(),
+		Requests: loadCounts(),
+		Latency:  summarize(series.successful()),
+		Series:   rep.Points(),
+	}
+	if res.Duration > 0 {
+		res.Throughput = float64(res.Requests.Successful) / res.Duration
+	}
+	if res.Requests.Total > 0 {
+		res.ErrorRate = float64(res.Requests.Errors()) / float64(res.Requests.Total)
+	}
+	for _, key := range series.sortedKeys() {
+		res.Endpoints = append(res.Endpoints, endpointResult{
+			Endpoint: key.Endpoint,
+			Status:   key.Status,
+			Latency:  summarize(series[key]),
+		})
+	}
+	return res
+}
+
+// writeResultJSON 将结果写为JSON
+func writeResultJSON(path string, res runResult) error {
+	data, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(path, append(data, '\n'), 0o644)
+}
+
+// readResultJSON 读取-json-out输出的结果
+func readResultJSON(path string) (runResult, error) {
+	var res runResult
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return res, err
+	}
+	if err := json.Unmarshal(data, &res); err != nil {
+		return res, fmt.Errorf("解析%s失败: %w", path, err)
+	}
+	return res, nil
+}
+
+// 延迟摘要在CSV中的列
+var latencyColumns = []string{"count", "mean_ms", "min_ms", "p50_ms", "p90_ms", "p99_ms", "p99_9_ms", "max_ms"}
+
+func latencyRecord(l latencySummary) []string {
+	record := []string{strconv.FormatUint(l.Count, 10)}
+	for _, value := range []float64{l.MeanMs, l.MinMs, l.P50Ms, l.P90Ms, l.P99Ms, l.P999Ms, l.MaxMs} {
+		record = append(record, strconv.FormatFloat(value, 'f', 3, 64))
+	}
+	return record
+}
+
+// writeResultCSV 将每秒的时间序列写入path，同目录下的 <文件名>_summary.csv 为整体结果（指标,值），
+// <文件名>_endpoints.csv 为按端点和状态码的延迟
+func writeResultCSV(path string, res runResult) error {
+	header := append([]string{"time", "elapsed_seconds", "total", "successful", "failed", "timed_out", "dropped", "rps", "in_flight"}, latencyColumns...)
+	records := [][]string{header}
+	for _, p := range res.Series {
+		record := []string{
+			p.Time.Format(time.RFC3339Nano),
+			strconv.FormatFloat(p.Elapsed, 'f', 3, 64),
+			strconv.FormatInt(p.Total, 10),
+			strconv.FormatInt(p.Successful, 10),
+			strconv.FormatInt(p.Failed, 10),
+			strconv.FormatInt(p.TimedOut, 10),
+			strconv.FormatInt(p.Dropped, 10),
+			strconv.FormatFloat(p.RPS, 'f', 1, 64),
+			strconv.FormatInt(p.InFlight, 10),
+		}
+		records = append(records, append(record, latencyRecord(p.Latency)...))
+	}
+	if err := writeCSV(path, records); err != nil {
+		return err
+	}
+
+	summary := [][]string{
+		{"metric", "value"},
+		{"name", res.Name},
+		{"target", res.Target},
+		{"start", res.Start.Format(time.RFC3339Nano)},
+		{"end", res.End.Format(time.RFC3339Nano)},
+		{"duration_seconds", strconv.FormatFloat(res.Duration, 'f', 3, 64)},
+		{"total", strconv.FormatInt(res.Requests.Total, 10)},
+		{"successful", strconv.FormatInt(res.Requests.Successful, 10)},
+		{"failed", strconv.FormatInt(res.Requests.Failed, 10)},
+		{"timed_out", strconv.FormatInt(res.Requests.TimedOut, 10)},
+		{"dropped", strconv.FormatInt(res.Requests.Dropped, 10)},
+		{"throughput_rps", strconv.FormatFloat(res.Throughput, 'f', 3, 64)},
+		{"error_rate", strconv.FormatFloat(res.ErrorRate, 'f', 6, 64)},
+	}
+	for i, value := range latencyRecord(res.Latency) {
+		summary = append(summary, []string{"latency_" + latencyColumns[i], value})
+	}
+	for _, key := range sortedLabelKeys(res.Labels) {
+		summary = append(summary, []string{"label_" + key, res.Labels[key]})
+	}
+	if err := writeCSV(siblingPath(path, "_summary"), summary); err != nil {
+		return err
+	}
+
+	endpoints := [][]string{append([]string{"endpoint", "status"}, latencyColumns...)}
+	for _, ep := range res.Endpoints {
+		endpoints = append(endpoints, append([]string{ep.Endpoint, ep.Status}, latencyRecord(ep.Latency)...))
+	}
+	return writeCSV(siblingPath(path, "_endpoints"), endpoints)
+}
+
+// siblingPath 在文件名和扩展名之间加上后缀
+func siblingPath(path, suffix string) string {
+	ext := filepath.Ext(path)
+	return strings.TrimSuffix(path, ext) + suffix + ext
+}
+
+func writeCSV(path string, records [][]string) error {
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	w := csv.NewWriter(f)
+	if err := w.WriteAll(records); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
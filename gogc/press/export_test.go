@@ -0,0 +1,111 @@
+package main
+
+import (
+	"math/rand"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestResultJSONRoundTrip(t *testing.T) {
+	rng := rand.New(rand.NewSource(3))
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	res := runResult{
+		Name:     "mixed",
+		Labels:   map[string]string{"gogc": "200"},
+		Target:   "localhost:8080",
+		Start:    start,
+		End:      start.Add(3 * time.Second),
+		Duration: 3,
+		Requests: requestCounts{Total: 300, Successful: 290, Failed: 5, TimedOut: 3, Dropped: 2},
+	}
+	total := newHistogram()
+	for i := 0; i < 3; i++ {
+		h := newHistogram()
+		for j := 0; j < 100; j++ {
+			h.Record(time.Duration(rng.ExpFloat64() * float64(5*time.Millisecond)))
+		}
+		total.Merge(h)
+		res.Series = append(res.Series, secondStats{
+			Time:          start.Add(time.Duration(i+1) * time.Second),
+			Elapsed:       float64(i + 1),
+			requestCounts: requestCounts{Total: 100, Successful: 100},
+			RPS:           100,
+			InFlight:      int64(i),
+			Latency:       summarize(h),
+			Histogram:     h,
+		})
+	}
+	// 没有请求的一秒不输出直方图
+	res.Series = append(res.Series, secondStats{Time: start.Add(4 * time.Second), Elapsed: 4, Latency: summarize(newHistogram())})
+	res.Latency = summarize(total)
+	res.Endpoints = []endpointResult{{Endpoint: "/", Status: "200", Latency: res.Latency}}
+
+	path := filepath.Join(t.TempDir(), "result.json")
+	if err := writeResultJSON(path, res); err != nil {
+		t.Fatalf("writeResultJSON: %v", err)
+	}
+	got, err := readResultJSON(path)
+	if err != nil {
+		t.Fatalf("readResultJSON: %v", err)
+	}
+
+	if len(got.Series) != len(res.Series) {
+		t.Fatalf("时间序列长度 = %d, want %d", len(got.Series), len(res.Series))
+	}
+	for i := range res.Series {
+		want, h := res.Series[i].Histogram, got.Series[i].Histogram
+		if (want == nil) != (h == nil) {
+			t.Fatalf("第%d秒的直方图 = %v, want %v", i+1, h, want)
+		}
+		if want != nil {
+			assertHistogramEqual(t, h, want)
+		}
+		// 直方图已单独比较
+		got.Series[i].Histogram, res.Series[i].Histogram = nil, nil
+	}
+	if !reflect.DeepEqual(got, res) {
+		t.Fatalf("读回的结果 = %+v, want %+v", got, res)
+	}
+}
+
+func TestReadResultJSONErrors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := readResultJSON(filepath.Join(dir, "missing.json")); !os.IsNotExist(err) {
+		t.Fatalf("文件不存在时错误 = %v", err)
+	}
+
+	for _, content := range []string{`{"series": [`, `{"series": [{"histogram": {"buckets": [[100000, 1]]}}]}`} {
+		path := filepath.Join(dir, "bad.json")
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		if _, err := readResultJSON(path); err == nil || !strings.Contains(err.Error(), "解析") {
+			t.Fatalf("读取%s 错误 = %v, want 解析失败", content, err)
+		}
+	}
+}
+
+// assertHistogramEqual 比较两个直方图记录的分布
+func assertHistogramEqual(t *testing.T, got, want *histogram) {
+	t.Helper()
+	if got.Count() != want.Count() || got.Mean() != want.Mean() || got.Min() != want.Min() || got.Max() != want.Max() {
+		t.Fatalf("直方图 Count=%d Mean=%v Min=%v Max=%v, want %d %v %v %v",
+			got.Count(), got.Mean(), got.Min(), got.Max(), want.Count(), want.Mean(), want.Min(), want.Max())
+	}
+	for i := range max(len(got.counts), len(want.counts)) {
+		var g, w uint64
+		if i < len(got.counts) {
+			g = got.counts[i]
+		}
+		if i < len(want.counts) {
+			w = want.counts[i]
+		}
+		if g != w {
+			t.Fatalf("直方图第%d个桶 = %d, want %d", i, g, w)
+		}
+	}
+}
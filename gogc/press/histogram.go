@@ -0,0 +1,172 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/bits"
+	"time"
+)
+
+// 直方图精度：每个2的幂区间划分为 1<<(subBucketBits-1) 个子桶，相对误差小于 1/(1<<(subBucketBits-1))
+const (
+	subBucketBits  = 7
+	subBucketCount = 1 << subBucketBits  // 小于该值的延迟(纳秒)精确记录
+	subBucketHalf  = subBucketCount >> 1 // 之后每个2的幂区间的子桶数
+)
+
+// histogram HDR风格的对数线性延迟直方图，单位纳秒
+// 相对误差小于1/64，桶按需扩展；不是并发安全的，每个工作协程各自记录，汇总时合并
+type histogram struct {
+	counts []uint64
+	total  uint64
+	sum    int64
+	min    int64
+	max    int64
+}
+
+func newHistogram() *histogram {
+	return &histogram{min: math.MaxInt64}
+}
+
+// bucketIndex 延迟值对应的桶
+func bucketIndex(v int64) int {
+	if v < subBucketCount {
+		return int(v)
+	}
+	shift := bits.Len64(uint64(v)) - subBucketBits
+	top := int(v >> shift) // [subBucketHalf, subBucketCount)
+	return subBucketCount + (shift-1)*subBucketHalf + top - subBucketHalf
+}
+
+// bucketUpper 桶内的最大值
+func bucketUpper(index int) int64 {
+	if index < subBucketCount {
+		return int64(index)
+	}
+	shift := (index-subBucketCount)/subBucketHalf + 1
+	top := int64((index-subBucketCount)%subBucketHalf + subBucketHalf)
+	return (top+1)<<shift - 1
+}
+
+// Record 记录一次请求的延迟
+func (h *histogram) Record(d time.Duration) {
+	v := max(int64(d), 0)
+	index := bucketIndex(v)
+	if index >= len(h.counts) {
+		counts := make([]uint64, index+1)
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	h.counts[index]++
+	h.total++
+	h.sum += v
+	h.min = min(h.min, v)
+	h.max = max(h.max, v)
+}
+
+// Merge 将other的记录合并进来
+func (h *histogram) Merge(other *histogram) {
+	if other.total == 0 {
+		return
+	}
+	if len(other.counts) > len(h.counts) {
+		counts := make([]uint64, len(other.counts))
+		copy(counts, h.counts)
+		h.counts = counts
+	}
+	for i, count := range other.counts {
+		h.counts[i] += count
+	}
+	h.total += other.total
+	h.sum += other.sum
+	h.min = min(h.min, other.min)
+	h.max = max(h.max, other.max)
+}
+
+// Count 记录的请求数
+func (h *histogram) Count() uint64 {
+	return h.total
+}
+
+// Mean 平均延迟
+func (h *histogram) Mean() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.sum / int64(h.total))
+}
+
+// Min 最小延迟
+func (h *histogram) Min() time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	return time.Duration(h.min)
+}
+
+// Max 最大延迟
+func (h *histogram) Max() time.Duration {
+	return time.Duration(h.max)
+}
+
+// Percentile 第q百分位(0-100)的延迟，返回所在桶的最大值，不超过实际的最大延迟
+func (h *histogram) Percentile(q float64) time.Duration {
+	if h.total == 0 {
+		return 0
+	}
+	rank := uint64(math.Ceil(q / 100 * float64(h.total)))
+	rank = min(max(rank, 1), h.total)
+
+	var seen uint64
+	for i, count := range h.counts {
+		seen += count
+		if seen >= rank {
+			return time.Duration(min(bucketUpper(i), h.max))
+		}
+	}
+	return time.Duration(h.max)
+}
+
+// histogramJSON 直方图的JSON格式，buckets只包含非空的桶: [[桶序号, 请求数], ...]
+type histogramJSON struct {
+	SumNs   int64       `json:"sum_ns"`
+	MinNs   int64       `json:"min_ns"`
+	MaxNs   int64       `json:"max_ns"`
+	Buckets [][2]uint64 `json:"buckets"`
+}
+
+func (h *histogram) MarshalJSON() ([]byte, error) {
+	data := histogramJSON{SumNs: h.sum, MinNs: h.Min().Nanoseconds(), MaxNs: h.max, Buckets: [][2]uint64{}}
+	for i, count := range h.counts {
+		if count > 0 {
+			data.Buckets = append(data.Buckets, [2]uint64{uint64(i), count})
+		}
+	}
+	return json.Marshal(data)
+}
+
+func (h *histogram) UnmarshalJSON(b []byte) error {
+	var data histogramJSON
+	if err := json.Unmarshal(b, &data); err != nil {
+		return err
+	}
+	*h = *newHistogram()
+	for _, bucket := range data.Buckets {
+		index, count := bucket[0], bucket[1]
+		if index > uint64(bucketIndex(math.MaxInt64)) {
+			return fmt.Errorf("非法的直方图桶: %d", index)
+		}
+		if int(index) >= len(h.counts) {
+			counts := make([]uint64, index+1)
+			copy(counts, h.counts)
+			h.counts = counts
+		}
+		h.counts[index] += count
+		h.total += count
+	}
+	if h.total > 0 {
+		h.sum, h.min, h.max = data.SumNs, data.MinNs, data.MaxNs
+	}
+	return nil
+}
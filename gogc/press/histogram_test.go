@@ -0,0 +1,136 @@
+package main
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+	"time"
+)
+
+func TestBucketIndex(t *testing.T) {
+	tests := []struct {
+		v     int64
+		index int
+		upper int64
+	}{
+		// 小于subBucketCount的值精确记录
+		{0, 0, 0},
+		{1, 1, 1},
+		{127, 127, 127},
+		// 之后每个2的幂区间64个子桶，[128, 256)的桶宽为2
+		{128, 128, 129},
+		{129, 128, 129},
+		{130, 129, 131},
+		{255, 191, 255},
+		// [256, 512)的桶宽为4
+		{256, 192, 259},
+		{259, 192, 259},
+		{260, 193, 263},
+		{511, 255, 511},
+		{512, 256, 519},
+	}
+	for _, tt := range tests {
+		if got := bucketIndex(tt.v); got != tt.index {
+			t.Errorf("bucketIndex(%d) = %d, want %d", tt.v, got, tt.index)
+		}
+		if got := bucketUpper(tt.index); got != tt.upper {
+			t.Errorf("bucketUpper(%d) = %d, want %d", tt.index, got, tt.upper)
+		}
+	}
+	if got := bucketUpper(bucketIndex(math.MaxInt64)); got != math.MaxInt64 {
+		t.Errorf("最大桶上界 = %d, want MaxInt64", got)
+	}
+}
+
+func TestBucketRelativeError(t *testing.T) {
+	// 每个值落在 (上一个桶的上界, 所在桶的上界] 内，相对误差小于1/64
+	rng := rand.New(rand.NewSource(1))
+	for i := 0; i < 100000; i++ {
+		v := rng.Int63n(int64(time.Minute))
+		if i%2 == 0 {
+			v = rng.Int63n(1 << 12)
+		}
+		index := bucketIndex(v)
+		upper := bucketUpper(index)
+		if upper < v || (index > 0 && bucketUpper(index-1) >= v) {
+			t.Fatalf("值%d 落在桶%d (%d, %d]之外", v, index, bucketUpper(index-1), upper)
+		}
+		if float64(upper-v) > float64(v)/subBucketHalf {
+			t.Fatalf("值%d 的桶上界%d 相对误差超过1/%d", v, upper, subBucketHalf)
+		}
+	}
+}
+
+func TestHistogramPercentile(t *testing.T) {
+	h := newHistogram()
+	if h.Percentile(99) != 0 || h.Mean() != 0 || h.Min() != 0 || h.Max() != 0 {
+		t.Fatal("空直方图的统计值应为0")
+	}
+
+	for i := 1; i <= 1000; i++ {
+		h.Record(time.Duration(i) * time.Millisecond)
+	}
+	if h.Count() != 1000 || h.Min() != time.Millisecond || h.Max() != time.Second || h.Mean() != 500500*time.Microsecond {
+		t.Fatalf("Count=%d Min=%v Max=%v Mean=%v", h.Count(), h.Min(), h.Max(), h.Mean())
+	}
+	tests := []struct {
+		q    float64
+		want time.Duration
+	}{
+		{0, time.Millisecond},
+		{50, 500 * time.Millisecond},
+		{90, 900 * time.Millisecond},
+		{99, 990 * time.Millisecond},
+		{99.9, 999 * time.Millisecond},
+		// 不超过实际的最大延迟
+		{100, time.Second},
+	}
+	for _, tt := range tests {
+		got := h.Percentile(tt.q)
+		if got < tt.want || float64(got-tt.want) > float64(tt.want)/subBucketHalf {
+			t.Errorf("p%v = %v, want [%v, %v]", tt.q, got, tt.want, tt.want+tt.want/subBucketHalf)
+		}
+	}
+
+	// 负的延迟按0记录
+	h = newHistogram()
+	h.Record(-time.Second)
+	if h.Min() != 0 || h.Percentile(50) != 0 {
+		t.Fatalf("负延迟 Min=%v p50=%v", h.Min(), h.Percentile(50))
+	}
+}
+
+func TestHistogramMerge(t *testing.T) {
+	rng := rand.New(rand.NewSource(2))
+	all, a, b := newHistogram(), newHistogram(), newHistogram()
+	for i := 0; i < 10000; i++ {
+		d := time.Duration(rng.ExpFloat64() * float64(20*time.Millisecond))
+		all.Record(d)
+		// b的桶更多，合并时需要扩展a
+		if d < 10*time.Millisecond {
+			a.Record(d)
+		} else {
+			b.Record(d)
+		}
+	}
+
+	merged := newHistogram()
+	merged.Merge(newHistogram())
+	merged.Merge(a)
+	merged.Merge(b)
+	if merged.Count() != all.Count() || merged.Mean() != all.Mean() || merged.Min() != all.Min() || merged.Max() != all.Max() {
+		t.Fatalf("合并后 Count=%d Mean=%v Min=%v Max=%v, want %d %v %v %v",
+			merged.Count(), merged.Mean(), merged.Min(), merged.Max(), all.Count(), all.Mean(), all.Min(), all.Max())
+	}
+	for _, q := range []float64{1, 50, 90, 99, 99.9} {
+		if merged.Percentile(q) != all.Percentile(q) {
+			t.Fatalf("合并后 p%v = %v, want %v", q, merged.Percentile(q), all.Percentile(q))
+		}
+	}
+
+	// 合并空直方图不改变最小值
+	a.Merge(newHistogram())
+	if a.Min() != all.Min() {
+		t.Fatalf("合并空直方图后 Min = %v", a.Min())
+	}
+}
@@ -0,0 +1,95 @@
+package main
+
+import (
+	"log"
+	"math"
+	"math/rand"
+	"time"
+)
+
+// rateFunc 返回压测开始后elapsed时刻的目标每秒请求数
+// 只由调度协程调用，可以保存状态
+type rateFunc func(elapsed time.Duration) float64
+
+// newRateFunc 根据负载类型创建目标速率
+func newRateFunc(loadType string, rps float64) rateFunc {
+	switch loadType {
+	case "constant":
+		log.Println("启动固定负载模式")
+		return constantRate(rps)
+	case "wave":
+		log.Println("启动波动负载模式")
+		// 与原来的波动负载控制器一致：rps * (0.5 + 0.5*sin)，在0到基础RPS之间波动
+		return waveRate(rps*0.5, 1, 30*time.Second)
+	case "spike":
+		log.Println("启动尖刺负载模式")
+		return spikeRate(rps, rps*5, 0, 0)
+	default:
+		log.Printf("未知的负载类型: %s, 使用默认的固定负载", loadType)
+		return constantRate(rps)
+	}
+}
+
+// 固定负载
+func constantRate(rps float64) rateFunc {
+	return func(time.Duration) float64 {
+		return rps
+	}
+}
+
+// 线性加压：在d内从from匀速变化到to
+func rampRate(from, to float64, d time.Duration) rateFunc {
+	return func(elapsed time.Duration) float64 {
+		progress := min(float64(elapsed)/float64(d), 1)
+		return from + (to-from)*progress
+	}
+}
+
+// 波动负载：以period为周期，在基础RPS的(1-amplitude)到(1+amplitude)倍之间按正弦波动
+func waveRate(rps, amplitude float64, period time.Duration) rateFunc {
+	return func(elapsed time.Duration) float64 {
+		position := float64(elapsed%period) / float64(period)
+		return rps * (1 + amplitude*math.Sin(position*2*math.Pi))
+	}
+}
+
+// 尖刺负载：大部分时间保持基础RPS，每隔every出现一次持续length、速率为spikeRPS的尖刺
+// every为0时间隔为10-30秒的随机值（第一次在10秒后），length为0时持续2-4秒的随机值
+func spikeRate(rps, spikeRPS float64, every, length time.Duration) rateFunc {
+	interval := func() time.Duration {
+		if every > 0 {
+			return every
+		}
+		return time.Duration(10+rand.Intn(20)) * time.Second
+	}
+	isSpike := false
+	nextSpike := every
+	if nextSpike <= 0 {
+		nextSpike = 10 * time.Second
+	}
+	var spikeEnd time.Duration
+
+	return func(elapsed time.Duration) float64 {
+		if !isSpike && elapsed >= nextSpike {
+			// 开始一个尖刺
+			isSpike = true
+			spikeDuration := length
+			if spikeDuration <= 0 {
+				spikeDuration = time.Duration(2+rand.Intn(3)) * time.Second
+			}
+			spikeEnd = elapsed + spikeDuration
+			log.Printf("触发负载尖刺! 持续 %v", spikeDuration)
+		} else if isSpike && elapsed >= spikeEnd {
+			// 结束尖刺状态
+			isSpike = false
+			wait := interval()
+			nextSpike = elapsed + wait
+			log.Printf("尖刺结束，下一次尖刺在 %v 秒后", wait.Seconds())
+		}
+
+		if isSpike {
+			return spikeRPS
+		}
+		return rps
+	}
+}
@@ -0,0 +1,131 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/prometheus/client_golang/prometheus/push"
+)
+
+// pushJob 推送到Pushgateway时使用的job
+const pushJob = "gogc_press"
+
+// pressMetrics 压测端的Prometheus指标
+// 使用独立的Registry，不导出压测进程自身的go_*指标，避免与被测服务的GC面板混在一起
+type pressMetrics struct {
+	registry  *prometheus.Registry
+	requests  *prometheus.CounterVec
+	duration  *prometheus.HistogramVec
+	dropped   prometheus.Counter
+	inFlight  prometheus.Gauge
+	targetRPS prometheus.Gauge
+}
+
+func newPressMetrics() *pressMetrics {
+	m := &pressMetrics{
+		registry: prometheus.NewRegistry(),
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "press_requests_total",
+			Help: "压测发送的请求数，status为HTTP状态码或error/timeout",
+		}, []string{"endpoint", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "press_request_duration_seconds",
+			Help:    "从预定开始时间计算的请求延迟(秒)，只包含得到响应的请求",
+			Buckets: prometheus.ExponentialBuckets(0.001, 2, 15),
+		}, []string{"endpoint"}),
+		dropped: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "press_dropped_requests_total",
+			Help: "因达到并发上限未发送的请求数",
+		}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "press_in_flight_requests",
+			Help: "进行中的请求数",
+		}),
+		targetRPS: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "press_target_rps",
+			Help: "当前的目标每秒请求数",
+		}),
+	}
+	m.registry.MustRegister(m.requests, m.duration, m.dropped, m.inFlight, m.targetRPS)
+	return m
+}
+
+// observe 记录一次请求
+func (m *pressMetrics) observe(endpoint, status string, d time.Duration) {
+	m.requests.WithLabelValues(endpoint, status).Inc()
+	if status != statusError && status != statusTimeout {
+		m.duration.WithLabelValues(endpoint).Observe(d.Seconds())
+	}
+}
+
+// Serve 在addr上提供/metrics，供Prometheus抓取
+func (m *pressMetrics) Serve(addr string) {
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{}))
+	go func() {
+		log.Printf("压测指标地址: http://%s/metrics", addr)
+		if err := http.ListenAndServe(addr, mux); err != nil {
+			log.Printf("压测指标服务退出: %v", err)
+		}
+	}()
+}
+
+// Pusher 推送到url指定的Pushgateway，标签作为分组标签
+func (m *pressMetrics) Pusher(url string, labels map[string]string) *push.Pusher {
+	pusher := push.New(url, pushJob).Gatherer(m.registry)
+	for _, key := range sortedLabelKeys(labels) {
+		pusher = pusher.Grouping(key, labels[key])
+	}
+	return pusher
+}
+
+// pushLoop 每interval推送一次，直到stop关闭
+func pushLoop(pusher *push.Pusher, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ticker.C:
+			if err := pusher.Push(); err != nil {
+				log.Printf("推送指标失败: %v", err)
+			}
+		case <-stop:
+			return
+		}
+	}
+}
+
+// labelFlag 可重复的 -label key=value 参数
+type labelFlag map[string]string
+
+func (l labelFlag) String() string {
+	var pairs []string
+	for _, key := range sortedLabelKeys(l) {
+		pairs = append(pairs, key+"="+l[key])
+	}
+	return strings.Join(pairs, ",")
+}
+
+func (l labelFlag) Set(value string) error {
+	key, val, ok := strings.Cut(value, "=")
+	if !ok || key == "" {
+		return fmt.Errorf("标签格式应为 key=value: %s", value)
+	}
+	l[key] = val
+	return nil
+}
+
+func sortedLabelKeys(labels map[string]string) []string {
+	keys := make([]string, 0, len(labels))
+	for key := range labels {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
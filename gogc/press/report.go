@@ -0,0 +1,215 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 控制台输出统计的间隔，时间序列按秒采样
+const (
+	reportInterval = 5 * time.Second
+	sampleInterval = time.Second
+)
+
+// requestCounts 各类请求数
+type requestCounts struct {
+	// 按计划应发送的请求数，包含被丢弃的请求
+	Total      int64 `json:"total"`
+	Successful int64 `json:"successful"`
+	Failed     int64 `json:"failed"`
+	TimedOut   int64 `json:"timed_out"`
+	Dropped    int64 `json:"dropped"`
+}
+
+// loadCounts 读取当前的累计请求数
+func loadCounts() requestCounts {
+	return requestCounts{
+		Total:      atomic.LoadInt64(&totalRequests),
+		Successful: atomic.LoadInt64(&successfulRequests),
+		Failed:     atomic.LoadInt64(&failedRequests),
+		TimedOut:   atomic.LoadInt64(&timedOutRequests),
+		Dropped:    atomic.LoadInt64(&droppedRequests),
+	}
+}
+
+func (c requestCounts) sub(other requestCounts) requestCounts {
+	return requestCounts{
+		Total:      c.Total - other.Total,
+		Successful: c.Successful - other.Successful,
+		Failed:     c.Failed - other.Failed,
+		TimedOut:   c.TimedOut - other.TimedOut,
+		Dropped:    c.Dropped - other.Dropped,
+	}
+}
+
+// Errors 失败、超时和丢弃的请求数
+func (c requestCounts) Errors() int64 {
+	return c.Failed + c.TimedOut + c.Dropped
+}
+
+// latencySummary 延迟分布的摘要，单位毫秒
+type latencySummary struct {
+	Count  uint64  `json:"count"`
+	MeanMs float64 `json:"mean_ms"`
+	MinMs  float64 `json:"min_ms"`
+	P50Ms  float64 `json:"p50_ms"`
+	P90Ms  float64 `json:"p90_ms"`
+	P99Ms  float64 `json:"p99_ms"`
+	P999Ms float64 `json:"p99_9_ms"`
+	MaxMs  float64 `json:"max_ms"`
+}
+
+func summarize(h *histogram) latencySummary {
+	ms := func(d time.Duration) float64 {
+		return float64(d) / float64(time.Millisecond)
+	}
+	return latencySummary{
+		Count:  h.Count(),
+		MeanMs: ms(h.Mean()),
+		MinMs:  ms(h.Min()),
+		P50Ms:  ms(h.Percentile(50)),
+		P90Ms:  ms(h.Percentile(90)),
+		P99Ms:  ms(h.Percentile(99)),
+		P999Ms: ms(h.Percentile(99.9)),
+		MaxMs:  ms(h.Max()),
+	}
+}
+
+// secondStats 时间序列中的一个采样点，统计上一个采样点以来的请求
+type secondStats struct {
+	Time time.Time `json:"time"`
+	// 距压测开始的秒数
+	Elapsed float64 `json:"elapsed_seconds"`
+	requestCounts
+	// 按计划发送请求的速率，包含被丢弃的请求
+	RPS      float64 `json:"rps"`
+	InFlight int64   `json:"in_flight"`
+	// 得到响应的请求的延迟
+	Latency latencySummary `json:"latency"`
+	// 延迟直方图，compare命令据此合并任意时间段的延迟分布
+	Histogram *histogram `json:"histogram,omitempty"`
+}
+
+// reporter 每秒采样一次时间序列，每reportInterval在控制台输出一次统计
+type reporter struct {
+	sched *scheduler
+	start time.Time
+
+	mu       sync.Mutex
+	points   []secondStats
+	last     requestCounts
+	lastTime time.Time
+	// 控制台输出周期内的延迟和起始时的请求数
+	window      *histogram
+	windowStart time.Time
+	windowLast  requestCounts
+
+	done    chan struct{}
+	stopped chan struct{}
+}
+
+func newReporter(sched *scheduler) *reporter {
+	now := time.Now()
+	return &reporter{
+		sched:       sched,
+		start:       now,
+		lastTime:    now,
+		window:      newHistogram(),
+		windowStart: now,
+		done:        make(chan struct{}),
+		stopped:     make(chan struct{}),
+	}
+}
+
+// Run 定时采样和输出，直到调用Stop
+func (r *reporter) Run() {
+	defer close(r.stopped)
+
+	ticker := time.NewTicker(sampleInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case now := <-ticker.C:
+			r.sample(now)
+			if now.Sub(r.windowStart) >= reportInterval-sampleInterval/2 {
+				r.print(now)
+			}
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// Stop 停止定时采样，并为最后不足一秒的请求补充一个采样点
+func (r *reporter) Stop() {
+	close(r.done)
+	<-r.stopped
+	r.sample(time.Now())
+}
+
+// Points 时间序列
+func (r *reporter) Points() []secondStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]secondStats(nil), r.points...)
+}
+
+// Start 压测开始时间
+func (r *reporter) Start() time.Time {
+	return r.start
+}
+
+func (r *reporter) sample(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	latency := latencies.Collect().successful()
+	counts := loadCounts()
+	delta := counts.sub(r.last)
+	point := secondStats{
+		Time:          now,
+		Elapsed:       now.Sub(r.start).Seconds(),
+		requestCounts: delta,
+		InFlight:      r.sched.InFlight(),
+		Latency:       summarize(latency),
+		Histogram:     latency,
+	}
+	if seconds := now.Sub(r.lastTime).Seconds(); seconds > 0 {
+		point.RPS = float64(delta.Total) / seconds
+	}
+	r.points = append(r.points, point)
+	r.window.Merge(latency)
+	r.last, r.lastTime = counts, now
+}
+
+// print 输出本周期的统计
+func (r *reporter) print(now time.Time) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	counts := r.last
+	currentRPS := float64(counts.Total-r.windowLast.Total) / now.Sub(r.windowStart).Seconds()
+	var successRate float64
+	if counts.Total > 0 {
+		successRate = float64(counts.Successful) / float64(counts.Total) * 100
+	}
+
+	fmt.Printf("[%s] 负载类型: %s, RPS: %.1f, 并发: %d, 成功率: %.1f%%, 平均延迟: %s, %s, 总请求: %d (成功: %d, 失败: %d, 超时: %d, 丢弃: %d)\n",
+		now.Format("15:04:05"),
+		loadName,
+		currentRPS,
+		r.sched.InFlight(),
+		successRate,
+		formatLatency(r.window.Mean()),
+		formatPercentiles(r.window),
+		counts.Total,
+		counts.Successful,
+		counts.Failed,
+		counts.TimedOut,
+		counts.Dropped)
+
+	r.window = newHistogram()
+	r.windowStart, r.windowLast = now, counts
+}
@@ -0,0 +1,256 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"net/http"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// 场景中的阶段类型
+const (
+	phaseRampUp   = "ramp-up"
+	phaseConstant = "constant"
+	phaseWave     = "wave"
+	phaseSpike    = "spike"
+	phaseSoak     = "soak"
+)
+
+// scenario 压测场景：请求的端点组合和按顺序执行的负载阶段
+type scenario struct {
+	Name      string     `json:"name"`
+	Endpoints []endpoint `json:"endpoints"`
+	Phases    []phase    `json:"phases"`
+}
+
+// endpoint 被请求的端点，按权重随机选择
+type endpoint struct {
+	// 统计中显示的名称，默认为路径（非GET请求为 "方法 路径"）
+	Name    string            `json:"name"`
+	Path    string            `json:"path"`
+	Method  string            `json:"method"`
+	Headers map[string]string `json:"headers"`
+	Body    string            `json:"body"`
+	// 相对权重，默认1
+	Weight float64 `json:"weight"`
+	// 请求完成后工作协程的停顿时间，期间不处理新请求，模拟客户端处理响应的耗时
+	ThinkTime jsonDuration `json:"think_time"`
+}
+
+// phase 负载阶段
+type phase struct {
+	// 阶段类型: ramp-up|constant|wave|spike|soak
+	Type     string       `json:"type"`
+	Duration jsonDuration `json:"duration"`
+	// 目标RPS：ramp-up为结束时的速率，wave和spike为基础速率
+	RPS float64 `json:"rps"`
+	// ramp-up开始时的速率，默认为上一阶段的速率，第一个阶段为0
+	FromRPS *float64 `json:"from_rps"`
+	// wave的波动幅度(0-1)，默认0.5，为0时速率固定
+	Amplitude *float64 `json:"amplitude"`
+	// wave的周期，默认30秒
+	Period jsonDuration `json:"period"`
+	// spike的尖刺速率，默认为基础速率的5倍
+	SpikeRPS float64 `json:"spike_rps"`
+	// spike的尖刺间隔和持续时间，默认分别为10-30秒和2-4秒的随机值
+	SpikeEvery  jsonDuration `json:"spike_every"`
+	SpikeLength jsonDuration `json:"spike_length"`
+}
+
+// jsonDuration 场景文件中的时长，可以写为 "30s"、"2m" 或秒数
+type jsonDuration time.Duration
+
+func (d *jsonDuration) UnmarshalJSON(data []byte) error {
+	var value any
+	if err := json.Unmarshal(data, &value); err != nil {
+		return err
+	}
+	switch value := value.(type) {
+	case float64:
+		*d = jsonDuration(value * float64(time.Second))
+	case string:
+		parsed, err := time.ParseDuration(value)
+		if err != nil {
+			return err
+		}
+		*d = jsonDuration(parsed)
+	case nil:
+		*d = 0
+	default:
+		return fmt.Errorf("非法的时长: %s", data)
+	}
+	return nil
+}
+
+func (d jsonDuration) String() string {
+	return time.Duration(d).String()
+}
+
+// loadScenario 读取YAML或JSON格式的场景文件，.json后缀或以{开头的文件按JSON解析
+func loadScenario(path string) (*scenario, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	if filepath.Ext(path) != ".json" && !bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		value, err := parseYAML(data)
+		if err != nil {
+			return nil, err
+		}
+		// 普通标量按字段类型转换，避免 X-Api-Version: 2 这样的请求头无法解码为字符串
+		if data, err = json.Marshal(resolveScalars(value, reflect.TypeOf(scenario{}))); err != nil {
+			return nil, err
+		}
+	}
+
+	var sc scenario
+	decoder := json.NewDecoder(bytes.NewReader(data))
+	decoder.DisallowUnknownFields()
+	if err := decoder.Decode(&sc); err != nil {
+		return nil, fmt.Errorf("解析场景失败: %w", err)
+	}
+	if sc.Name == "" {
+		sc.Name = strings.TrimSuffix(filepath.Base(path), filepath.Ext(path))
+	}
+	if err := sc.validate(); err != nil {
+		return nil, err
+	}
+	return &sc, nil
+}
+
+// validate 检查场景并填充默认值
+func (sc *scenario) validate() error {
+	if len(sc.Endpoints) == 0 {
+		sc.Endpoints = defaultEndpoints()
+	}
+	for i := range sc.Endpoints {
+		ep := &sc.Endpoints[i]
+		if !strings.HasPrefix(ep.Path, "/") {
+			return fmt.Errorf("第%d个端点: 路径必须以/开头: %q", i+1, ep.Path)
+		}
+		ep.Method = strings.ToUpper(ep.Method)
+		if ep.Method == "" {
+			ep.Method = http.MethodGet
+		}
+		if ep.Name == "" {
+			ep.Name = ep.Path
+			if ep.Method != http.MethodGet {
+				ep.Name = ep.Method + " " + ep.Path
+			}
+		}
+		if ep.Weight < 0 {
+			return fmt.Errorf("第%d个端点: 权重不能为负数", i+1)
+		}
+		if ep.Weight == 0 {
+			ep.Weight = 1
+		}
+		if ep.ThinkTime < 0 {
+			return fmt.Errorf("第%d个端点: think_time不能为负数", i+1)
+		}
+	}
+
+	if len(sc.Phases) == 0 {
+		return fmt.Errorf("场景至少需要一个阶段")
+	}
+	for i := range sc.Phases {
+		ph := &sc.Phases[i]
+		if ph.Duration <= 0 {
+			return fmt.Errorf("第%d个阶段: duration必须大于0", i+1)
+		}
+		if ph.RPS <= 0 {
+			return fmt.Errorf("第%d个阶段: rps必须大于0", i+1)
+		}
+		switch ph.Type {
+		case phaseConstant, phaseSoak:
+		case phaseRampUp:
+			if ph.FromRPS == nil {
+				from := 0.0
+				if i > 0 {
+					from = sc.Phases[i-1].RPS
+				}
+				ph.FromRPS = &from
+			}
+			if *ph.FromRPS < 0 {
+				return fmt.Errorf("第%d个阶段: from_rps不能为负数", i+1)
+			}
+		case phaseWave:
+			if ph.Amplitude == nil {
+				amplitude := 0.5
+				ph.Amplitude = &amplitude
+			}
+			if *ph.Amplitude < 0 || *ph.Amplitude > 1 {
+				return fmt.Errorf("第%d个阶段: amplitude必须在0-1之间", i+1)
+			}
+			if ph.Period <= 0 {
+				ph.Period = jsonDuration(30 * time.Second)
+			}
+		case phaseSpike:
+			if ph.SpikeRPS <= 0 {
+				ph.SpikeRPS = ph.RPS * 5
+			}
+		default:
+			return fmt.Errorf("第%d个阶段: 未知的类型 %q，可选 ramp-up/constant/wave/spike/soak", i+1, ph.Type)
+		}
+	}
+	return nil
+}
+
+// Duration 所有阶段的总时长
+func (sc *scenario) Duration() time.Duration {
+	var total time.Duration
+	for _, ph := range sc.Phases {
+		total += time.Duration(ph.Duration)
+	}
+	return total
+}
+
+// rateFunc 按阶段顺序组合的目标速率，所有阶段结束后为0
+func (sc *scenario) rateFunc() rateFunc {
+	rates := make([]rateFunc, len(sc.Phases))
+	for i, ph := range sc.Phases {
+		rates[i] = ph.rateFunc()
+	}
+
+	current := -1
+	var start time.Duration
+	return func(elapsed time.Duration) float64 {
+		// 进入新的阶段
+		for current+1 < len(sc.Phases) && (current < 0 || elapsed >= start+time.Duration(sc.Phases[current].Duration)) {
+			if current >= 0 {
+				start += time.Duration(sc.Phases[current].Duration)
+			}
+			current++
+			ph := sc.Phases[current]
+			log.Printf("进入阶段 %d/%d: %s, 持续 %v, 目标RPS %.0f", current+1, len(sc.Phases), ph.Type, ph.Duration, ph.RPS)
+		}
+		if elapsed >= start+time.Duration(sc.Phases[current].Duration) {
+			return 0
+		}
+		return rates[current](elapsed - start)
+	}
+}
+
+func (ph phase) rateFunc() rateFunc {
+	switch ph.Type {
+	case phaseRampUp:
+		return rampRate(*ph.FromRPS, ph.RPS, time.Duration(ph.Duration))
+	case phaseWave:
+		return waveRate(ph.RPS, *ph.Amplitude, time.Duration(ph.Period))
+	case phaseSpike:
+		return spikeRate(ph.RPS, ph.SpikeRPS, time.Duration(ph.SpikeEvery), time.Duration(ph.SpikeLength))
+	default:
+		return constantRate(ph.RPS)
+	}
+}
+
+// defaultEndpoints 未使用场景文件时请求的端点
+func defaultEndpoints() []endpoint {
+	return []endpoint{{Name: "/", Path: "/", Method: http.MethodGet, Weight: 1}}
+}
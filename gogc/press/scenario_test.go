@@ -0,0 +1,162 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestLoadScenario(t *testing.T) {
+	tests := []struct {
+		name    string
+		file    string
+		content string
+		check   func(t *testing.T, sc *scenario)
+	}{
+		{
+			name:    "YAML默认值",
+			file:    "basic.yaml",
+			content: "phases:\n  - {type: constant, duration: 10s, rps: 100}\n  - {type: ramp-up, duration: 1m, rps: 200}\n  - {type: wave, duration: 30, rps: 50}\n",
+			check: func(t *testing.T, sc *scenario) {
+				if sc.Name != "basic" {
+					t.Errorf("Name = %q, want basic", sc.Name)
+				}
+				if len(sc.Endpoints) != 1 || sc.Endpoints[0].Path != "/" || sc.Endpoints[0].Weight != 1 {
+					t.Errorf("Endpoints = %+v, want 默认端点", sc.Endpoints)
+				}
+				if got := *sc.Phases[1].FromRPS; got != 100 {
+					t.Errorf("from_rps = %v, want 上一阶段的100", got)
+				}
+				wave := sc.Phases[2]
+				if time.Duration(wave.Duration) != 30*time.Second || *wave.Amplitude != 0.5 || time.Duration(wave.Period) != 30*time.Second {
+					t.Errorf("wave = duration %v amplitude %v period %v, want 30s 0.5 30s", wave.Duration, *wave.Amplitude, wave.Period)
+				}
+				if got := sc.Duration(); got != 100*time.Second {
+					t.Errorf("Duration = %v, want 1m40s", got)
+				}
+			},
+		},
+		{
+			name:    "端点",
+			file:    "endpoints.yml",
+			content: "name: api\nendpoints:\n  - path: /a\n    method: post\n    headers: {Content-Type: application/json}\n    body: |\n      {\"id\": 1}\n    think_time: 50ms\n  - {path: /b, name: B, weight: 3}\nphases:\n  - {type: soak, duration: 1m, rps: 10}\n",
+			check: func(t *testing.T, sc *scenario) {
+				if sc.Name != "api" {
+					t.Errorf("Name = %q, want api", sc.Name)
+				}
+				a, b := sc.Endpoints[0], sc.Endpoints[1]
+				if a.Name != "POST /a" || a.Method != "POST" || a.Headers["Content-Type"] != "application/json" ||
+					a.Body != "{\"id\": 1}\n" || time.Duration(a.ThinkTime) != 50*time.Millisecond || a.Weight != 1 {
+					t.Errorf("第1个端点 = %+v", a)
+				}
+				if b.Name != "B" || b.Method != "GET" || b.Weight != 3 {
+					t.Errorf("第2个端点 = %+v", b)
+				}
+			},
+		},
+		{
+			name:    "数字和布尔值写在字符串字段中",
+			file:    "scalars.yaml",
+			content: "endpoints:\n  - path: /v\n    method: put\n    headers: {X-Api-Version: 2, X-Ratio: 0.50, X-Debug: true}\n    body: true\n  - path: /n\n    name: 404\n    headers:\n      X-Retry: False\n    body: 1e3\nphases:\n  - {type: constant, duration: 1m, rps: 1}\n",
+			check: func(t *testing.T, sc *scenario) {
+				a, b := sc.Endpoints[0], sc.Endpoints[1]
+				want := map[string]string{"X-Api-Version": "2", "X-Ratio": "0.50", "X-Debug": "true"}
+				if !reflect.DeepEqual(a.Headers, want) || a.Body != "true" {
+					t.Errorf("第1个端点 Headers = %v Body = %q, want %v true", a.Headers, a.Body, want)
+				}
+				if b.Name != "404" || b.Headers["X-Retry"] != "False" || b.Body != "1e3" {
+					t.Errorf("第2个端点 Name = %q Headers = %v Body = %q, want 404 False 1e3", b.Name, b.Headers, b.Body)
+				}
+			},
+		},
+		{
+			name:    "amplitude为0时速率固定",
+			file:    "flat.yaml",
+			content: "phases:\n  - {type: wave, duration: 1m, rps: 100, amplitude: 0, period: 20s}\n",
+			check: func(t *testing.T, sc *scenario) {
+				if got := *sc.Phases[0].Amplitude; got != 0 {
+					t.Fatalf("amplitude = %v, want 0", got)
+				}
+				rate := sc.Phases[0].rateFunc()
+				for _, elapsed := range []time.Duration{0, 5 * time.Second, 15 * time.Second} {
+					if got := rate(elapsed); got != 100 {
+						t.Errorf("rate(%v) = %v, want 100", elapsed, got)
+					}
+				}
+			},
+		},
+		{
+			name:    "JSON",
+			file:    "smoke.json",
+			content: `{"phases": [{"type": "spike", "duration": "1m", "rps": 20}]}`,
+			check: func(t *testing.T, sc *scenario) {
+				if sc.Name != "smoke" || sc.Phases[0].SpikeRPS != 100 {
+					t.Errorf("Name = %q SpikeRPS = %v, want smoke 100", sc.Name, sc.Phases[0].SpikeRPS)
+				}
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sc, err := loadScenario(writeScenario(t, tt.file, tt.content))
+			if err != nil {
+				t.Fatalf("loadScenario: %v", err)
+			}
+			tt.check(t, sc)
+		})
+	}
+}
+
+func TestLoadScenarioErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"没有阶段", "name: x\n", "至少需要一个阶段"},
+		{"未知字段", "phases:\n  - {type: constant, duration: 1m, rps: 1, burst: 2}\n", "unknown field"},
+		{"未知类型", "phases:\n  - {type: burst, duration: 1m, rps: 1}\n", "未知的类型"},
+		{"非法时长", "phases:\n  - {type: constant, duration: soon, rps: 1}\n", "解析场景失败"},
+		{"数字字段为字符串", "phases:\n  - {type: constant, duration: 1m, rps: fast}\n", "解析场景失败"},
+		{"duration为0", "phases:\n  - {type: constant, duration: 0, rps: 1}\n", "duration必须大于0"},
+		{"rps为0", "phases:\n  - {type: constant, duration: 1m}\n", "rps必须大于0"},
+		{"amplitude超出范围", "phases:\n  - {type: wave, duration: 1m, rps: 1, amplitude: 1.5}\n", "amplitude必须在0-1之间"},
+		{"from_rps为负数", "phases:\n  - {type: ramp-up, duration: 1m, rps: 1, from_rps: -1}\n", "from_rps不能为负数"},
+		{"路径", "endpoints:\n  - path: a\nphases:\n  - {type: constant, duration: 1m, rps: 1}\n", "路径必须以/开头"},
+		{"权重为负数", "endpoints:\n  - {path: /, weight: -1}\nphases:\n  - {type: constant, duration: 1m, rps: 1}\n", "权重不能为负数"},
+		{"流式序列中的右花括号", "endpoints:\n  - path: /\n    headers: [x}\nphases:\n  - {type: constant, duration: 1m, rps: 1}\n", "应为 , 或 ]"},
+		{"流式映射未闭合", "phases:\n  - {type: constant, duration: 1m, rps: 1\n", "缺少 }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := loadScenario(writeScenario(t, "scenario.yaml", tt.content))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("loadScenario 错误 = %v, want 包含 %q", err, tt.err)
+			}
+		})
+	}
+}
+
+func TestExampleScenarios(t *testing.T) {
+	files, err := filepath.Glob("scenarios/*")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("没有找到示例场景: %v", err)
+	}
+	for _, file := range files {
+		if _, err := loadScenario(file); err != nil {
+			t.Errorf("loadScenario(%s): %v", file, err)
+		}
+	}
+}
+
+func writeScenario(t *testing.T, name, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), name)
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
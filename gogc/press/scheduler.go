@@ -0,0 +1,246 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/rand"
+	"net"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// 请求间隔超过该值时（低速率、加压开始阶段），按该间隔重新计算目标速率，累计满一个请求再发送
+const rateCheckInterval = 10 * time.Millisecond
+
+// scheduler 开环调度器：按目标速率为每个请求分配预定开始时间，不受服务端响应速度影响
+// 延迟从预定开始时间计算，服务端变慢时排队等待的时间也计入延迟，避免协调遗漏(coordinated omission)
+type scheduler struct {
+	client *http.Client
+	rate   rateFunc
+	// 按权重随机选择的端点，cumulative为累计权重
+	endpoints  []endpoint
+	cumulative []float64
+	// 最大并发请求数，0表示不限制；达到上限时新请求被丢弃
+	maxInFlight int
+
+	// 空闲的工作协程从这里领取请求的预定开始时间
+	work chan time.Time
+	// 进行中的请求数，调度时占用，请求完成时释放；思考中的工作协程不占用
+	inFlight int64
+	wg       sync.WaitGroup
+	// Run返回时关闭，之后不再启动新的工作协程
+	done chan struct{}
+}
+
+func newScheduler(client *http.Client, rate rateFunc, endpoints []endpoint, maxInFlight int) *scheduler {
+	s := &scheduler{
+		client:      client,
+		rate:        rate,
+		endpoints:   endpoints,
+		maxInFlight: maxInFlight,
+		work:        make(chan time.Time),
+		done:        make(chan struct{}),
+	}
+	var total float64
+	for _, ep := range endpoints {
+		total += ep.Weight
+		s.cumulative = append(s.cumulative, total)
+	}
+	return s
+}
+
+// Run 预先启动workers个工作协程，然后按目标速率调度请求直到stop关闭
+func (s *scheduler) Run(workers int) {
+	defer close(s.done)
+	if s.maxInFlight > 0 {
+		workers = min(workers, s.maxInFlight)
+	}
+	for i := 0; i < workers; i++ {
+		s.startWorker(time.Time{})
+	}
+
+	start := time.Now()
+	next := start
+	var credit float64
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+	for {
+		// 落后于计划时不等待，立即按预定时间补发
+		if wait := time.Until(next); wait > 0 {
+			timer.Reset(wait)
+			select {
+			case <-timer.C:
+			case <-stop:
+				return
+			}
+		} else {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+		}
+
+		rate := s.rate(next.Sub(start))
+		promMetrics.targetRPS.Set(rate)
+		if rate*rateCheckInterval.Seconds() < 1 {
+			credit += max(rate, 0) * rateCheckInterval.Seconds()
+			if credit >= 1 {
+				credit--
+				s.dispatch(next)
+			}
+			next = next.Add(rateCheckInterval)
+			continue
+		}
+		s.dispatch(next)
+		next = next.Add(time.Duration(float64(time.Second) / rate))
+	}
+}
+
+// Wait 等待调度协程退出和进行中的请求完成，需在stop关闭后调用
+// 先等待Run返回，保证不会在等待期间再启动工作协程
+func (s *scheduler) Wait() {
+	<-s.done
+	s.wg.Wait()
+}
+
+// InFlight 进行中的请求数
+func (s *scheduler) InFlight() int64 {
+	return atomic.LoadInt64(&s.inFlight)
+}
+
+// dispatch 占用一个并发名额后将请求交给空闲的工作协程，没有空闲时启动新的工作协程
+// 进行中的请求达到并发上限时丢弃
+func (s *scheduler) dispatch(intended time.Time) {
+	atomic.AddInt64(&totalRequests, 1)
+	// 先占用名额再交给工作协程，避免多个请求同时通过上限检查
+	if n := atomic.AddInt64(&s.inFlight, 1); s.maxInFlight > 0 && n > int64(s.maxInFlight) {
+		atomic.AddInt64(&s.inFlight, -1)
+		atomic.AddInt64(&droppedRequests, 1)
+		promMetrics.dropped.Inc()
+		return
+	}
+	promMetrics.inFlight.Inc()
+
+	select {
+	case s.work <- intended:
+		return
+	default:
+	}
+	// 已停止时工作协程会立即退出，不再启动
+	select {
+	case <-stop:
+		s.release()
+		return
+	default:
+	}
+	s.startWorker(intended)
+}
+
+// release 释放dispatch占用的并发名额
+func (s *scheduler) release() {
+	atomic.AddInt64(&s.inFlight, -1)
+	promMetrics.inFlight.Dec()
+}
+
+// startWorker 启动一个工作协程，first不为零值时先执行该请求
+func (s *scheduler) startWorker(first time.Time) {
+	stats := latencies.NewWorker()
+	s.wg.Add(1)
+	go func() {
+		defer s.wg.Done()
+
+		if !first.IsZero() {
+			s.think(s.do(first, stats))
+		}
+		for {
+			select {
+			case intended := <-s.work:
+				s.think(s.do(intended, stats))
+			case <-stop:
+				return
+			}
+		}
+	}()
+}
+
+// do 发送一个请求，完成后释放并发名额；延迟从预定开始时间计算，返回请求的端点
+func (s *scheduler) do(intended time.Time, stats *workerStats) *endpoint {
+	defer s.release()
+
+	ep := s.pick()
+	resp, err := s.send(ep)
+	if err == nil {
+		// 读完响应体，延迟包含传输时间，连接也能复用
+		_, err = io.Copy(io.Discard, resp.Body)
+		resp.Body.Close()
+	}
+	elapsed := time.Since(intended)
+
+	var status string
+	switch {
+	case err == nil:
+		atomic.AddInt64(&successfulRequests, 1)
+		status = strconv.Itoa(resp.StatusCode)
+	case isTimeout(err):
+		atomic.AddInt64(&timedOutRequests, 1)
+		status = statusTimeout
+	default:
+		atomic.AddInt64(&failedRequests, 1)
+		status = statusError
+	}
+	stats.Record(ep.Name, status, elapsed)
+	promMetrics.observe(ep.Name, status, elapsed)
+	return ep
+}
+
+// think 请求完成后按端点的思考时间等待，期间不计入进行中的请求
+func (s *scheduler) think(ep *endpoint) {
+	if ep.ThinkTime <= 0 {
+		return
+	}
+	select {
+	case <-time.After(time.Duration(ep.ThinkTime)):
+	case <-stop:
+	}
+}
+
+// pick 按权重随机选择一个端点
+func (s *scheduler) pick() *endpoint {
+	r := rand.Float64() * s.cumulative[len(s.cumulative)-1]
+	i := sort.SearchFloat64s(s.cumulative, r)
+	return &s.endpoints[min(i, len(s.endpoints)-1)]
+}
+
+// send 按端点的方法、请求头和请求体发送请求
+func (s *scheduler) send(ep *endpoint) (*http.Response, error) {
+	var body io.Reader
+	if ep.Body != "" {
+		body = strings.NewReader(ep.Body)
+	}
+	url := fmt.Sprintf("http://%s:%d%s", *host, *port, ep.Path)
+	req, err := http.NewRequest(ep.Method, url, body)
+	if err != nil {
+		return nil, err
+	}
+	for key, value := range ep.Headers {
+		if strings.EqualFold(key, "Host") {
+			req.Host = value
+			continue
+		}
+		req.Header.Set(key, value)
+	}
+	return s.client.Do(req)
+}
+
+// isTimeout 请求是否因超时失败
+func isTimeout(err error) bool {
+	var netErr net.Error
+	return errors.As(err, &netErr) && netErr.Timeout()
+}
@@ -0,0 +1,171 @@
+package main
+
+import (
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// newTestScheduler 重置全局的统计和stop，创建向srv发送请求的调度器
+func newTestScheduler(t *testing.T, srv *httptest.Server, client *http.Client, rate rateFunc, maxInFlight int, ep endpoint) *scheduler {
+	t.Helper()
+	addr := srv.Listener.Addr().(*net.TCPAddr)
+	*host, *port = addr.IP.String(), addr.Port
+	stop = make(chan struct{})
+	latencies = newLatencyStats()
+	totalRequests, successfulRequests, failedRequests, timedOutRequests, droppedRequests = 0, 0, 0, 0, 0
+	if client == nil {
+		client = srv.Client()
+	}
+	ep.Weight = 1
+	return newScheduler(client, rate, []endpoint{ep}, maxInFlight)
+}
+
+// runFor 按目标速率调度d时间后停止，等待进行中的请求完成前先调用beforeWait
+func runFor(s *scheduler, d time.Duration, beforeWait func()) {
+	go s.Run(1)
+	time.Sleep(d)
+	close(stop)
+	if beforeWait != nil {
+		beforeWait()
+	}
+	s.Wait()
+}
+
+// finishDispatched 停止直接调用dispatch的调度器并等待请求完成
+func finishDispatched(s *scheduler) {
+	close(stop)
+	// 没有运行Run，直接关闭done
+	close(s.done)
+	s.Wait()
+}
+
+func TestSchedulerLatencyFromIntendedStart(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	s := newTestScheduler(t, srv, nil, constantRate(1), 0, endpoint{Name: "/", Method: "GET", Path: "/"})
+
+	// 调度落后1秒时补发的请求，服务端立即响应，延迟仍包含落后的1秒
+	s.dispatch(time.Now().Add(-time.Second))
+	s.dispatch(time.Now())
+	finishDispatched(s)
+
+	h := latencies.Total()[seriesKey{Endpoint: "/", Status: "200"}]
+	if h == nil || h.Count() != 2 {
+		t.Fatalf("延迟记录 = %v, want 2个请求", h)
+	}
+	if h.Max() < time.Second || h.Min() >= time.Second {
+		t.Fatalf("延迟 min=%v max=%v, want 补发的请求不小于1s，按时发送的请求小于1s", h.Min(), h.Max())
+	}
+}
+
+func TestSchedulerDropsAtMaxInFlight(t *testing.T) {
+	var mu sync.Mutex
+	var current, peak int
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		current++
+		peak = max(peak, current)
+		mu.Unlock()
+		<-release
+		mu.Lock()
+		current--
+		mu.Unlock()
+	}))
+	defer srv.Close()
+	s := newTestScheduler(t, srv, nil, constantRate(200), 5, endpoint{Name: "/", Method: "GET", Path: "/"})
+
+	// 服务端不响应，并发达到上限后新请求全部丢弃
+	runFor(s, 300*time.Millisecond, func() { close(release) })
+
+	if successfulRequests != 5 || failedRequests != 0 || timedOutRequests != 0 {
+		t.Fatalf("成功=%d 失败=%d 超时=%d, want 5 0 0", successfulRequests, failedRequests, timedOutRequests)
+	}
+	if totalRequests < 20 || droppedRequests != totalRequests-5 {
+		t.Fatalf("总请求=%d 丢弃=%d, want 超过上限的请求全部丢弃", totalRequests, droppedRequests)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if peak != 5 || s.InFlight() != 0 {
+		t.Fatalf("服务端最大并发=%d 结束时进行中=%d, want 5 0", peak, s.InFlight())
+	}
+}
+
+func TestSchedulerThinkTimeNotInFlight(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer srv.Close()
+	// 每个工作协程请求后停顿10秒，思考中的工作协程不占用并发名额
+	ep := endpoint{Name: "/", Method: "GET", Path: "/", ThinkTime: jsonDuration(10 * time.Second)}
+	s := newTestScheduler(t, srv, nil, constantRate(20), 1, ep)
+
+	runFor(s, 300*time.Millisecond, nil)
+
+	if totalRequests < 3 || droppedRequests != 0 || successfulRequests != totalRequests {
+		t.Fatalf("总请求=%d 成功=%d 丢弃=%d, want 全部成功", totalRequests, successfulRequests, droppedRequests)
+	}
+}
+
+func TestSchedulerOutcomes(t *testing.T) {
+	tests := []struct {
+		name    string
+		handler http.HandlerFunc
+		// 成功、失败、超时的请求数
+		successful, failed, timedOut int64
+		status                       string
+	}{
+		{
+			name:       "非2xx状态码算成功",
+			handler:    func(w http.ResponseWriter, r *http.Request) { w.WriteHeader(http.StatusServiceUnavailable) },
+			successful: 3,
+			status:     "503",
+		},
+		{
+			name: "超时",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				select {
+				case <-r.Context().Done():
+				case <-time.After(5 * time.Second):
+				}
+			},
+			timedOut: 3,
+			status:   statusTimeout,
+		},
+		{
+			name: "连接被关闭",
+			handler: func(w http.ResponseWriter, r *http.Request) {
+				conn, _, err := w.(http.Hijacker).Hijack()
+				if err == nil {
+					conn.Close()
+				}
+			},
+			failed: 3,
+			status: statusError,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			srv := httptest.NewServer(tt.handler)
+			defer srv.Close()
+			client := srv.Client()
+			client.Timeout = 50 * time.Millisecond
+			s := newTestScheduler(t, srv, client, constantRate(1), 0, endpoint{Name: "/", Method: "GET", Path: "/"})
+
+			for range 3 {
+				s.dispatch(time.Now())
+			}
+			finishDispatched(s)
+
+			if successfulRequests != tt.successful || failedRequests != tt.failed || timedOutRequests != tt.timedOut {
+				t.Fatalf("成功=%d 失败=%d 超时=%d, want %d %d %d",
+					successfulRequests, failedRequests, timedOutRequests, tt.successful, tt.failed, tt.timedOut)
+			}
+			if h := latencies.Total()[seriesKey{Endpoint: "/", Status: tt.status}]; h == nil || h.Count() != 3 {
+				t.Fatalf("状态%s的延迟记录 = %v, want 3个请求", tt.status, h)
+			}
+		})
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"sync"
+	"text/tabwriter"
+	"time"
+)
+
+// 请求未得到响应时记录的状态
+const (
+	statusError   = "error"
+	statusTimeout = "timeout"
+)
+
+// 报告的延迟百分位
+var reportedPercentiles = []float64{50, 90, 99, 99.9}
+
+// seriesKey 延迟按端点和状态码分组
+type seriesKey struct {
+	Endpoint string
+	Status   string
+}
+
+// seriesSet 各分组的延迟直方图
+type seriesSet map[seriesKey]*histogram
+
+// merge 将other合并进来
+func (s seriesSet) merge(other seriesSet) {
+	for key, h := range other {
+		if s[key] == nil {
+			s[key] = newHistogram()
+		}
+		s[key].Merge(h)
+	}
+}
+
+// successful 所有得到响应的请求的延迟
+func (s seriesSet) successful() *histogram {
+	merged := newHistogram()
+	for key, h := range s {
+		if key.Status != statusError && key.Status != statusTimeout {
+			merged.Merge(h)
+		}
+	}
+	return merged
+}
+
+// sortedKeys 按端点、状态码排序的分组
+func (s seriesSet) sortedKeys() []seriesKey {
+	keys := make([]seriesKey, 0, len(s))
+	for key := range s {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(i, j int) bool {
+		if keys[i].Endpoint != keys[j].Endpoint {
+			return keys[i].Endpoint < keys[j].Endpoint
+		}
+		return keys[i].Status < keys[j].Status
+	})
+	return keys
+}
+
+// workerStats 单个工作协程的延迟记录
+// 锁只在汇总时与统计协程竞争，记录时基本无竞争
+type workerStats struct {
+	mu     sync.Mutex
+	series seriesSet
+}
+
+// Record 记录一次请求，status为HTTP状态码或error/timeout
+func (w *workerStats) Record(endpoint, status string, d time.Duration) {
+	key := seriesKey{Endpoint: endpoint, Status: status}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.series == nil {
+		w.series = make(seriesSet)
+	}
+	h := w.series[key]
+	if h == nil {
+		h = newHistogram()
+		w.series[key] = h
+	}
+	h.Record(d)
+}
+
+// drain 取出上次汇总以来的记录
+func (w *workerStats) drain() seriesSet {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	series := w.series
+	w.series = nil
+	return series
+}
+
+// latencyStats 汇总各工作协程的延迟
+type latencyStats struct {
+	mu      sync.Mutex
+	workers []*workerStats
+	total   seriesSet
+}
+
+func newLatencyStats() *latencyStats {
+	return &latencyStats{total: make(seriesSet)}
+}
+
+// NewWorker 为新的工作协程创建记录器
+func (s *latencyStats) NewWorker() *workerStats {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	worker := &workerStats{}
+	s.workers = append(s.workers, worker)
+	return worker
+}
+
+// Collect 合并各工作协程上次汇总以来的记录，返回这段时间的延迟，同时累计到总计中
+func (s *latencyStats) Collect() seriesSet {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	interval := make(seriesSet)
+	for _, worker := range s.workers {
+		interval.merge(worker.drain())
+	}
+	s.total.merge(interval)
+	return interval
+}
+
+// Total 运行以来的全部延迟
+func (s *latencyStats) Total() seriesSet {
+	s.Collect()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	total := make(seriesSet, len(s.total))
+	total.merge(s.total)
+	return total
+}
+
+// formatPercentiles 格式化百分位和最大延迟，例如 p50=1.2ms p90=3.4ms ...
+func formatPercentiles(h *histogram) string {
+	var s string
+	for _, q := range reportedPercentiles {
+		s += fmt.Sprintf("p%s=%s ", strconv.FormatFloat(q, 'f', -1, 64), formatLatency(h.Percentile(q)))
+	}
+	return s + "max=" + formatLatency(h.Max())
+}
+
+// formatLatency 以毫秒输出延迟
+func formatLatency(d time.Duration) string {
+	return fmt.Sprintf("%.2fms", float64(d)/float64(time.Millisecond))
+}
+
+// printSeries 按端点和状态码输出延迟分布
+func printSeries(w io.Writer, series seriesSet) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprint(tw, "端点\t状态码\t请求数\t平均")
+	for _, q := range reportedPercentiles {
+		fmt.Fprintf(tw, "\tp%s", strconv.FormatFloat(q, 'f', -1, 64))
+	}
+	fmt.Fprintln(tw, "\tmax")
+	for _, key := range series.sortedKeys() {
+		h := series[key]
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%s", key.Endpoint, key.Status, h.Count(), formatLatency(h.Mean()))
+		for _, q := range reportedPercentiles {
+			fmt.Fprintf(tw, "\t%s", formatLatency(h.Percentile(q)))
+		}
+		fmt.Fprintf(tw, "\t%s\n", formatLatency(h.Max()))
+	}
+	tw.Flush()
+}
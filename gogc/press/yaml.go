@@ -0,0 +1,508 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+)
+
+// parseYAML 解析场景文件使用的YAML子集，结果为map[string]any/[]any/标量，可直接编码为JSON：
+//   - 以缩进表示层级的映射和序列（包括序列中的映射）
+//   - 单行的流式映射和序列，例如 {Content-Type: application/json}、[a, b]
+//   - 单引号、双引号和普通标量，true/false/null和数字，数字和布尔值以yamlScalar保留原文
+//   - |、|- 字面块和 >、>- 折叠块，用于多行请求体
+//   - # 注释
+//
+// 不支持锚点、标签、多文档和复杂键，场景文件用不到这些特性
+func parseYAML(data []byte) (any, error) {
+	p := &yamlParser{lines: strings.Split(strings.ReplaceAll(string(data), "\r\n", "\n"), "\n")}
+	i := p.skip(0)
+	if i >= len(p.lines) {
+		return nil, nil
+	}
+	if indent := indentOf(p.lines[i]); indent != 0 {
+		return nil, p.errorf(i, "首行不应缩进")
+	}
+	value, next, err := p.block(i, 0)
+	if err != nil {
+		return nil, err
+	}
+	if next = p.skip(next); next < len(p.lines) {
+		return nil, p.errorf(next, "缩进错误")
+	}
+	return value, nil
+}
+
+type yamlParser struct {
+	lines []string
+}
+
+func (p *yamlParser) errorf(i int, format string, args ...any) error {
+	return fmt.Errorf("YAML第%d行: %s", i+1, fmt.Sprintf(format, args...))
+}
+
+// skip 跳过空行和注释行，返回下一个有内容的行
+func (p *yamlParser) skip(i int) int {
+	for i < len(p.lines) {
+		text := strings.TrimSpace(p.lines[i])
+		if text != "" && !strings.HasPrefix(text, "#") && text != "---" {
+			break
+		}
+		i++
+	}
+	return i
+}
+
+func indentOf(line string) int {
+	return len(line) - len(strings.TrimLeft(line, " "))
+}
+
+func isSequenceItem(text string) bool {
+	return text == "-" || strings.HasPrefix(text, "- ")
+}
+
+// block 解析从第i行开始、缩进为indent的映射或序列
+func (p *yamlParser) block(i, indent int) (any, int, error) {
+	text := strings.TrimSpace(p.lines[i])
+	if strings.HasPrefix(p.lines[i], strings.Repeat(" ", indent)+"\t") {
+		return nil, 0, p.errorf(i, "不支持以制表符缩进")
+	}
+	if isSequenceItem(text) {
+		return p.sequence(i, indent)
+	}
+	return p.mapping(i, indent)
+}
+
+func (p *yamlParser) sequence(i, indent int) (any, int, error) {
+	items := []any{}
+	for i = p.skip(i); i < len(p.lines); i = p.skip(i) {
+		line := p.lines[i]
+		text := strings.TrimSpace(line)
+		if indentOf(line) < indent || !isSequenceItem(text) {
+			break
+		}
+		if indentOf(line) > indent {
+			return nil, 0, p.errorf(i, "缩进错误")
+		}
+
+		content := strings.TrimLeft(strings.TrimPrefix(text, "-"), " ")
+		var value any
+		var err error
+		switch {
+		case content == "":
+			// 内容在下一行
+			next := p.skip(i + 1)
+			if next < len(p.lines) && indentOf(p.lines[next]) > indent {
+				value, i, err = p.block(next, indentOf(p.lines[next]))
+			} else {
+				i++
+			}
+		case isMappingLine(content):
+			// "- key: value" 开始一个映射，后续键与key对齐
+			keyIndent := indentOf(line) + len(text) - len(content)
+			p.lines[i] = strings.Repeat(" ", keyIndent) + content
+			value, i, err = p.mapping(i, keyIndent)
+		default:
+			value, i, err = p.inline(i, indent, content)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		items = append(items, value)
+	}
+	return items, i, nil
+}
+
+func (p *yamlParser) mapping(i, indent int) (any, int, error) {
+	m := map[string]any{}
+	for i = p.skip(i); i < len(p.lines); i = p.skip(i) {
+		line := p.lines[i]
+		if indentOf(line) < indent {
+			break
+		}
+		if indentOf(line) > indent {
+			return nil, 0, p.errorf(i, "缩进错误")
+		}
+		text := strings.TrimSpace(line)
+		if isSequenceItem(text) {
+			break
+		}
+
+		key, rest, ok := splitMappingLine(text)
+		if !ok {
+			return nil, 0, p.errorf(i, "应为 key: value")
+		}
+		if _, exists := m[key]; exists {
+			return nil, 0, p.errorf(i, "重复的键 %s", key)
+		}
+
+		var value any
+		var err error
+		if rest == "" || strings.HasPrefix(rest, "#") {
+			// 值在后续缩进更深的行，序列可以与键对齐
+			next := p.skip(i + 1)
+			switch {
+			case next < len(p.lines) && indentOf(p.lines[next]) > indent:
+				value, i, err = p.block(next, indentOf(p.lines[next]))
+			case next < len(p.lines) && indentOf(p.lines[next]) == indent && isSequenceItem(strings.TrimSpace(p.lines[next])):
+				value, i, err = p.sequence(next, indent)
+			default:
+				i++
+			}
+		} else {
+			value, i, err = p.inline(i, indent, rest)
+		}
+		if err != nil {
+			return nil, 0, err
+		}
+		m[key] = value
+	}
+	return m, i, nil
+}
+
+// inline 解析写在第i行的值，块标量会继续读取后续行
+func (p *yamlParser) inline(i, indent int, text string) (any, int, error) {
+	if style := text[0]; style == '|' || style == '>' {
+		chomp := strings.HasSuffix(strings.TrimSpace(stripComment(text)), "-")
+		value, next := p.blockScalar(i+1, indent, style == '>', chomp)
+		return value, next, nil
+	}
+	value, err := parseFlow(stripComment(text))
+	if err != nil {
+		return nil, 0, p.errorf(i, "%v", err)
+	}
+	return value, i + 1, nil
+}
+
+// blockScalar 读取缩进大于parent的块标量，folded为true时将换行折叠为空格，chomp为true时去掉末尾换行
+func (p *yamlParser) blockScalar(i, parent int, folded, chomp bool) (string, int) {
+	var lines []string
+	indent := -1
+	for ; i < len(p.lines); i++ {
+		line := p.lines[i]
+		if strings.TrimSpace(line) == "" {
+			lines = append(lines, "")
+			continue
+		}
+		if indentOf(line) <= parent {
+			break
+		}
+		if indent < 0 {
+			indent = indentOf(line)
+		}
+		lines = append(lines, line[min(indent, indentOf(line)):])
+	}
+	// 末尾的空行不属于块
+	for len(lines) > 0 && lines[len(lines)-1] == "" {
+		lines = lines[:len(lines)-1]
+	}
+
+	sep := "\n"
+	if folded {
+		sep = " "
+	}
+	value := strings.Join(lines, sep)
+	if !chomp && value != "" {
+		value += "\n"
+	}
+	return value, i
+}
+
+// isMappingLine 是否为 key: value 形式
+func isMappingLine(text string) bool {
+	if text[0] == '[' || text[0] == '{' {
+		return false
+	}
+	_, _, ok := splitMappingLine(text)
+	return ok
+}
+
+// splitMappingLine 拆分 key: value，冒号后必须是空格或行尾
+func splitMappingLine(text string) (key, rest string, ok bool) {
+	start := 0
+	if text[0] == '"' || text[0] == '\'' {
+		end := closingQuote(text)
+		if end < 0 {
+			return "", "", false
+		}
+		start = end + 1
+	}
+	for j := start; j < len(text); j++ {
+		if text[j] == ':' && (j+1 == len(text) || text[j+1] == ' ') {
+			key, err := parseFlow(strings.TrimSpace(text[:j]))
+			if err != nil {
+				return "", "", false
+			}
+			return fmt.Sprint(key), strings.TrimSpace(text[j+1:]), true
+		}
+		if text[j] == ' ' && j+1 < len(text) && text[j+1] == '#' {
+			break
+		}
+	}
+	return "", "", false
+}
+
+// closingQuote 以引号开头的文本中结束引号的位置
+func closingQuote(text string) int {
+	quote := text[0]
+	for j := 1; j < len(text); j++ {
+		switch {
+		case quote == '"' && text[j] == '\\':
+			j++
+		case quote == '\'' && text[j] == '\'' && j+1 < len(text) && text[j+1] == '\'':
+			j++
+		case text[j] == quote:
+			return j
+		}
+	}
+	return -1
+}
+
+// stripComment 去掉引号外的行尾注释
+func stripComment(text string) string {
+	inQuote := byte(0)
+	for j := 0; j < len(text); j++ {
+		c := text[j]
+		switch {
+		case inQuote == '"' && c == '\\':
+			j++
+		case inQuote != 0:
+			if c == inQuote {
+				inQuote = 0
+			}
+		case (c == '"' || c == '\'') && (j == 0 || strings.IndexByte(" [{,:", text[j-1]) >= 0):
+			inQuote = c
+		case c == '#' && (j == 0 || text[j-1] == ' '):
+			return strings.TrimSpace(text[:j])
+		}
+	}
+	return strings.TrimSpace(text)
+}
+
+// parseFlow 解析单行的值：流式映射、流式序列或标量
+func parseFlow(text string) (any, error) {
+	f := &flowParser{text: text}
+	value, err := f.value()
+	if err != nil {
+		return nil, err
+	}
+	if f.skipSpaces(); f.pos < len(f.text) {
+		return nil, fmt.Errorf("多余的内容: %s", f.text[f.pos:])
+	}
+	return value, nil
+}
+
+type flowParser struct {
+	text string
+	pos  int
+}
+
+func (f *flowParser) skipSpaces() {
+	for f.pos < len(f.text) && f.text[f.pos] == ' ' {
+		f.pos++
+	}
+}
+
+func (f *flowParser) value() (any, error) {
+	f.skipSpaces()
+	if f.pos >= len(f.text) {
+		return nil, nil
+	}
+	switch f.text[f.pos] {
+	case '[':
+		return f.collection(']')
+	case '{':
+		return f.collection('}')
+	case '"', '\'':
+		return f.quoted()
+	}
+	return f.plain(), nil
+}
+
+// collection 解析 [a, b] 或 {a: b}
+func (f *flowParser) collection(end byte) (any, error) {
+	f.pos++
+	var items []any
+	m := map[string]any{}
+	for {
+		f.skipSpaces()
+		if f.pos < len(f.text) && f.text[f.pos] == end {
+			f.pos++
+			break
+		}
+		value, err := f.value()
+		if err != nil {
+			return nil, err
+		}
+		if end == '}' {
+			f.skipSpaces()
+			if f.pos >= len(f.text) || f.text[f.pos] != ':' {
+				return nil, fmt.Errorf("流式映射缺少冒号")
+			}
+			f.pos++
+			key := fmt.Sprint(value)
+			if value, err = f.value(); err != nil {
+				return nil, err
+			}
+			m[key] = value
+		} else {
+			items = append(items, value)
+		}
+
+		f.skipSpaces()
+		if f.pos >= len(f.text) {
+			return nil, fmt.Errorf("缺少 %c", end)
+		}
+		// 元素之后只能是逗号或结束符，否则无法前进
+		switch f.text[f.pos] {
+		case ',':
+			f.pos++
+		case end:
+		default:
+			return nil, fmt.Errorf("应为 , 或 %c: %s", end, f.text[f.pos:])
+		}
+	}
+	if end == '}' {
+		return m, nil
+	}
+	if items == nil {
+		items = []any{}
+	}
+	return items, nil
+}
+
+func (f *flowParser) quoted() (any, error) {
+	rest := f.text[f.pos:]
+	end := closingQuote(rest)
+	if end < 0 {
+		return nil, fmt.Errorf("引号未闭合: %s", rest)
+	}
+	f.pos += end + 1
+	if rest[0] == '\'' {
+		return strings.ReplaceAll(rest[1:end], "''", "'"), nil
+	}
+	value, err := strconv.Unquote(rest[:end+1])
+	if err != nil {
+		return nil, fmt.Errorf("非法的字符串 %s: %w", rest[:end+1], err)
+	}
+	return value, nil
+}
+
+// plain 普通标量，在流式集合中以逗号、]、}、": "结束
+func (f *flowParser) plain() any {
+	start := f.pos
+	for ; f.pos < len(f.text); f.pos++ {
+		c := f.text[f.pos]
+		if c == ',' || c == ']' || c == '}' || (c == ':' && (f.pos+1 == len(f.text) || f.text[f.pos+1] == ' ')) {
+			break
+		}
+	}
+	// 块上下文中值可以包含这些字符，只有整行都是普通标量时才会读到末尾
+	if start == 0 && f.pos < len(f.text) && f.text[f.pos] != ':' {
+		f.pos = len(f.text)
+	}
+	return plainScalar(strings.TrimSpace(f.text[start:f.pos]))
+}
+
+// plainScalar 将普通标量转换为空值、布尔值、数字或字符串，布尔值和数字保留原文
+func plainScalar(s string) any {
+	switch s {
+	case "", "~", "null", "Null", "NULL":
+		return nil
+	case "true", "True", "TRUE":
+		return yamlScalar{raw: s, value: true}
+	case "false", "False", "FALSE":
+		return yamlScalar{raw: s, value: false}
+	}
+	// 只把以数字、符号或小数点开头的值当作数字，避免inf、nan等被解析为浮点数
+	if !strings.ContainsAny(s[:1], "0123456789+-.") {
+		return s
+	}
+	if n, err := strconv.ParseInt(s, 10, 64); err == nil {
+		return yamlScalar{raw: s, value: n}
+	}
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return yamlScalar{raw: s, value: n}
+	}
+	return s
+}
+
+// yamlScalar 解析为布尔值或数字的普通标量
+// 编码为JSON时输出解析后的值；目标字段为字符串时由resolveScalars换回原文，例如 X-Api-Version: 2.0、body: true
+type yamlScalar struct {
+	raw   string
+	value any
+}
+
+func (s yamlScalar) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.value)
+}
+
+func (s yamlScalar) String() string {
+	return s.raw
+}
+
+// resolveScalars 按目标类型t转换parseYAML的结果：字符串字段中的yamlScalar还原为原文，其他字段使用解析后的值
+// t为nil（未知字段）时使用解析后的值，由JSON解码报告错误
+func resolveScalars(value any, t reflect.Type) any {
+	for t != nil && t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+	switch value := value.(type) {
+	case yamlScalar:
+		if t != nil && t.Kind() == reflect.String {
+			return value.raw
+		}
+		return value.value
+	case []any:
+		var elem reflect.Type
+		if t != nil && (t.Kind() == reflect.Slice || t.Kind() == reflect.Array) {
+			elem = t.Elem()
+		}
+		items := make([]any, len(value))
+		for i, item := range value {
+			items[i] = resolveScalars(item, elem)
+		}
+		return items
+	case map[string]any:
+		m := make(map[string]any, len(value))
+		for key, item := range value {
+			m[key] = resolveScalars(item, fieldType(t, key))
+		}
+		return m
+	}
+	return value
+}
+
+// fieldType 映射中key对应的目标类型，与encoding/json一样按json标签匹配结构体字段，优先精确匹配，其次忽略大小写
+func fieldType(t reflect.Type, key string) reflect.Type {
+	if t == nil {
+		return nil
+	}
+	switch t.Kind() {
+	case reflect.Map:
+		return t.Elem()
+	case reflect.Struct:
+		var folded reflect.Type
+		for i := range t.NumField() {
+			field := t.Field(i)
+			name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+			if name == "-" || !field.IsExported() {
+				continue
+			}
+			if name == "" {
+				name = field.Name
+			}
+			if name == key {
+				return field.Type
+			}
+			if folded == nil && strings.EqualFold(name, key) {
+				folded = field.Type
+			}
+		}
+		return folded
+	}
+	return nil
+}
@@ -0,0 +1,154 @@
+package main
+
+import (
+	"encoding/json"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestParseYAML(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		want any
+	}{
+		{
+			name: "空文档",
+			yaml: "# 只有注释\n\n",
+			want: nil,
+		},
+		{
+			name: "标量",
+			yaml: "a: 1\nb: 1.5\nc: true\nd: null\ne: hello world\nf: 'it''s'\ng: \"x\\ty\"\nh: inf\n",
+			want: map[string]any{"a": scalar("1", int64(1)), "b": scalar("1.5", 1.5), "c": scalar("true", true), "d": nil, "e": "hello world", "f": "it's", "g": "x\ty", "h": "inf"},
+		},
+		{
+			name: "嵌套映射和序列",
+			yaml: "a:\n  b:\n    - 1\n    - x\n  c: y\nlist:\n- k: v\n  n: 2\n-\n  - z\n",
+			want: map[string]any{
+				"a":    map[string]any{"b": []any{scalar("1", int64(1)), "x"}, "c": "y"},
+				"list": []any{map[string]any{"k": "v", "n": scalar("2", int64(2))}, []any{"z"}},
+			},
+		},
+		{
+			name: "流式集合",
+			yaml: "h: {Content-Type: application/json, X: 'a, b'}\nl: [1, [2, 3], {k: v}]\ne: []\nm: {}\n",
+			want: map[string]any{
+				"h": map[string]any{"Content-Type": "application/json", "X": "a, b"},
+				"l": []any{scalar("1", int64(1)), []any{scalar("2", int64(2)), scalar("3", int64(3))}, map[string]any{"k": "v"}},
+				"e": []any{},
+				"m": map[string]any{},
+			},
+		},
+		{
+			name: "块标量",
+			yaml: "lit: |\n  line1\n  line2\n\nfold: >-\n  a\n  b\nnext: 1\n",
+			want: map[string]any{"lit": "line1\nline2\n", "fold": "a b", "next": scalar("1", int64(1))},
+		},
+		{
+			name: "行尾注释",
+			yaml: "a: x # 注释\nb: 'y # 不是注释'\nc: u#v\n",
+			want: map[string]any{"a": "x", "b": "y # 不是注释", "c": "u#v"},
+		},
+		{
+			name: "值中的冒号",
+			yaml: "url: http://localhost:8080/path\n",
+			want: map[string]any{"url": "http://localhost:8080/path"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseYAML([]byte(tt.yaml))
+			if err != nil {
+				t.Fatalf("parseYAML: %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Fatalf("parseYAML = %#v, want %#v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestResolveScalars(t *testing.T) {
+	value, err := parseYAML([]byte("endpoints:\n  - headers: {X-Api-Version: 2.0, X-Debug: True}\n    body: 1e3\n    weight: 2\n    think_time: 1.5\nphases:\n  - {type: constant, duration: 30, rps: 1, amplitude: 0}\nextra: 1\n"))
+	if err != nil {
+		t.Fatalf("parseYAML: %v", err)
+	}
+	got := resolveScalars(value, reflect.TypeOf(scenario{}))
+	// 字符串字段保留原文，数字字段和未知字段使用解析后的值
+	want := map[string]any{
+		"endpoints": []any{map[string]any{
+			"headers":    map[string]any{"X-Api-Version": "2.0", "X-Debug": "True"},
+			"body":       "1e3",
+			"weight":     int64(2),
+			"think_time": 1.5,
+		}},
+		"phases": []any{map[string]any{"type": "constant", "duration": int64(30), "rps": int64(1), "amplitude": int64(0)}},
+		"extra":  int64(1),
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("resolveScalars = %#v, want %#v", got, want)
+	}
+}
+
+func TestParseYAMLErrors(t *testing.T) {
+	tests := []struct {
+		name string
+		yaml string
+		err  string
+	}{
+		{"首行缩进", "  a: 1\n", "首行不应缩进"},
+		{"缩进错误", "a:\n  b: 1\n    c: 2\n", "缩进错误"},
+		{"缺少冒号", "a: 1\nb\n", "应为 key: value"},
+		{"重复的键", "a: 1\na: 2\n", "重复的键"},
+		{"制表符缩进", "a:\n  \tb: 1\n", "制表符"},
+		{"引号未闭合", "a: \"x\n", "引号未闭合"},
+		{"多余的内容", "a: [1] x\n", "多余的内容"},
+		{"序列未闭合", "a: [1, 2\n", "缺少 ]"},
+		{"映射未闭合", "a: {k: v\n", "缺少 }"},
+		{"流式映射缺少冒号", "a: {k}\n", "缺少冒号"},
+		{"序列中的右花括号", "a: [}]\n", "应为 , 或 ]"},
+		{"序列以右花括号结束", "headers: [x}\n", "应为 , 或 ]"},
+		{"映射中的右方括号", "a: {k: v]\n", "应为 , 或 }"},
+		{"映射中多余的冒号", "a: {k: : v}\n", "应为 , 或 }"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := parseYAML([]byte(tt.yaml))
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("parseYAML(%q) 错误 = %v, want 包含 %q", tt.yaml, err, tt.err)
+			}
+		})
+	}
+}
+
+func FuzzParseYAML(f *testing.F) {
+	for _, seed := range []string{
+		"a: 1\n",
+		"a:\n  - {k: v, l: [1, 2]}\n  - 'x'\n",
+		"body: |\n  {\"a\": 1}\nnext: >-\n  b\n",
+		"a: [}]\n",
+		"headers: [x}\n",
+		"- - a\n  - b\n",
+	} {
+		f.Add(seed)
+	}
+	f.Fuzz(func(t *testing.T, data string) {
+		value, err := parseYAML([]byte(data))
+		if err != nil {
+			return
+		}
+		// 解析结果需要能编码为JSON再按场景解码
+		if _, err := json.Marshal(value); err != nil {
+			t.Fatalf("json.Marshal(%#v): %v", value, err)
+		}
+		if _, err := json.Marshal(resolveScalars(value, reflect.TypeOf(scenario{}))); err != nil {
+			t.Fatalf("json.Marshal(resolveScalars(%#v)): %v", value, err)
+		}
+	})
+}
+
+func scalar(raw string, value any) yamlScalar {
+	return yamlScalar{raw: raw, value: value}
+}
@@ -0,0 +1,201 @@
+package gogctuner
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+)
+
+const (
+	// 管理接口的建议挂载路径，与/debug/pprof/并列
+	DefaultAdminPath = "/debug/gogctuner/"
+	// 请求体大小上限
+	maxAdminBodyBytes = 1 << 20
+)
+
+// AdminHandler 调优器管理接口
+// 需要通过http.StripPrefix挂载，例如:
+//
+//	http.Handle("/debug/gogctuner/", http.StripPrefix("/debug/gogctuner", gogctuner.NewAdminHandler(tuner, token)))
+//
+// 路由:
+//
+//	GET  /        调优器状态和最近的调整记录
+//	POST /pause   暂停调优
+//	POST /resume  恢复调优
+//	POST /pin     固定GOGC，请求体 {"gogc": 50, "ttl": "10m"}
+//	POST /unpin   解除GOGC固定
+//	POST /config  修改配置，请求体为ConfigPatch的JSON
+//
+// POST请求需携带 Authorization: Bearer <token>；token为空时禁用所有POST接口
+type AdminHandler struct {
+	tuner *Tuner
+	token string
+	mux   *http.ServeMux
+}
+
+// AdminStatus 管理接口返回的调优器状态
+type AdminStatus struct {
+	Snapshot    Snapshot     `json:"snapshot"`
+	Config      AdminConfig  `json:"config"`
+	Adjustments []Adjustment `json:"adjustments"`
+	// 最近的紧急模式事件
+	EmergencyEvents []EmergencyEvent `json:"emergency_events"`
+}
+
+// AdminConfig 管理接口展示的配置，只包含可序列化的字段
+type AdminConfig struct {
+	MemoryHardLimit    int64      `json:"memory_hard_limit"`
+	SafetyFactor       float64    `json:"safety_factor"`
+	MinGOGC            int        `json:"min_gogc"`
+	MaxGOGC            int        `json:"max_gogc"`
+	AllowPeakOverride  bool       `json:"allow_peak_override"`
+	PeakThreshold      float64    `json:"peak_threshold"`
+	Mode               TuningMode `json:"mode"`
+	LimitModeGOGC      int        `json:"limit_mode_gogc"`
+	GCCPUCeiling       float64    `json:"gc_cpu_ceiling"`
+	TargetGCCPUPercent float64    `json:"target_gc_cpu_percent"`
+	EmergencyHeapRatio float64    `json:"emergency_heap_ratio"`
+	EmergencyRSSRatio  float64    `json:"emergency_rss_ratio"`
+	BallastRatio       float64    `json:"ballast_ratio"`
+	BallastGOGC        int        `json:"ballast_gogc"`
+}
+
+// PinRequest 固定GOGC的请求体
+type PinRequest struct {
+	GOGC int `json:"gogc"`
+	// 固定时长，time.ParseDuration格式，例如"10m"
+	TTL string `json:"ttl"`
+}
+
+// NewAdminHandler 创建管理接口，token为POST请求使用的Bearer令牌
+func NewAdminHandler(t *Tuner, token string) *AdminHandler {
+	h := &AdminHandler{tuner: t, token: token, mux: http.NewServeMux()}
+	h.mux.HandleFunc("GET /{$}", h.handleStatus)
+	h.mux.HandleFunc("POST /pause", h.authorized(h.handlePause))
+	h.mux.HandleFunc("POST /resume", h.authorized(h.handleResume))
+	h.mux.HandleFunc("POST /pin", h.authorized(h.handlePin))
+	h.mux.HandleFunc("POST /unpin", h.authorized(h.handleUnpin))
+	h.mux.HandleFunc("POST /config", h.authorized(h.handleConfig))
+	return h
+}
+
+// ServeHTTP 实现http.Handler
+func (h *AdminHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	h.mux.ServeHTTP(w, r)
+}
+
+// authorized 校验Bearer令牌
+func (h *AdminHandler) authorized(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if h.token == "" {
+			writeAdminError(w, http.StatusForbidden, errors.New("管理接口未配置令牌，POST请求已禁用"))
+			return
+		}
+		token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+		if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(h.token)) != 1 {
+			w.Header().Set("WWW-Authenticate", "Bearer")
+			writeAdminError(w, http.StatusUnauthorized, errors.New("令牌无效"))
+			return
+		}
+		next(w, r)
+	}
+}
+
+func (h *AdminHandler) handleStatus(w http.ResponseWriter, r *http.Request) {
+	h.writeStatus(w)
+}
+
+func (h *AdminHandler) handlePause(w http.ResponseWriter, r *http.Request) {
+	h.tuner.Pause()
+	h.writeStatus(w)
+}
+
+func (h *AdminHandler) handleResume(w http.ResponseWriter, r *http.Request) {
+	h.tuner.Resume()
+	h.writeStatus(w)
+}
+
+func (h *AdminHandler) handlePin(w http.ResponseWriter, r *http.Request) {
+	var req PinRequest
+	if err := decodeAdminBody(w, r, &req); err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	ttl, err := time.ParseDuration(req.TTL)
+	if err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.tuner.Pin(req.GOGC, ttl); err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	h.writeStatus(w)
+}
+
+func (h *AdminHandler) handleUnpin(w http.ResponseWriter, r *http.Request) {
+	h.tuner.Unpin()
+	h.writeStatus(w)
+}
+
+func (h *AdminHandler) handleConfig(w http.ResponseWriter, r *http.Request) {
+	var patch ConfigPatch
+	if err := decodeAdminBody(w, r, &patch); err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	if err := h.tuner.ApplyPatch(patch); err != nil {
+		writeAdminError(w, http.StatusBadRequest, err)
+		return
+	}
+	h.writeStatus(w)
+}
+
+// writeStatus 输出当前状态
+func (h *AdminHandler) writeStatus(w http.ResponseWriter) {
+	config := h.tuner.Config()
+	writeAdminJSON(w, http.StatusOK, AdminStatus{
+		Snapshot: h.tuner.Snapshot(),
+		Config: AdminConfig{
+			MemoryHardLimit:    config.MemoryHardLimit,
+			SafetyFactor:       config.SafetyFactor,
+			MinGOGC:            config.MinGOGC,
+			MaxGOGC:            config.MaxGOGC,
+			AllowPeakOverride:  config.AllowPeakOverride,
+			PeakThreshold:      config.PeakThreshold,
+			Mode:               config.Mode,
+			LimitModeGOGC:      config.LimitModeGOGC,
+			GCCPUCeiling:       config.GCCPUCeiling,
+			TargetGCCPUPercent: config.TargetGCCPUPercent,
+			EmergencyHeapRatio: config.EmergencyHeapRatio,
+			EmergencyRSSRatio:  config.EmergencyRSSRatio,
+			BallastRatio:       config.BallastRatio,
+			BallastGOGC:        config.BallastGOGC,
+		},
+		Adjustments:     h.tuner.Adjustments(),
+		EmergencyEvents: h.tuner.EmergencyEvents(),
+	})
+}
+
+// decodeAdminBody 解析JSON请求体，拒绝未知字段
+func decodeAdminBody(w http.ResponseWriter, r *http.Request, v any) error {
+	decoder := json.NewDecoder(http.MaxBytesReader(w, r.Body, maxAdminBodyBytes))
+	decoder.DisallowUnknownFields()
+	return decoder.Decode(v)
+}
+
+func writeAdminError(w http.ResponseWriter, status int, err error) {
+	writeAdminJSON(w, status, map[string]string{"error": err.Error()})
+}
+
+func writeAdminJSON(w http.ResponseWriter, status int, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	_ = encoder.Encode(v)
+}
@@ -0,0 +1,168 @@
+package gogctuner
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+const testAdminToken = "secret"
+
+// adminRequest 发送请求到管理接口，返回状态码和JSON响应
+func adminRequest(t *testing.T, h http.Handler, method, path, token, body string) (int, map[string]json.RawMessage) {
+	t.Helper()
+
+	req := httptest.NewRequest(method, path, strings.NewReader(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if ct := rec.Header().Get("Content-Type"); rec.Code != http.StatusNotFound && rec.Code != http.StatusMethodNotAllowed && ct != "application/json" {
+		t.Fatalf("%s %s Content-Type = %q", method, path, ct)
+	}
+	var resp map[string]json.RawMessage
+	_ = json.Unmarshal(rec.Body.Bytes(), &resp)
+	return rec.Code, resp
+}
+
+func decodeAdminStatus(t *testing.T, resp map[string]json.RawMessage) AdminStatus {
+	t.Helper()
+
+	data, err := json.Marshal(resp)
+	if err != nil {
+		t.Fatalf("Marshal: %v", err)
+	}
+	var status AdminStatus
+	if err := json.Unmarshal(data, &status); err != nil {
+		t.Fatalf("解析状态失败: %v", err)
+	}
+	return status
+}
+
+func TestAdminHandlerAuth(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+
+	tests := []struct {
+		name   string
+		token  string
+		method string
+		path   string
+		auth   string
+		want   int
+	}{
+		{"状态无需令牌", testAdminToken, http.MethodGet, "/", "", http.StatusOK},
+		{"缺少令牌", testAdminToken, http.MethodPost, "/pause", "", http.StatusUnauthorized},
+		{"令牌错误", testAdminToken, http.MethodPost, "/pause", "wrong", http.StatusUnauthorized},
+		{"未配置令牌时禁用POST", "", http.MethodPost, "/pause", testAdminToken, http.StatusForbidden},
+		{"未配置令牌时仍可查看状态", "", http.MethodGet, "/", "", http.StatusOK},
+		{"方法不允许", testAdminToken, http.MethodGet, "/pause", testAdminToken, http.StatusMethodNotAllowed},
+		{"未知路径", testAdminToken, http.MethodGet, "/unknown", testAdminToken, http.StatusNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewAdminHandler(sim.tuner, tt.token)
+			code, resp := adminRequest(t, h, tt.method, tt.path, tt.auth, "")
+			if code != tt.want {
+				t.Fatalf("状态码 = %d, want %d", code, tt.want)
+			}
+			if code >= 400 && code != http.StatusNotFound && code != http.StatusMethodNotAllowed && resp["error"] == nil {
+				t.Fatalf("错误响应缺少error字段: %v", resp)
+			}
+		})
+	}
+	if sim.tuner.Snapshot().Paused {
+		t.Fatal("未授权的请求不应暂停调优")
+	}
+}
+
+func TestAdminHandlerControl(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+	sim.step(traceStep{Live: 250 * mb})
+	h := NewAdminHandler(sim.tuner, testAdminToken)
+
+	code, resp := adminRequest(t, h, http.MethodPost, "/pause", testAdminToken, "")
+	if code != http.StatusOK || !decodeAdminStatus(t, resp).Snapshot.Paused {
+		t.Fatalf("pause: 状态码 = %d, 响应 = %s", code, resp["snapshot"])
+	}
+	// 暂停期间GOGC不变
+	if got := sim.step(traceStep{Live: 100 * mb}); got != 300 {
+		t.Fatalf("暂停后 GOGC = %d, want 300", got)
+	}
+	code, resp = adminRequest(t, h, http.MethodPost, "/resume", testAdminToken, "")
+	if status := decodeAdminStatus(t, resp); code != http.StatusOK || status.Snapshot.Paused || status.Snapshot.GOGC != 500 {
+		t.Fatalf("resume: 状态码 = %d, 快照 = %+v", code, status.Snapshot)
+	}
+
+	code, resp = adminRequest(t, h, http.MethodPost, "/pin", testAdminToken, `{"gogc": 80, "ttl": "10m"}`)
+	status := decodeAdminStatus(t, resp)
+	if code != http.StatusOK || status.Snapshot.PinnedGOGC != 80 || status.Snapshot.PinnedUntil == nil {
+		t.Fatalf("pin: 状态码 = %d, 快照 = %+v", code, status.Snapshot)
+	}
+	if got := sim.rt.GCSettings().GOGC; got != 80 {
+		t.Fatalf("固定后运行时 GOGC = %d, want 80", got)
+	}
+	if last := status.Adjustments[len(status.Adjustments)-1]; last.Strategy != manualStrategyName || last.NewGOGC != 80 {
+		t.Fatalf("固定后最后一次调整 = %+v", last)
+	}
+
+	code, resp = adminRequest(t, h, http.MethodPost, "/unpin", testAdminToken, "")
+	status = decodeAdminStatus(t, resp)
+	if code != http.StatusOK || status.Snapshot.PinnedUntil != nil || status.Snapshot.GOGC != 500 {
+		t.Fatalf("unpin: 状态码 = %d, 快照 = %+v", code, status.Snapshot)
+	}
+}
+
+func TestAdminHandlerBadRequest(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+	h := NewAdminHandler(sim.tuner, testAdminToken)
+
+	tests := []struct {
+		name string
+		path string
+		body string
+	}{
+		{"pin请求体不是JSON", "/pin", `gogc=80`},
+		{"pin未知字段", "/pin", `{"gogc": 80, "ttl": "10m", "force": true}`},
+		{"pin时长格式错误", "/pin", `{"gogc": 80, "ttl": "10"}`},
+		{"pin时长超过上限", "/pin", `{"gogc": 80, "ttl": "48h"}`},
+		{"pin非法GOGC", "/pin", `{"gogc": 0, "ttl": "10m"}`},
+		{"config未知字段", "/config", `{"min_gogc": 50, "unknown": 1}`},
+		{"config非法配置", "/config", `{"min_gogc": 600, "max_gogc": 500}`},
+		{"config请求体过大", "/config", `{"min_gogc": 50` + strings.Repeat(" ", maxAdminBodyBytes) + `}`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code, resp := adminRequest(t, h, http.MethodPost, tt.path, testAdminToken, tt.body)
+			if code != http.StatusBadRequest || resp["error"] == nil {
+				t.Fatalf("状态码 = %d, 响应 = %v", code, resp)
+			}
+		})
+	}
+
+	config := sim.tuner.Config()
+	if snap := sim.tuner.Snapshot(); snap.PinnedUntil != nil || config.MinGOGC != 25 {
+		t.Fatalf("非法请求后 快照 = %+v, MinGOGC = %d", snap, config.MinGOGC)
+	}
+}
+
+func TestAdminHandlerConfig(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+	sim.step(traceStep{Live: 250 * mb})
+	h := NewAdminHandler(sim.tuner, testAdminToken)
+
+	code, resp := adminRequest(t, h, http.MethodPost, "/config", testAdminToken, `{"max_gogc": 200, "safety_factor": 0.6}`)
+	status := decodeAdminStatus(t, resp)
+	if code != http.StatusOK || status.Config.MaxGOGC != 200 || status.Config.SafetyFactor != 0.6 {
+		t.Fatalf("config: 状态码 = %d, 配置 = %+v", code, status.Config)
+	}
+	// 未修改的配置保持不变，新配置立即生效
+	if status.Config.MemoryHardLimit != simMemoryLimit || status.Config.MinGOGC != 25 {
+		t.Fatalf("未修改的配置被改变: %+v", status.Config)
+	}
+	if got := sim.tuner.GetCurrentGOGC(); got != 200 {
+		t.Fatalf("修改配置后 GOGC = %d, want 200", got)
+	}
+}
@@ -0,0 +1,106 @@
+package gogctuner
+
+import (
+	"log/slog"
+	"sync"
+)
+
+const (
+	// 默认压舱石大小占内存硬限制的比例
+	defaultBallastRatio = 0.25
+	// 压舱石大小变化超过该比例才重新分配，避免频繁分配大对象
+	ballastResizeThreshold = 0.1
+)
+
+// Ballast 内存压舱石
+// 分配后从不读写，大对象直接从操作系统映射新页，通常只占虚拟内存而不占RSS；
+// 但它计入存活堆，使堆目标 = (存活堆 + 压舱石) * (1 + GOGC/100)，从而降低GC频率
+type Ballast struct {
+	mu  sync.Mutex
+	buf []byte
+}
+
+// NewBallast 创建指定大小(字节)的压舱石，size<=0时不分配
+func NewBallast(size int64) *Ballast {
+	b := &Ballast{}
+	b.Resize(size)
+	return b
+}
+
+// Resize 调整压舱石大小，size<=0时释放
+// 新旧压舱石会短暂共存，旧的在下一次GC时回收
+func (b *Ballast) Resize(size int64) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if size <= 0 {
+		b.buf = nil
+		return
+	}
+	if int64(len(b.buf)) == size {
+		return
+	}
+	b.buf = make([]byte, size)
+}
+
+// Size 当前压舱石大小(字节)
+func (b *Ballast) Size() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return int64(len(b.buf))
+}
+
+// Release 释放压舱石
+func (b *Ballast) Release() {
+	b.Resize(0)
+}
+
+// BallastStrategy 压舱石模式策略：GOGC固定为Config.BallastGOGC
+// 压舱石大小由调优器按内存硬限制维护，见Config.BallastRatio
+type BallastStrategy struct{}
+
+// Name 策略名称
+func (BallastStrategy) Name() string { return "ballast" }
+
+// Decide 返回固定的GOGC
+func (BallastStrategy) Decide(s State) Decision {
+	return Decision{GOGC: s.Config.BallastGOGC, Reason: "ballast"}
+}
+
+// ballastSize 按内存硬限制计算压舱石大小
+// 不超过 安全限制 / (1 + GOGC/100)，保证仅有压舱石时堆目标不超过安全限制
+func ballastSize(memoryLimit int64, config Config) int64 {
+	size := int64(float64(memoryLimit) * config.BallastRatio)
+	maxSize := int64(float64(memoryLimit) * config.SafetyFactor * 100 / float64(100+config.BallastGOGC))
+	if size > maxSize {
+		size = maxSize
+	}
+	return size
+}
+
+// syncBallast 压舱石模式下按当前内存限制调整压舱石，其他模式下释放，调用方需持有t.mu
+func (t *Tuner) syncBallast() {
+	var size int64
+	if t.config.Mode == ModeBallast {
+		size = ballastSize(t.memoryLimit.Load(), t.config)
+	}
+
+	current := t.ballast.Size()
+	if size == current {
+		return
+	}
+	// 大小变化不大时保持不变，避免内存限制小幅波动导致重复分配
+	if size > 0 && current > 0 {
+		change := float64(size-current) / float64(current)
+		if change < ballastResizeThreshold && change > -ballastResizeThreshold {
+			return
+		}
+	}
+
+	t.ballast.Resize(size)
+	t.logger.Info(logMsgBallast,
+		slog.Int64(logKeyBallastBytes, size),
+		slog.Int64(logKeyOldBallast, current),
+		slog.Int64(logKeyMemoryLimit, t.memoryLimit.Load()),
+	)
+}
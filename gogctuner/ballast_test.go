@@ -0,0 +1,100 @@
+package gogctuner
+
+import "testing"
+
+func TestBallast(t *testing.T) {
+	b := NewBallast(0)
+	if b.Size() != 0 {
+		t.Fatalf("NewBallast(0) Size = %d", b.Size())
+	}
+	b.Resize(mb)
+	if b.Size() != mb {
+		t.Fatalf("Resize后 Size = %d, want %d", b.Size(), mb)
+	}
+	b.Resize(-1)
+	if b.Size() != 0 {
+		t.Fatalf("Resize(-1)后 Size = %d, want 0", b.Size())
+	}
+	b = NewBallast(2 * mb)
+	b.Release()
+	if b.Size() != 0 {
+		t.Fatalf("Release后 Size = %d, want 0", b.Size())
+	}
+}
+
+func TestBallastSize(t *testing.T) {
+	tests := []struct {
+		name   string
+		ratio  float64
+		factor float64
+		gogc   int
+		want   int64
+	}{
+		{"按比例", 0.25, 0.5, 100, 500 * mb},
+		// 不超过 安全限制 / (1 + GOGC/100) = 1000MB / 3
+		{"受安全限制约束", 0.25, 0.5, 200, 1000 * mb / 3},
+		{"安全系数较小", 0.5, 0.4, 100, 400 * mb},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := Config{BallastRatio: tt.ratio, SafetyFactor: tt.factor, BallastGOGC: tt.gogc}
+			if got := ballastSize(2000*mb, config); got != tt.want {
+				t.Fatalf("ballastSize = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBallastMode(t *testing.T) {
+	config := simConfig()
+	config.Mode = ModeBallast
+	config.BallastGOGC = 150
+	sim := newSimulation(t, config)
+
+	// GOGC固定为BallastGOGC，压舱石 = min(2000MB * 0.25, 1000MB / 2.5)
+	assertTrajectory(t, sim.replay(liveTrace(100, 600)), []int{150, 150})
+	if got := sim.tuner.Snapshot().BallastBytes; got != 400*mb {
+		t.Fatalf("压舱石 = %dMB, want 400MB", got/mb)
+	}
+
+	updateLimit := func(limit int64) {
+		t.Helper()
+		config := sim.tuner.Config()
+		config.MemoryHardLimit = limit
+		if err := sim.tuner.UpdateConfig(config); err != nil {
+			t.Fatalf("UpdateConfig: %v", err)
+		}
+	}
+	// 内存限制小幅变化时保持不变，超过10%时重新分配
+	updateLimit(2100 * mb)
+	if got := sim.tuner.ballast.Size(); got != 400*mb {
+		t.Fatalf("小幅变化后 压舱石 = %dMB, want 400MB", got/mb)
+	}
+	updateLimit(1000 * mb)
+	if got := sim.tuner.ballast.Size(); got != 200*mb {
+		t.Fatalf("限制减半后 压舱石 = %dMB, want 200MB", got/mb)
+	}
+
+	// 切换到其他模式时释放
+	config = sim.tuner.Config()
+	config.Mode = ModeGOGC
+	if err := sim.tuner.UpdateConfig(config); err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+	if got := sim.tuner.ballast.Size(); got != 0 {
+		t.Fatalf("切换模式后 压舱石 = %dMB, want 0", got/mb)
+	}
+
+	// 停止时释放
+	config.Mode = ModeBallast
+	if err := sim.tuner.UpdateConfig(config); err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+	if sim.tuner.ballast.Size() == 0 {
+		t.Fatal("切换回压舱石模式后没有分配压舱石")
+	}
+	sim.tuner.Stop()
+	if got := sim.tuner.ballast.Size(); got != 0 {
+		t.Fatalf("停止后 压舱石 = %dMB, want 0", got/mb)
+	}
+}
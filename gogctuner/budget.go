@@ -0,0 +1,191 @@
+package gogctuner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// WorkingSet 读取当前容器的工作集内存，即cgroup OOM判断依据的用量
+// cgroup v2为 memory.current - inactive_file，v1为 memory.usage_in_bytes - total_inactive_file，
+// 不在cgroup中时回退到进程RSS
+func (d MemoryLimitDetector) WorkingSet() (int64, error) {
+	usage, err := d.cgroupWorkingSet()
+	if err == nil {
+		return usage, nil
+	}
+	return readProcessRSS(d.procRoot())
+}
+
+// cgroupWorkingSet 读取本进程所在cgroup的工作集
+func (d MemoryLimitDetector) cgroupWorkingSet() (int64, error) {
+	entries, err := d.selfCgroups()
+	if err != nil {
+		return 0, err
+	}
+
+	if path, ok := entries[""]; ok && d.isUnified() {
+		dir := d.resolveDir(d.cgroupRoot(), path)
+		return workingSet(filepath.Join(dir, "memory.current"), filepath.Join(dir, "memory.stat"), "inactive_file")
+	}
+	if path, ok := entries["memory"]; ok {
+		dir := d.resolveDir(filepath.Join(d.cgroupRoot(), "memory"), path)
+		return workingSet(filepath.Join(dir, "memory.usage_in_bytes"), filepath.Join(dir, "memory.stat"), "total_inactive_file")
+	}
+	return 0, errNoLimit
+}
+
+// workingSet 用量减去可回收的非活跃文件缓存，与kubelet的计算方式一致
+func workingSet(usagePath, statPath, inactiveKey string) (int64, error) {
+	usage, err := readCgroupValue(usagePath)
+	if err != nil {
+		return 0, err
+	}
+	inactive, err := readCgroupStat(statPath, inactiveKey)
+	if err != nil && !errors.Is(err, errNoLimit) {
+		return 0, err
+	}
+	if inactive > usage {
+		return 0, nil
+	}
+	return usage - inactive, nil
+}
+
+// readCgroupStat 读取memory.stat中的一项，不存在时返回errNoLimit
+func readCgroupStat(path, key string) (int64, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != key {
+			continue
+		}
+		value, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("gogctuner: 解析 %s 中的%s失败: %w", path, key, err)
+		}
+		return value, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errNoLimit
+}
+
+// memoryBudget 一次决策时的内存预算
+type memoryBudget struct {
+	// 容器工作集，无法读取时为0
+	WorkingSetBytes int64
+	// 运行时的非堆内存：goroutine栈、元数据等
+	RuntimeOverheadBytes int64
+	// 运行时之外的内存：cgo、mmap、活跃的页缓存等
+	ExternalBytes int64
+	// 堆可用的安全限制
+	SafetyLimit int64
+	// 多进程协调时本进程分得的预算比例，未协调时为1
+	Share float64
+	// 多进程协调时注册表中的进程数（含本进程），未协调时为0
+	Peers int
+}
+
+// computeBudget 计算堆可用的安全限制
+// 安全限制 = 内存硬限制 * SafetyFactor - 运行时非堆内存 - 运行时之外的内存
+// 其中运行时之外的内存 = 工作集 - 运行时实际占用的内存
+// 需要读取cgroup文件和写入协调注册表，不能持有t.mu
+func (t *Tuner) computeBudget(config Config, stats RuntimeStats, memoryLimit int64) memoryBudget {
+	budget := memoryBudget{
+		SafetyLimit: int64(float64(memoryLimit) * config.SafetyFactor),
+		Share:       1,
+	}
+	if config.Coordinator != nil {
+		if coordinated, ok := t.coordinatedBudget(config, stats, budget.SafetyLimit); ok {
+			return coordinated
+		}
+	}
+	if config.HeapOnlyBudget {
+		return budget
+	}
+
+	budget.RuntimeOverheadBytes = int64(stats.RuntimeOverheadBytes())
+	detector := MemoryLimitDetector{CgroupRoot: config.CgroupRoot, ProcRoot: config.ProcRoot}
+	if ws, err := detector.WorkingSet(); err == nil {
+		budget.WorkingSetBytes = ws
+		if external := ws - int64(stats.MappedBytes()); external > 0 {
+			budget.ExternalBytes = external
+		}
+	}
+
+	budget.SafetyLimit -= budget.RuntimeOverheadBytes + budget.ExternalBytes
+	if budget.SafetyLimit < 0 {
+		budget.SafetyLimit = 0
+	}
+	return budget
+}
+
+// coordinatedBudget 多进程共享容器时的预算
+// 共享安全限制 = 内存硬限制 * SafetyFactor - 所有进程的运行时非堆内存 - 非Go内存，
+// 本进程的安全限制 = 共享安全限制 * 本进程的预算比例。
+// 运行时之外的内存包括其他进程占用的全部内存，供混合模式计算软限制上限。
+// 注册表不可用时返回false，回退到单进程预算
+func (t *Tuner) coordinatedBudget(config Config, stats RuntimeStats, safetyLimit int64) (memoryBudget, bool) {
+	coordinator := config.Coordinator
+	self := PeerUsage{
+		ID:                   coordinator.id(),
+		LiveBytes:            stats.LiveBytes,
+		MappedBytes:          stats.MappedBytes(),
+		RuntimeOverheadBytes: stats.RuntimeOverheadBytes(),
+		UpdatedAt:            t.runtime.Now(),
+	}
+	peers, err := coordinator.sync(self)
+	if err != nil {
+		t.logger.Warn(logMsgCoordination, slog.String(logKeyPath, coordinator.Dir), slog.Any(logKeyError, err))
+		return memoryBudget{}, false
+	}
+
+	budget := memoryBudget{
+		RuntimeOverheadBytes: int64(self.RuntimeOverheadBytes),
+		Share:                budgetShare(self.ID, peers),
+		Peers:                len(peers),
+	}
+
+	var othersMapped, totalMapped, totalOverhead int64
+	for _, peer := range peers {
+		totalMapped += int64(peer.MappedBytes)
+		totalOverhead += int64(peer.RuntimeOverheadBytes)
+		if peer.ID != self.ID {
+			othersMapped += int64(peer.MappedBytes)
+		}
+	}
+
+	var nonGo int64
+	if !config.HeapOnlyBudget {
+		detector := MemoryLimitDetector{CgroupRoot: config.CgroupRoot, ProcRoot: config.ProcRoot}
+		if ws, err := detector.WorkingSet(); err == nil {
+			budget.WorkingSetBytes = ws
+			if ws > totalMapped {
+				nonGo = ws - totalMapped
+			}
+		}
+	} else {
+		totalOverhead = 0
+		budget.RuntimeOverheadBytes = 0
+	}
+	budget.ExternalBytes = othersMapped + nonGo
+
+	shared := safetyLimit - totalOverhead - nonGo
+	if shared < 0 {
+		shared = 0
+	}
+	budget.SafetyLimit = int64(float64(shared) * budget.Share)
+	return budget, true
+}
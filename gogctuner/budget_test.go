@@ -0,0 +1,120 @@
+package gogctuner
+
+import (
+	"io"
+	"log/slog"
+	"testing"
+)
+
+func TestComputeBudget(t *testing.T) {
+	detector := fakeRoot(t, map[string]string{
+		"proc/self/cgroup":          "0::/\n",
+		"proc/meminfo":              hostMemInfo,
+		"cgroup/cgroup.controllers": "memory\n",
+		"cgroup/memory.max":         "2097152000\n",
+		// 工作集 = 700MB - 50MB = 650MB
+		"cgroup/memory.current": "734003200\n",
+		"cgroup/memory.stat":    "inactive_file 52428800\n",
+	})
+
+	rt := newFakeRuntime()
+	tuner, err := NewTuner(Config{
+		SafetyFactor:         simSafetyFactor,
+		CgroupRoot:           detector.CgroupRoot,
+		ProcRoot:             detector.ProcRoot,
+		LimitRefreshInterval: -1,
+		Runtime:              rt,
+		Logger:               slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewTuner: %v", err)
+	}
+	if tuner.MemoryLimit() != simMemoryLimit || tuner.LimitSource() != LimitSourceCgroupV2 {
+		t.Fatalf("内存限制 = %d, %s", tuner.MemoryLimit(), tuner.LimitSource())
+	}
+
+	// 运行时占用 = 600MB - 100MB(已归还) = 500MB，其中堆 = 400 + 20 + 30 = 450MB
+	stats := RuntimeStats{
+		LiveBytes:       300 * mb,
+		HeapObjectBytes: 400 * mb,
+		UnusedBytes:     20 * mb,
+		FreeBytes:       30 * mb,
+		TotalBytes:      600 * mb,
+		ReleasedBytes:   100 * mb,
+	}
+	budget := tuner.computeBudget(tuner.config, stats, simMemoryLimit)
+	want := memoryBudget{
+		WorkingSetBytes:      650 * mb,
+		RuntimeOverheadBytes: 50 * mb,
+		ExternalBytes:        150 * mb,
+		// 1000MB - 50MB - 150MB
+		SafetyLimit: 800 * mb,
+		Share:       1,
+	}
+	if budget != want {
+		t.Fatalf("computeBudget = %+v, want %+v", budget, want)
+	}
+
+	tuner.config.HeapOnlyBudget = true
+	budget = tuner.computeBudget(tuner.config, stats, simMemoryLimit)
+	if budget.SafetyLimit != 1000*mb || budget.ExternalBytes != 0 || budget.RuntimeOverheadBytes != 0 {
+		t.Fatalf("HeapOnlyBudget时 computeBudget = %+v", budget)
+	}
+}
+
+func TestCoordinatedBudget(t *testing.T) {
+	dir := t.TempDir()
+	newPeer := func(id string) *simulation {
+		config := simConfig()
+		config.Coordinator = NewCoordinator(dir)
+		config.Coordinator.ID = id
+		return newSimulation(t, config)
+	}
+	a, b := newPeer("a"), newPeer("b")
+
+	// b尚无存活堆数据，a独占预算
+	if got := a.step(traceStep{Live: 100 * mb}); got != 500 {
+		t.Fatalf("a GOGC = %d, want 500", got)
+	}
+	// 按存活堆分配：b占300/400
+	if got := b.step(traceStep{Live: 300 * mb}); got != 150 {
+		t.Fatalf("b GOGC = %d, want 150", got)
+	}
+	// a占100/400，安全限制250MB
+	if got := a.step(traceStep{Live: 100 * mb}); got != 150 {
+		t.Fatalf("a GOGC = %d, want 150", got)
+	}
+
+	snapshot := a.tuner.Snapshot()
+	if snapshot.Peers != 2 || snapshot.BudgetShare != 0.25 || snapshot.SafetyLimit != 250*mb {
+		t.Fatalf("a 快照 Peers=%d BudgetShare=%v SafetyLimit=%dMB",
+			snapshot.Peers, snapshot.BudgetShare, snapshot.SafetyLimit/mb)
+	}
+
+	// a退出后b收回全部预算：(1000 - 300) / 300
+	a.tuner.Stop()
+	if got := b.step(traceStep{Live: 300 * mb}); got != 233 {
+		t.Fatalf("a退出后 b GOGC = %d, want 233", got)
+	}
+	if peers := b.tuner.config.Coordinator.Peers(); len(peers) != 1 || peers[0].ID != "b" {
+		t.Fatalf("注册表 = %+v", peers)
+	}
+}
+
+func TestBudgetShare(t *testing.T) {
+	peers := []PeerUsage{
+		{ID: "a", LiveBytes: 100, Weight: 1},
+		{ID: "b", LiveBytes: 100, Weight: 3},
+	}
+	if got := budgetShare("b", peers); got != 0.75 {
+		t.Fatalf("按加权存活堆 = %v, want 0.75", got)
+	}
+
+	peers[0].LiveBytes, peers[1].LiveBytes = 0, 0
+	if got := budgetShare("a", peers); got != 0.25 {
+		t.Fatalf("没有存活堆时按权重 = %v, want 0.25", got)
+	}
+	if got := budgetShare("a", nil); got != 1 {
+		t.Fatalf("没有条目时 = %v, want 1", got)
+	}
+}
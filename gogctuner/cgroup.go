@@ -0,0 +1,243 @@
+package gogctuner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+const (
+	// 默认cgroup文件系统挂载点
+	defaultCgroupRoot = "/sys/fs/cgroup"
+	// 默认proc文件系统挂载点
+	defaultProcRoot = "/proc"
+	// cgroup v1 中"无限制"会表示为接近 math.MaxInt64 的页对齐值，超过该阈值视为无限制
+	cgroupV1UnlimitedThreshold = math.MaxInt64 / 2
+)
+
+// LimitSource 内存限制来源
+type LimitSource string
+
+const (
+	LimitSourceConfig   LimitSource = "config"
+	LimitSourceEnv      LimitSource = "env"
+	LimitSourceCgroupV2 LimitSource = "cgroup_v2"
+	LimitSourceCgroupV1 LimitSource = "cgroup_v1"
+	LimitSourceHost     LimitSource = "host"
+)
+
+// errNoLimit 表示该来源没有设置内存限制
+var errNoLimit = errors.New("gogctuner: no memory limit")
+
+// MemoryLimitDetector 内存限制探测器
+// 依次尝试 cgroup v2、cgroup v1，最后回退到宿主机物理内存
+type MemoryLimitDetector struct {
+	// cgroup文件系统根目录，为空时使用/sys/fs/cgroup
+	CgroupRoot string
+	// proc文件系统根目录，为空时使用/proc
+	ProcRoot string
+}
+
+// Detect 探测当前进程可用的内存上限
+func (d MemoryLimitDetector) Detect() (int64, LimitSource, error) {
+	hostTotal, hostErr := d.hostMemory()
+
+	limit, source, err := d.cgroupLimit()
+	if err == nil {
+		// 容器限制不应超过宿主机内存
+		if hostErr == nil && hostTotal > 0 && limit > hostTotal {
+			return hostTotal, LimitSourceHost, nil
+		}
+		return limit, source, nil
+	}
+	if !errors.Is(err, errNoLimit) && !os.IsNotExist(err) {
+		return 0, "", err
+	}
+
+	if hostErr != nil {
+		return 0, "", fmt.Errorf("gogctuner: 无法探测内存限制: %w", hostErr)
+	}
+	return hostTotal, LimitSourceHost, nil
+}
+
+// cgroupLimit 读取cgroup内存限制，未设置时返回errNoLimit
+func (d MemoryLimitDetector) cgroupLimit() (int64, LimitSource, error) {
+	entries, err := d.selfCgroups()
+	if err != nil {
+		return 0, "", err
+	}
+
+	// 优先使用统一层级(v2)
+	if path, ok := entries[""]; ok && d.isUnified() {
+		limit, err := d.cgroupV2Limit(path)
+		return limit, LimitSourceCgroupV2, err
+	}
+	if path, ok := entries["memory"]; ok {
+		limit, err := d.cgroupV1Limit(path)
+		return limit, LimitSourceCgroupV1, err
+	}
+	return 0, "", errNoLimit
+}
+
+// cgroupV2Limit 沿cgroup层级向上查找memory.max和memory.high，取最小值
+func (d MemoryLimitDetector) cgroupV2Limit(cgroupPath string) (int64, error) {
+	dir := d.resolveDir(d.cgroupRoot(), cgroupPath)
+
+	limit := int64(-1)
+	for {
+		for _, name := range []string{"memory.max", "memory.high"} {
+			value, err := readCgroupValue(filepath.Join(dir, name))
+			if err != nil {
+				if errors.Is(err, errNoLimit) || os.IsNotExist(err) {
+					continue
+				}
+				return 0, err
+			}
+			if limit < 0 || value < limit {
+				limit = value
+			}
+		}
+		if dir == d.cgroupRoot() {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	if limit < 0 {
+		return 0, errNoLimit
+	}
+	return limit, nil
+}
+
+// cgroupV1Limit 读取memory子系统的memory.limit_in_bytes
+func (d MemoryLimitDetector) cgroupV1Limit(cgroupPath string) (int64, error) {
+	memoryRoot := filepath.Join(d.cgroupRoot(), "memory")
+	dir := d.resolveDir(memoryRoot, cgroupPath)
+
+	limit := int64(-1)
+	for {
+		value, err := readCgroupValue(filepath.Join(dir, "memory.limit_in_bytes"))
+		if err == nil && value < cgroupV1UnlimitedThreshold {
+			if limit < 0 || value < limit {
+				limit = value
+			}
+		} else if err != nil && !errors.Is(err, errNoLimit) && !os.IsNotExist(err) {
+			return 0, err
+		}
+		if dir == memoryRoot {
+			break
+		}
+		dir = filepath.Dir(dir)
+	}
+
+	if limit < 0 {
+		return 0, errNoLimit
+	}
+	return limit, nil
+}
+
+// resolveDir 将/proc/self/cgroup中的路径映射到挂载点下的目录
+// 在cgroup命名空间外路径可能不存在（挂载点即为本容器cgroup），此时回退到挂载点
+func (d MemoryLimitDetector) resolveDir(root, cgroupPath string) string {
+	dir := filepath.Join(root, filepath.Clean("/"+cgroupPath))
+	if _, err := os.Stat(dir); err != nil {
+		return root
+	}
+	return dir
+}
+
+// isUnified 判断是否为cgroup v2统一层级
+func (d MemoryLimitDetector) isUnified() bool {
+	_, err := os.Stat(filepath.Join(d.cgroupRoot(), "cgroup.controllers"))
+	return err == nil
+}
+
+// selfCgroups 解析/proc/self/cgroup，返回 控制器 -> cgroup路径
+// cgroup v2 的控制器名为空字符串
+func (d MemoryLimitDetector) selfCgroups() (map[string]string, error) {
+	file, err := os.Open(filepath.Join(d.procRoot(), "self", "cgroup"))
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	entries := make(map[string]string)
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// 格式: hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[1] == "" {
+			entries[""] = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			entries[controller] = parts[2]
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// hostMemory 从/proc/meminfo读取宿主机物理内存总量
+func (d MemoryLimitDetector) hostMemory() (int64, error) {
+	file, err := os.Open(filepath.Join(d.procRoot(), "meminfo"))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "MemTotal:" {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("gogctuner: 解析MemTotal失败: %w", err)
+		}
+		return kb * 1024, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("gogctuner: /proc/meminfo 中未找到MemTotal")
+}
+
+func (d MemoryLimitDetector) cgroupRoot() string {
+	if d.CgroupRoot == "" {
+		return defaultCgroupRoot
+	}
+	return filepath.Clean(d.CgroupRoot)
+}
+
+func (d MemoryLimitDetector) procRoot() string {
+	if d.ProcRoot == "" {
+		return defaultProcRoot
+	}
+	return filepath.Clean(d.ProcRoot)
+}
+
+// readCgroupValue 读取cgroup数值文件，"max"表示无限制
+func readCgroupValue(path string) (int64, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, err
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "" || value == "max" {
+		return 0, errNoLimit
+	}
+	parsed, err := strconv.ParseInt(value, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("gogctuner: 解析 %s 失败: %w", path, err)
+	}
+	return parsed, nil
+}
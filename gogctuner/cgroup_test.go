@@ -0,0 +1,275 @@
+package gogctuner
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const hostMemInfo = "MemTotal:       16777216 kB\nMemFree:         8388608 kB\n"
+
+// fakeRoot 在临时目录中构造cgroup和proc文件系统，files的键为相对路径
+func fakeRoot(t *testing.T, files map[string]string) MemoryLimitDetector {
+	t.Helper()
+
+	root := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return MemoryLimitDetector{
+		CgroupRoot: filepath.Join(root, "cgroup"),
+		ProcRoot:   filepath.Join(root, "proc"),
+	}
+}
+
+func TestMemoryLimitDetector(t *testing.T) {
+	tests := []struct {
+		name       string
+		files      map[string]string
+		wantLimit  int64
+		wantSource LimitSource
+	}{
+		{
+			// 沿层级向上取memory.max和memory.high的最小值
+			name: "cgroup v2 nested",
+			files: map[string]string{
+				"proc/self/cgroup":                    "0::/kubepods/pod1\n",
+				"proc/meminfo":                        hostMemInfo,
+				"cgroup/cgroup.controllers":           "cpu memory\n",
+				"cgroup/kubepods/memory.max":          "1073741824\n",
+				"cgroup/kubepods/pod1/memory.max":     "max\n",
+				"cgroup/kubepods/pod1/memory.high":    "805306368\n",
+				"cgroup/kubepods/pod1/memory.current": "1024\n",
+			},
+			wantLimit:  805306368,
+			wantSource: LimitSourceCgroupV2,
+		},
+		{
+			// cgroup命名空间内路径不存在时使用挂载点
+			name: "cgroup v2 namespace",
+			files: map[string]string{
+				"proc/self/cgroup":          "0::/\n",
+				"proc/meminfo":              hostMemInfo,
+				"cgroup/cgroup.controllers": "memory\n",
+				"cgroup/memory.max":         "536870912\n",
+			},
+			wantLimit:  536870912,
+			wantSource: LimitSourceCgroupV2,
+		},
+		{
+			name: "cgroup v1",
+			files: map[string]string{
+				"proc/self/cgroup": "5:cpu,cpuacct:/docker/abc\n4:memory:/docker/abc\n",
+				"proc/meminfo":     hostMemInfo,
+				"cgroup/memory/docker/abc/memory.limit_in_bytes": "536870912\n",
+			},
+			wantLimit:  536870912,
+			wantSource: LimitSourceCgroupV1,
+		},
+		{
+			name: "cgroup v1 unlimited",
+			files: map[string]string{
+				"proc/self/cgroup": "4:memory:/docker/abc\n",
+				"proc/meminfo":     hostMemInfo,
+				"cgroup/memory/docker/abc/memory.limit_in_bytes": "9223372036854771712\n",
+			},
+			wantLimit:  16 << 30,
+			wantSource: LimitSourceHost,
+		},
+		{
+			name: "cgroup v2 unlimited",
+			files: map[string]string{
+				"proc/self/cgroup":          "0::/\n",
+				"proc/meminfo":              hostMemInfo,
+				"cgroup/cgroup.controllers": "memory\n",
+				"cgroup/memory.max":         "max\n",
+			},
+			wantLimit:  16 << 30,
+			wantSource: LimitSourceHost,
+		},
+		{
+			// 容器限制大于宿主机内存时以宿主机为准
+			name: "limit above host memory",
+			files: map[string]string{
+				"proc/self/cgroup":          "0::/\n",
+				"proc/meminfo":              hostMemInfo,
+				"cgroup/cgroup.controllers": "memory\n",
+				"cgroup/memory.max":         "34359738368\n",
+			},
+			wantLimit:  16 << 30,
+			wantSource: LimitSourceHost,
+		},
+		{
+			name: "no cgroup",
+			files: map[string]string{
+				"proc/meminfo": hostMemInfo,
+			},
+			wantLimit:  16 << 30,
+			wantSource: LimitSourceHost,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			limit, source, err := fakeRoot(t, tt.files).Detect()
+			if err != nil {
+				t.Fatalf("Detect: %v", err)
+			}
+			if limit != tt.wantLimit || source != tt.wantSource {
+				t.Fatalf("Detect = %d, %s, want %d, %s", limit, source, tt.wantLimit, tt.wantSource)
+			}
+		})
+	}
+}
+
+func TestMemoryLimitDetectorErrors(t *testing.T) {
+	if _, _, err := fakeRoot(t, nil).Detect(); err == nil {
+		t.Fatal("没有cgroup和meminfo时应返回错误")
+	}
+
+	detector := fakeRoot(t, map[string]string{
+		"proc/self/cgroup":          "0::/\n",
+		"proc/meminfo":              hostMemInfo,
+		"cgroup/cgroup.controllers": "memory\n",
+		"cgroup/memory.max":         "not-a-number\n",
+	})
+	if _, _, err := detector.Detect(); err == nil {
+		t.Fatal("memory.max无法解析时应返回错误")
+	}
+}
+
+func TestWorkingSet(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+		want  int64
+	}{
+		{
+			name: "cgroup v2",
+			files: map[string]string{
+				"proc/self/cgroup":          "0::/app\n",
+				"cgroup/cgroup.controllers": "memory\n",
+				"cgroup/app/memory.current": "629145600\n",
+				"cgroup/app/memory.stat":    "anon 419430400\nfile 209715200\ninactive_file 104857600\n",
+			},
+			want: 524288000,
+		},
+		{
+			name: "cgroup v1",
+			files: map[string]string{
+				"proc/self/cgroup": "4:memory:/docker/abc\n",
+				"cgroup/memory/docker/abc/memory.usage_in_bytes": "629145600\n",
+				"cgroup/memory/docker/abc/memory.stat":           "cache 209715200\ntotal_inactive_file 209715200\n",
+			},
+			want: 419430400,
+		},
+		{
+			// 没有memory.stat中的项时不扣除
+			name: "cgroup v2 without inactive_file",
+			files: map[string]string{
+				"proc/self/cgroup":          "0::/\n",
+				"cgroup/cgroup.controllers": "memory\n",
+				"cgroup/memory.current":     "629145600\n",
+				"cgroup/memory.stat":        "anon 629145600\n",
+			},
+			want: 629145600,
+		},
+		{
+			name: "process rss",
+			files: map[string]string{
+				"proc/self/status": "Name:\tapp\nVmRSS:\t  102400 kB\nVmSwap:\t       0 kB\n",
+			},
+			want: 104857600,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := fakeRoot(t, tt.files).WorkingSet()
+			if err != nil {
+				t.Fatalf("WorkingSet: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("WorkingSet = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestReadCgroupValue(t *testing.T) {
+	dir := t.TempDir()
+	for name, content := range map[string]string{
+		"number": "123\n",
+		"max":    "max\n",
+		"empty":  "",
+	} {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if got, err := readCgroupValue(filepath.Join(dir, "number")); err != nil || got != 123 {
+		t.Fatalf("readCgroupValue(number) = %d, %v", got, err)
+	}
+	for _, name := range []string{"max", "empty"} {
+		if _, err := readCgroupValue(filepath.Join(dir, name)); !errors.Is(err, errNoLimit) {
+			t.Fatalf("readCgroupValue(%s) err = %v, want errNoLimit", name, err)
+		}
+	}
+	if _, err := readCgroupValue(filepath.Join(dir, "missing")); !os.IsNotExist(err) {
+		t.Fatalf("readCgroupValue(missing) err = %v", err)
+	}
+}
+
+func TestResolveMemoryLimit(t *testing.T) {
+	detector := fakeRoot(t, map[string]string{
+		"proc/self/cgroup":          "0::/app\n",
+		"proc/meminfo":              hostMemInfo,
+		"cgroup/cgroup.controllers": "memory\n",
+		"cgroup/app/memory.max":     "536870912\n",
+	})
+
+	tests := []struct {
+		name       string
+		hardLimit  int64
+		env        string
+		wantLimit  int64
+		wantSource LimitSource
+	}{
+		{name: "cgroup", wantLimit: 536870912, wantSource: LimitSourceCgroupV2},
+		{name: "env", env: "268435456", wantLimit: 268435456, wantSource: LimitSourceEnv},
+		// 无法解析的环境变量忽略，继续探测cgroup
+		{name: "invalid env", env: "256MB", wantLimit: 536870912, wantSource: LimitSourceCgroupV2},
+		{name: "config", hardLimit: 1 << 30, env: "268435456", wantLimit: 1 << 30, wantSource: LimitSourceConfig},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("MEMORY_LIMIT_BYTES", tt.env)
+			tuner, err := NewTuner(Config{
+				MemoryHardLimit:      tt.hardLimit,
+				CgroupRoot:           detector.CgroupRoot,
+				ProcRoot:             detector.ProcRoot,
+				LimitRefreshInterval: -1,
+				Runtime:              newFakeRuntime(),
+			})
+			if err != nil {
+				t.Fatalf("NewTuner: %v", err)
+			}
+			if tuner.MemoryLimit() != tt.wantLimit || tuner.LimitSource() != tt.wantSource {
+				t.Fatalf("NewTuner 内存限制 = %d, %s, want %d, %s",
+					tuner.MemoryLimit(), tuner.LimitSource(), tt.wantLimit, tt.wantSource)
+			}
+		})
+	}
+
+	// 探测失败时NewTuner返回错误
+	broken := fakeRoot(t, nil)
+	if _, err := NewTuner(Config{CgroupRoot: broken.CgroupRoot, ProcRoot: broken.ProcRoot, Runtime: newFakeRuntime()}); err == nil {
+		t.Fatal("无法探测内存限制时NewTuner应返回错误")
+	}
+}
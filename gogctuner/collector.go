@@ -0,0 +1,160 @@
+package gogctuner
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const metricsNamespace = "gogctuner"
+
+// Collector 以Prometheus指标导出调优器状态
+// 用法: prometheus.MustRegister(gogctuner.NewCollector(tuner))
+type Collector struct {
+	tuner *Tuner
+
+	gogc             *prometheus.Desc
+	memoryLimit      *prometheus.Desc
+	safetyLimit      *prometheus.Desc
+	softMemoryLimit  *prometheus.Desc
+	heapLive         *prometheus.Desc
+	memoryUsageRatio *prometheus.Desc
+	adjustments      *prometheus.Desc
+	enabled          *prometheus.Desc
+	emergency        *prometheus.Desc
+	emergencies      *prometheus.Desc
+	workingSet       *prometheus.Desc
+	nonHeap          *prometheus.Desc
+	ballast          *prometheus.Desc
+	budgetShare      *prometheus.Desc
+}
+
+// NewCollector 创建调优器指标采集器
+func NewCollector(t *Tuner) *Collector {
+	return &Collector{
+		tuner: t,
+		gogc: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "gogc"),
+			"当前GOGC值，-1表示关闭",
+			nil, nil,
+		),
+		memoryLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "memory_limit_bytes"),
+			"内存硬限制(字节)",
+			[]string{"source"}, nil,
+		),
+		safetyLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "safety_limit_bytes"),
+			"堆可用的安全限制(字节)，即内存硬限制 * 安全系数 - 非堆内存",
+			nil, nil,
+		),
+		softMemoryLimit: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "soft_memory_limit_bytes"),
+			"通过debug.SetMemoryLimit设置的软内存限制(字节)，0表示未设置",
+			nil, nil,
+		),
+		heapLive: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "heap_live_bytes"),
+			"最近一次GC标记后的存活堆(字节)",
+			nil, nil,
+		),
+		memoryUsageRatio: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "memory_usage_ratio"),
+			"存活堆占内存硬限制的比例",
+			nil, nil,
+		),
+		adjustments: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "adjustments_total"),
+			"GOGC或软内存限制的调整次数",
+			[]string{"strategy", "reason"}, nil,
+		),
+		enabled: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "enabled"),
+			"调优器是否启用(1/0)",
+			[]string{"mode", "strategy"}, nil,
+		),
+		emergency: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "emergency"),
+			"是否处于紧急模式(1/0)",
+			nil, nil,
+		),
+		emergencies: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "emergencies_total"),
+			"紧急模式触发次数，含冷却后的重复触发",
+			nil, nil,
+		),
+		workingSet: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "working_set_bytes"),
+			"容器工作集(字节)，无cgroup时为进程RSS",
+			nil, nil,
+		),
+		nonHeap: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "non_heap_bytes"),
+			"从安全限制中扣除的非堆内存(字节)，kind=runtime为栈和元数据，kind=external为cgo/mmap/页缓存",
+			[]string{"kind"}, nil,
+		),
+		ballast: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "ballast_bytes"),
+			"压舱石大小(字节)，非压舱石模式为0",
+			nil, nil,
+		),
+		budgetShare: prometheus.NewDesc(
+			prometheus.BuildFQName(metricsNamespace, "", "budget_share"),
+			"多进程协调时本进程分得的安全限制比例，未协调时为1",
+			nil, nil,
+		),
+	}
+}
+
+// Describe 实现prometheus.Collector
+func (c *Collector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.gogc
+	ch <- c.memoryLimit
+	ch <- c.safetyLimit
+	ch <- c.softMemoryLimit
+	ch <- c.heapLive
+	ch <- c.memoryUsageRatio
+	ch <- c.adjustments
+	ch <- c.enabled
+	ch <- c.emergency
+	ch <- c.emergencies
+	ch <- c.workingSet
+	ch <- c.nonHeap
+	ch <- c.ballast
+	ch <- c.budgetShare
+}
+
+// Collect 实现prometheus.Collector
+func (c *Collector) Collect(ch chan<- prometheus.Metric) {
+	snapshot := c.tuner.Snapshot()
+
+	ch <- prometheus.MustNewConstMetric(c.gogc, prometheus.GaugeValue, float64(snapshot.GOGC))
+	ch <- prometheus.MustNewConstMetric(c.memoryLimit, prometheus.GaugeValue,
+		float64(snapshot.MemoryLimit), string(snapshot.LimitSource))
+	ch <- prometheus.MustNewConstMetric(c.safetyLimit, prometheus.GaugeValue, float64(snapshot.SafetyLimit))
+	ch <- prometheus.MustNewConstMetric(c.softMemoryLimit, prometheus.GaugeValue, float64(snapshot.SoftMemoryLimit))
+	ch <- prometheus.MustNewConstMetric(c.heapLive, prometheus.GaugeValue, float64(snapshot.HeapLiveBytes))
+	ch <- prometheus.MustNewConstMetric(c.memoryUsageRatio, prometheus.GaugeValue, snapshot.MemoryUsageRatio)
+
+	for _, adjustment := range snapshot.Adjustments {
+		ch <- prometheus.MustNewConstMetric(c.adjustments, prometheus.CounterValue,
+			float64(adjustment.Count), adjustment.Strategy, adjustment.Reason)
+	}
+
+	var enabled float64
+	if snapshot.Enabled {
+		enabled = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.enabled, prometheus.GaugeValue,
+		enabled, string(snapshot.Mode), snapshot.Strategy)
+
+	var emergency float64
+	if snapshot.Emergency {
+		emergency = 1
+	}
+	ch <- prometheus.MustNewConstMetric(c.emergency, prometheus.GaugeValue, emergency)
+	ch <- prometheus.MustNewConstMetric(c.emergencies, prometheus.CounterValue, float64(snapshot.EmergencyCount))
+	ch <- prometheus.MustNewConstMetric(c.workingSet, prometheus.GaugeValue, float64(snapshot.WorkingSetBytes))
+	ch <- prometheus.MustNewConstMetric(c.nonHeap, prometheus.GaugeValue, float64(snapshot.RuntimeOverheadBytes), "runtime")
+	ch <- prometheus.MustNewConstMetric(c.nonHeap, prometheus.GaugeValue, float64(snapshot.ExternalBytes), "external")
+	ch <- prometheus.MustNewConstMetric(c.ballast, prometheus.GaugeValue, float64(snapshot.BallastBytes))
+	ch <- prometheus.MustNewConstMetric(c.budgetShare, prometheus.GaugeValue, snapshot.BudgetShare)
+}
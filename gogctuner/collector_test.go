@@ -0,0 +1,76 @@
+package gogctuner
+
+import (
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+func TestCollector(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+	sim.replay(liveTrace(200, 400))
+
+	registry := prometheus.NewPedanticRegistry()
+	if err := registry.Register(NewCollector(sim.tuner)); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	families, err := registry.Gather()
+	if err != nil {
+		t.Fatalf("Gather: %v", err)
+	}
+
+	// 指标名 + 标签 -> 值
+	values := make(map[string]float64)
+	for _, family := range families {
+		for _, metric := range family.GetMetric() {
+			key := family.GetName()
+			for _, label := range metric.GetLabel() {
+				key += "," + label.GetName() + "=" + label.GetValue()
+			}
+			switch {
+			case metric.GetGauge() != nil:
+				values[key] = metric.GetGauge().GetValue()
+			case metric.GetCounter() != nil:
+				values[key] = metric.GetCounter().GetValue()
+			}
+		}
+	}
+
+	want := map[string]float64{
+		"gogctuner_gogc": 150,
+		"gogctuner_memory_limit_bytes,source=config":                          simMemoryLimit,
+		"gogctuner_safety_limit_bytes":                                        1000 * mb,
+		"gogctuner_soft_memory_limit_bytes":                                   0,
+		"gogctuner_heap_live_bytes":                                           400 * mb,
+		"gogctuner_memory_usage_ratio":                                        0.2,
+		"gogctuner_adjustments_total,reason=heap_target,strategy=heap_target": 2,
+		"gogctuner_enabled,mode=gogc,strategy=heap_target":                    1,
+		"gogctuner_emergency":                                                 0,
+		"gogctuner_emergencies_total":                                         0,
+		"gogctuner_non_heap_bytes,kind=runtime":                               0,
+		"gogctuner_non_heap_bytes,kind=external":                              0,
+		"gogctuner_ballast_bytes":                                             0,
+		"gogctuner_budget_share":                                              1,
+	}
+	for key, value := range want {
+		got, ok := values[key]
+		if !ok {
+			t.Errorf("缺少指标 %s", key)
+			continue
+		}
+		if got != value {
+			t.Errorf("%s = %v, want %v", key, got, value)
+		}
+	}
+
+	sim.tuner.Stop()
+	families, err = registry.Gather()
+	if err != nil {
+		t.Fatalf("停止后 Gather: %v", err)
+	}
+	for _, family := range families {
+		if family.GetName() == "gogctuner_enabled" && family.GetMetric()[0].GetGauge().GetValue() != 0 {
+			t.Fatal("停止后 gogctuner_enabled 应为0")
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package gogctuner
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"strconv"
+)
+
+// applyDefaults 为未设置或不合法的配置项应用默认值
+func applyDefaults(config Config) Config {
+	if config.SafetyFactor <= 0 || config.SafetyFactor > 1 {
+		config.SafetyFactor = defaultSafetyFactor
+	}
+
+	if config.MinGOGC <= 0 {
+		config.MinGOGC = 25 // 最小值避免GC太频繁
+	}
+
+	if config.MaxGOGC <= 0 {
+		config.MaxGOGC = 500 // 最大值避免内存占用过高
+	}
+
+	if config.Mode == "" {
+		config.Mode = ModeGOGC
+	}
+
+	if config.GCCPUCeiling <= 0 || config.GCCPUCeiling > 1 {
+		config.GCCPUCeiling = defaultGCCPUCeiling
+	}
+
+	if config.TargetGCCPUPercent <= 0 || config.TargetGCCPUPercent > 100 {
+		config.TargetGCCPUPercent = defaultTargetGCCPUPercent
+	}
+
+	if config.HistorySize <= 0 {
+		config.HistorySize = defaultHistorySize
+	}
+
+	if config.LimitRefreshInterval == 0 {
+		config.LimitRefreshInterval = defaultLimitRefreshInterval
+	}
+
+	if config.BallastRatio <= 0 || config.BallastRatio >= 1 {
+		config.BallastRatio = defaultBallastRatio
+	}
+
+	if config.BallastGOGC <= 0 {
+		config.BallastGOGC = 100
+	}
+
+	if config.EmergencyHeapRatio == 0 {
+		config.EmergencyHeapRatio = defaultEmergencyHeapRatio
+	}
+
+	if config.EmergencyRSSRatio == 0 {
+		config.EmergencyRSSRatio = defaultEmergencyRSSRatio
+	}
+
+	if config.EmergencyHysteresis <= 0 {
+		config.EmergencyHysteresis = defaultEmergencyHysteresis
+	}
+
+	if config.EmergencyCooldown <= 0 {
+		config.EmergencyCooldown = defaultEmergencyCooldown
+	}
+
+	if !config.AllowPeakOverride {
+		config.PeakThreshold = 1.0
+	} else if config.PeakThreshold < 1.0 {
+		config.PeakThreshold = 1.5
+	}
+
+	return config
+}
+
+// validateConfig 校验运行时更新的配置，零值表示使用默认值
+func validateConfig(config Config) error {
+	if config.MemoryHardLimit < 0 {
+		return fmt.Errorf("gogctuner: MemoryHardLimit不能为负数: %d", config.MemoryHardLimit)
+	}
+	if config.SafetyFactor < 0 || config.SafetyFactor > 1 {
+		return fmt.Errorf("gogctuner: SafetyFactor必须在(0, 1]范围内: %v", config.SafetyFactor)
+	}
+	if config.MinGOGC < 0 || config.MaxGOGC < 0 {
+		return fmt.Errorf("gogctuner: MinGOGC/MaxGOGC不能为负数: %d/%d", config.MinGOGC, config.MaxGOGC)
+	}
+	if config.MinGOGC > 0 && config.MaxGOGC > 0 && config.MinGOGC > config.MaxGOGC {
+		return fmt.Errorf("gogctuner: MinGOGC(%d)不能大于MaxGOGC(%d)", config.MinGOGC, config.MaxGOGC)
+	}
+	if config.AllowPeakOverride && config.PeakThreshold != 0 && config.PeakThreshold < 1 {
+		return fmt.Errorf("gogctuner: PeakThreshold不能小于1: %v", config.PeakThreshold)
+	}
+	switch config.Mode {
+	case "", ModeGOGC, ModeMemoryLimit, ModeGCCPU, ModeBallast:
+	default:
+		return fmt.Errorf("gogctuner: 未知调优模式: %s", config.Mode)
+	}
+	if config.LimitModeGOGC < GOGCOff {
+		return fmt.Errorf("gogctuner: LimitModeGOGC必须为GOGCOff(-1)、0或正数: %d", config.LimitModeGOGC)
+	}
+	if config.GCCPUCeiling < 0 || config.GCCPUCeiling > 1 {
+		return fmt.Errorf("gogctuner: GCCPUCeiling必须在(0, 1]范围内: %v", config.GCCPUCeiling)
+	}
+	if config.TargetGCCPUPercent < 0 || config.TargetGCCPUPercent > 100 {
+		return fmt.Errorf("gogctuner: TargetGCCPUPercent必须在(0, 100]范围内: %v", config.TargetGCCPUPercent)
+	}
+	if config.BallastRatio < 0 || config.BallastRatio >= 1 {
+		return fmt.Errorf("gogctuner: BallastRatio必须在(0, 1)范围内: %v", config.BallastRatio)
+	}
+	if config.BallastGOGC < 0 {
+		return fmt.Errorf("gogctuner: BallastGOGC不能为负数: %d", config.BallastGOGC)
+	}
+	if config.EmergencyHeapRatio > 1 || config.EmergencyRSSRatio > 1 {
+		return fmt.Errorf("gogctuner: 紧急模式阈值不能大于1: %v/%v", config.EmergencyHeapRatio, config.EmergencyRSSRatio)
+	}
+	if config.EmergencyHysteresis < 0 || config.EmergencyHysteresis >= 1 {
+		return fmt.Errorf("gogctuner: EmergencyHysteresis必须在[0, 1)范围内: %v", config.EmergencyHysteresis)
+	}
+	if config.EmergencyCooldown < 0 {
+		return fmt.Errorf("gogctuner: EmergencyCooldown不能为负数: %s", config.EmergencyCooldown)
+	}
+	return nil
+}
+
+// Config 获取当前生效的配置（已应用默认值）
+func (t *Tuner) Config() Config {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.config
+}
+
+// UpdateConfig 在运行时原子地替换配置并立即重新调整
+// 配置非法时返回错误且不做任何修改。Logger、DebugMode、GCNotifier、HistorySize、
+// LimitRefreshInterval和Runtime只在创建时生效；未设置Strategy时，Mode变化会切换到对应的内置策略；
+// MemoryHardLimit改为0时开始定期刷新自动探测的内存限制，改为正数时停止刷新
+func (t *Tuner) UpdateConfig(config Config) error {
+	return t.updateConfig(func(Config) Config { return config })
+}
+
+// updateConfig 以当前配置为基础计算新配置并更新
+// 探测内存限制需要读取文件，在锁外进行；期间配置被其他调用修改时基于新的配置重新计算，
+// 保证并发的补丁不会互相覆盖
+func (t *Tuner) updateConfig(update func(Config) Config) error {
+	for {
+		t.mu.Lock()
+		config := update(t.config)
+		version := t.configVersion
+		t.mu.Unlock()
+
+		if err := validateConfig(config); err != nil {
+			return err
+		}
+		config = applyDefaults(config)
+
+		// MemoryHardLimit或探测路径变化时重新确定内存限制
+		memLimit, source, err := resolveMemoryLimit(config)
+		if err != nil {
+			return err
+		}
+
+		t.mu.Lock()
+		if t.configVersion != version {
+			t.mu.Unlock()
+			continue
+		}
+		t.configVersion++
+		t.applyConfig(config, memLimit, source)
+		t.mu.Unlock()
+
+		t.adjustGOGC()
+		return nil
+	}
+}
+
+// applyConfig 替换配置，调用方需持有t.mu
+func (t *Tuner) applyConfig(config Config, memLimit int64, source LimitSource) {
+	old := t.config
+	config.Logger = old.Logger
+	config.DebugMode = old.DebugMode
+	config.GCNotifier = old.GCNotifier
+	config.HistorySize = old.HistorySize
+	config.LimitRefreshInterval = old.LimitRefreshInterval
+	config.Runtime = old.Runtime
+	t.config = config
+
+	if config.Strategy != nil {
+		t.strategy = config.Strategy
+	} else if old.Strategy != nil || old.Mode != config.Mode {
+		t.strategy = StrategyForMode(config.Mode)
+	}
+	t.memoryLimit.Store(memLimit)
+	t.limitSource.Store(source)
+	// 自动探测与固定限制之间切换时启动或停止限制刷新协程
+	t.syncLimitWatcher()
+
+	t.logger.Info(logMsgConfigUpdate,
+		slog.String(logKeyMode, string(config.Mode)),
+		slog.String(logKeyStrategy, t.strategy.Name()),
+		slog.Int64(logKeyMemoryLimit, memLimit),
+		slog.Float64(logKeySafetyFactor, config.SafetyFactor),
+		slog.Int(logKeyMinGOGC, config.MinGOGC),
+		slog.Int(logKeyMaxGOGC, config.MaxGOGC),
+	)
+}
+
+// ConfigPatch 运行时可修改的配置项，nil字段表示保持不变
+// 用于配置文件和环境变量，JSON字段名与环境变量后缀一一对应
+type ConfigPatch struct {
+	MemoryHardLimit    *int64      `json:"memory_hard_limit,omitempty"`
+	SafetyFactor       *float64    `json:"safety_factor,omitempty"`
+	MinGOGC            *int        `json:"min_gogc,omitempty"`
+	MaxGOGC            *int        `json:"max_gogc,omitempty"`
+	AllowPeakOverride  *bool       `json:"allow_peak_override,omitempty"`
+	PeakThreshold      *float64    `json:"peak_threshold,omitempty"`
+	Mode               *TuningMode `json:"mode,omitempty"`
+	LimitModeGOGC      *int        `json:"limit_mode_gogc,omitempty"`
+	GCCPUCeiling       *float64    `json:"gc_cpu_ceiling,omitempty"`
+	TargetGCCPUPercent *float64    `json:"target_gc_cpu_percent,omitempty"`
+	EmergencyHeapRatio *float64    `json:"emergency_heap_ratio,omitempty"`
+	EmergencyRSSRatio  *float64    `json:"emergency_rss_ratio,omitempty"`
+	BallastRatio       *float64    `json:"ballast_ratio,omitempty"`
+	BallastGOGC        *int        `json:"ballast_gogc,omitempty"`
+}
+
+// Apply 将补丁应用到配置上
+func (p ConfigPatch) Apply(config Config) Config {
+	if p.MemoryHardLimit != nil {
+		config.MemoryHardLimit = *p.MemoryHardLimit
+	}
+	if p.SafetyFactor != nil {
+		config.SafetyFactor = *p.SafetyFactor
+	}
+	if p.MinGOGC != nil {
+		config.MinGOGC = *p.MinGOGC
+	}
+	if p.MaxGOGC != nil {
+		config.MaxGOGC = *p.MaxGOGC
+	}
+	if p.AllowPeakOverride != nil {
+		config.AllowPeakOverride = *p.AllowPeakOverride
+	}
+	if p.PeakThreshold != nil {
+		config.PeakThreshold = *p.PeakThreshold
+	}
+	if p.Mode != nil {
+		config.Mode = *p.Mode
+	}
+	if p.LimitModeGOGC != nil {
+		config.LimitModeGOGC = *p.LimitModeGOGC
+	}
+	if p.GCCPUCeiling != nil {
+		config.GCCPUCeiling = *p.GCCPUCeiling
+	}
+	if p.TargetGCCPUPercent != nil {
+		config.TargetGCCPUPercent = *p.TargetGCCPUPercent
+	}
+	if p.EmergencyHeapRatio != nil {
+		config.EmergencyHeapRatio = *p.EmergencyHeapRatio
+	}
+	if p.EmergencyRSSRatio != nil {
+		config.EmergencyRSSRatio = *p.EmergencyRSSRatio
+	}
+	if p.BallastRatio != nil {
+		config.BallastRatio = *p.BallastRatio
+	}
+	if p.BallastGOGC != nil {
+		config.BallastGOGC = *p.BallastGOGC
+	}
+	return config
+}
+
+// patchFromConfig 包含config中所有可修改配置项的补丁
+func patchFromConfig(config Config) ConfigPatch {
+	return ConfigPatch{
+		MemoryHardLimit:    &config.MemoryHardLimit,
+		SafetyFactor:       &config.SafetyFactor,
+		MinGOGC:            &config.MinGOGC,
+		MaxGOGC:            &config.MaxGOGC,
+		AllowPeakOverride:  &config.AllowPeakOverride,
+		PeakThreshold:      &config.PeakThreshold,
+		Mode:               &config.Mode,
+		LimitModeGOGC:      &config.LimitModeGOGC,
+		GCCPUCeiling:       &config.GCCPUCeiling,
+		TargetGCCPUPercent: &config.TargetGCCPUPercent,
+		EmergencyHeapRatio: &config.EmergencyHeapRatio,
+		EmergencyRSSRatio:  &config.EmergencyRSSRatio,
+		BallastRatio:       &config.BallastRatio,
+		BallastGOGC:        &config.BallastGOGC,
+	}
+}
+
+// ApplyPatch 将补丁应用到当前配置并更新，并发调用的补丁不会互相覆盖
+func (t *Tuner) ApplyPatch(p ConfigPatch) error {
+	return t.updateConfig(p.Apply)
+}
+
+// ConfigPatchFromEnv 从环境变量读取配置补丁
+// 变量名为 prefix + 大写的JSON字段名，例如 GOGCTUNER_SAFETY_FACTOR、GOGCTUNER_MAX_GOGC
+func ConfigPatchFromEnv(prefix string) (ConfigPatch, error) {
+	var p ConfigPatch
+	var err error
+
+	lookup := func(name string) (string, bool) {
+		return os.LookupEnv(prefix + name)
+	}
+	parseInt64 := func(name string) *int64 {
+		value, ok := lookup(name)
+		if !ok || err != nil {
+			return nil
+		}
+		parsed, parseErr := strconv.ParseInt(value, 10, 64)
+		if parseErr != nil {
+			err = fmt.Errorf("gogctuner: 解析环境变量%s%s失败: %w", prefix, name, parseErr)
+			return nil
+		}
+		return &parsed
+	}
+	parseInt := func(name string) *int {
+		parsed := parseInt64(name)
+		if parsed == nil {
+			return nil
+		}
+		value := int(*parsed)
+		return &value
+	}
+	parseFloat := func(name string) *float64 {
+		value, ok := lookup(name)
+		if !ok || err != nil {
+			return nil
+		}
+		parsed, parseErr := strconv.ParseFloat(value, 64)
+		if parseErr != nil {
+			err = fmt.Errorf("gogctuner: 解析环境变量%s%s失败: %w", prefix, name, parseErr)
+			return nil
+		}
+		return &parsed
+	}
+
+	p.MemoryHardLimit = parseInt64("MEMORY_HARD_LIMIT")
+	p.SafetyFactor = parseFloat("SAFETY_FACTOR")
+	p.MinGOGC = parseInt("MIN_GOGC")
+	p.MaxGOGC = parseInt("MAX_GOGC")
+	p.PeakThreshold = parseFloat("PEAK_THRESHOLD")
+	p.LimitModeGOGC = parseInt("LIMIT_MODE_GOGC")
+	p.GCCPUCeiling = parseFloat("GC_CPU_CEILING")
+	p.TargetGCCPUPercent = parseFloat("TARGET_GC_CPU_PERCENT")
+	p.EmergencyHeapRatio = parseFloat("EMERGENCY_HEAP_RATIO")
+	p.EmergencyRSSRatio = parseFloat("EMERGENCY_RSS_RATIO")
+	p.BallastRatio = parseFloat("BALLAST_RATIO")
+	p.BallastGOGC = parseInt("BALLAST_GOGC")
+	if value, ok := lookup("ALLOW_PEAK_OVERRIDE"); ok && err == nil {
+		parsed, parseErr := strconv.ParseBool(value)
+		if parseErr != nil {
+			err = fmt.Errorf("gogctuner: 解析环境变量%sALLOW_PEAK_OVERRIDE失败: %w", prefix, parseErr)
+		}
+		p.AllowPeakOverride = &parsed
+	}
+	if value, ok := lookup("MODE"); ok {
+		mode := TuningMode(value)
+		p.Mode = &mode
+	}
+
+	if err != nil {
+		return ConfigPatch{}, err
+	}
+	return p, nil
+}
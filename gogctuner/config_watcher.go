@@ -0,0 +1,144 @@
+package gogctuner
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+const (
+	// 默认环境变量前缀
+	DefaultEnvPrefix = "GOGCTUNER_"
+	// 配置文件默认轮询间隔
+	defaultConfigPollInterval = 10 * time.Second
+)
+
+// ConfigWatcher 从JSON配置文件和环境变量加载配置补丁并应用到调优器
+// 适用于Kubernetes ConfigMap挂载等场景：文件内容为ConfigPatch的JSON，修改后自动生效。
+// 文件每次变化都以Start时的配置（已应用环境变量）为基础重新应用，
+// 因此从文件中删除的配置项会恢复为启动时的值，期间通过管理接口等途径做的修改也会被覆盖
+type ConfigWatcher struct {
+	// 配置文件路径，为空时只读取环境变量
+	Path string
+	// 轮询间隔，默认10秒
+	Interval time.Duration
+	// 环境变量前缀，默认GOGCTUNER_
+	EnvPrefix string
+
+	tuner   *Tuner
+	modTime time.Time
+	size    int64
+	// Start时应用环境变量后的配置，重新加载文件时以此为基础
+	base     ConfigPatch
+	stopCh   chan struct{}
+	stopOnce sync.Once
+}
+
+// NewConfigWatcher 创建配置监听器
+func NewConfigWatcher(t *Tuner, path string) *ConfigWatcher {
+	return &ConfigWatcher{
+		Path:      path,
+		Interval:  defaultConfigPollInterval,
+		EnvPrefix: DefaultEnvPrefix,
+		tuner:     t,
+	}
+}
+
+// Start 先应用环境变量和配置文件中的配置，再在后台轮询配置文件的变化
+// 初始配置非法时返回错误；之后的加载失败只记录日志，保持当前配置不变
+func (w *ConfigWatcher) Start() error {
+	patch, err := ConfigPatchFromEnv(w.EnvPrefix)
+	if err != nil {
+		return err
+	}
+	config := patch.Apply(w.tuner.Config())
+	w.base = patchFromConfig(config)
+
+	if w.Path != "" {
+		filePatch, changed, err := w.load()
+		if err != nil {
+			return err
+		}
+		if changed {
+			config = filePatch.Apply(config)
+		}
+	}
+	if err := w.tuner.UpdateConfig(config); err != nil {
+		return err
+	}
+
+	if w.Path == "" {
+		return nil
+	}
+	interval := w.Interval
+	if interval <= 0 {
+		interval = defaultConfigPollInterval
+	}
+	w.stopCh = make(chan struct{})
+	go w.watch(w.tuner.runtime.NewTicker(interval), w.stopCh)
+	return nil
+}
+
+// Stop 停止轮询配置文件，可以重复或并发调用，停止后不能再次Start
+func (w *ConfigWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		if w.stopCh != nil {
+			close(w.stopCh)
+		}
+	})
+}
+
+// watch 每次ticker触发时检查配置文件，直到stopCh关闭
+func (w *ConfigWatcher) watch(ticker Ticker, stopCh <-chan struct{}) {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			w.reload()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// reload 配置文件变化时重新加载
+func (w *ConfigWatcher) reload() {
+	patch, changed, err := w.load()
+	if err == nil && changed {
+		err = w.tuner.updateConfig(func(config Config) Config {
+			return patch.Apply(w.base.Apply(config))
+		})
+	}
+	if err != nil {
+		w.tuner.logger.Warn(logMsgConfigReload,
+			slog.String(logKeyPath, w.Path),
+			slog.Any(logKeyError, err),
+		)
+	}
+}
+
+// load 读取配置文件，根据修改时间和大小判断是否变化
+func (w *ConfigWatcher) load() (ConfigPatch, bool, error) {
+	info, err := os.Stat(w.Path)
+	if err != nil {
+		return ConfigPatch{}, false, err
+	}
+	if info.ModTime().Equal(w.modTime) && info.Size() == w.size {
+		return ConfigPatch{}, false, nil
+	}
+
+	data, err := os.ReadFile(w.Path)
+	if err != nil {
+		return ConfigPatch{}, false, err
+	}
+	var patch ConfigPatch
+	if err := json.Unmarshal(data, &patch); err != nil {
+		return ConfigPatch{}, false, err
+	}
+	w.modTime = info.ModTime()
+	w.size = info.Size()
+	return patch, true, nil
+}
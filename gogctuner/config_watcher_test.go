@@ -0,0 +1,194 @@
+package gogctuner
+
+import (
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestConfigWatcher(t *testing.T) {
+	t.Setenv("TEST_GOGCTUNER_MAX_GOGC", "400")
+	path := filepath.Join(t.TempDir(), "gogctuner.json")
+	writeFile(t, path, `{"min_gogc": 50, "safety_factor": 0.4}`)
+
+	sim := newSimulation(t, simConfig())
+	watcher := NewConfigWatcher(sim.tuner, path)
+	watcher.EnvPrefix = "TEST_GOGCTUNER_"
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+	defer watcher.Stop()
+
+	config := sim.tuner.Config()
+	if config.MinGOGC != 50 || config.MaxGOGC != 400 || config.SafetyFactor != 0.4 {
+		t.Fatalf("启动后 MinGOGC=%d MaxGOGC=%d SafetyFactor=%v", config.MinGOGC, config.MaxGOGC, config.SafetyFactor)
+	}
+
+	// 文件内容未变化时不重新应用
+	sim.tuner.ApplyPatch(ConfigPatch{MinGOGC: ptr(60)})
+	watcher.reload()
+	if got := sim.tuner.Config().MinGOGC; got != 60 {
+		t.Fatalf("文件未变化时 MinGOGC = %d, want 60", got)
+	}
+
+	// 删除的配置项恢复为启动时的值，环境变量仍然生效
+	writeFile(t, path, `{"min_gogc": 30}`)
+	watcher.reload()
+	config = sim.tuner.Config()
+	if config.MinGOGC != 30 || config.MaxGOGC != 400 || config.SafetyFactor != simSafetyFactor {
+		t.Fatalf("重新加载后 MinGOGC=%d MaxGOGC=%d SafetyFactor=%v", config.MinGOGC, config.MaxGOGC, config.SafetyFactor)
+	}
+
+	// 非法配置不生效
+	for _, content := range []string{`{"min_gogc": 1000}`, `{"min_gogc": `} {
+		writeFile(t, path, content)
+		watcher.reload()
+		if got := sim.tuner.Config().MinGOGC; got != 30 {
+			t.Fatalf("加载%s后 MinGOGC = %d, want 30", content, got)
+		}
+	}
+}
+
+func TestConfigWatcherStop(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "gogctuner.json")
+	writeFile(t, path, `{}`)
+
+	watcher := NewConfigWatcher(newSimulation(t, simConfig()).tuner, path)
+	watcher.Interval = time.Millisecond
+	if err := watcher.Start(); err != nil {
+		t.Fatalf("Start: %v", err)
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			watcher.Stop()
+		}()
+	}
+	wg.Wait()
+	watcher.Stop()
+}
+
+func TestConfigPatchFromEnv(t *testing.T) {
+	t.Setenv("TEST_GOGCTUNER_SAFETY_FACTOR", "0.6")
+	t.Setenv("TEST_GOGCTUNER_MEMORY_HARD_LIMIT", "1073741824")
+	t.Setenv("TEST_GOGCTUNER_ALLOW_PEAK_OVERRIDE", "true")
+	t.Setenv("TEST_GOGCTUNER_MODE", "gc_cpu")
+	patch, err := ConfigPatchFromEnv("TEST_GOGCTUNER_")
+	if err != nil {
+		t.Fatalf("ConfigPatchFromEnv: %v", err)
+	}
+	config := patch.Apply(Config{MinGOGC: 10})
+	if config.SafetyFactor != 0.6 || config.MemoryHardLimit != 1<<30 || !config.AllowPeakOverride ||
+		config.Mode != ModeGCCPU || config.MinGOGC != 10 || patch.MaxGOGC != nil {
+		t.Fatalf("应用环境变量后 = %+v", config)
+	}
+
+	t.Setenv("TEST_GOGCTUNER_MAX_GOGC", "lots")
+	if _, err := ConfigPatchFromEnv("TEST_GOGCTUNER_"); err == nil {
+		t.Fatal("无法解析的环境变量应返回错误")
+	}
+}
+
+func TestApplyPatchConcurrent(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+	patches := []ConfigPatch{
+		{MinGOGC: ptr(40)},
+		{MaxGOGC: ptr(450)},
+		{SafetyFactor: ptr(0.6)},
+		{GCCPUCeiling: ptr(0.3)},
+	}
+	var wg sync.WaitGroup
+	for _, patch := range patches {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; i < 200; i++ {
+				if err := sim.tuner.ApplyPatch(patch); err != nil {
+					t.Errorf("ApplyPatch: %v", err)
+					return
+				}
+			}
+		}()
+	}
+	wg.Wait()
+
+	// 每个补丁修改不同的字段，并发应用后都应生效
+	config := sim.tuner.Config()
+	if config.MinGOGC != 40 || config.MaxGOGC != 450 || config.SafetyFactor != 0.6 || config.GCCPUCeiling != 0.3 {
+		t.Fatalf("并发应用补丁后 MinGOGC=%d MaxGOGC=%d SafetyFactor=%v GCCPUCeiling=%v",
+			config.MinGOGC, config.MaxGOGC, config.SafetyFactor, config.GCCPUCeiling)
+	}
+}
+
+func TestUpdateConfigLimitWatcher(t *testing.T) {
+	detector := fakeRoot(t, map[string]string{
+		"proc/self/cgroup":          "0::/\n",
+		"proc/meminfo":              hostMemInfo,
+		"cgroup/cgroup.controllers": "memory\n",
+		"cgroup/memory.max":         "2097152000\n",
+	})
+	sim := newSimulation(t, simConfig())
+	// 模拟时默认不刷新，这里开启刷新，由假运行时的周期定时器触发
+	sim.tuner.mu.Lock()
+	sim.tuner.config.LimitRefreshInterval = 10 * time.Second
+	sim.tuner.mu.Unlock()
+
+	watching := func() bool {
+		sim.tuner.mu.Lock()
+		defer sim.tuner.mu.Unlock()
+		return sim.tuner.stopCh != nil
+	}
+	if watching() {
+		t.Fatal("固定内存限制时不应刷新")
+	}
+
+	// 切换为自动探测后开始刷新，跟随cgroup变化
+	config := sim.tuner.Config()
+	config.MemoryHardLimit = 0
+	config.CgroupRoot, config.ProcRoot = detector.CgroupRoot, detector.ProcRoot
+	if err := sim.tuner.UpdateConfig(config); err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+	if !watching() {
+		t.Fatal("自动探测内存限制时应启动刷新")
+	}
+	// 刷新协程收到定时器触发后异步刷新，推进时钟直到内存限制变化
+	writeFile(t, filepath.Join(detector.CgroupRoot, "memory.max"), "1048576000\n")
+	deadline := time.Now().Add(5 * time.Second)
+	for sim.tuner.MemoryLimit() != 1000*mb {
+		if time.Now().After(deadline) {
+			t.Fatalf("MemoryLimit = %d, 未跟随cgroup变化", sim.tuner.MemoryLimit())
+		}
+		sim.rt.advance(10 * time.Second)
+		time.Sleep(time.Millisecond)
+	}
+
+	// 切换回固定限制后停止刷新
+	config.MemoryHardLimit = simMemoryLimit
+	if err := sim.tuner.UpdateConfig(config); err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+	if watching() {
+		t.Fatal("固定内存限制后应停止刷新")
+	}
+	// 刷新协程退出时停止周期定时器
+	for deadline := time.Now().Add(5 * time.Second); sim.rt.tickers() != 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("刷新协程没有停止周期定时器")
+		}
+		time.Sleep(time.Millisecond)
+	}
+	writeFile(t, filepath.Join(detector.CgroupRoot, "memory.max"), "524288000\n")
+	sim.rt.advance(time.Minute)
+	if got := sim.tuner.MemoryLimit(); got != simMemoryLimit {
+		t.Fatalf("停止刷新后 MemoryLimit = %d, want %d", got, simMemoryLimit)
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
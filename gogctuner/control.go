@@ -0,0 +1,130 @@
+package gogctuner
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+const (
+	// 人工干预记录使用的策略名
+	manualStrategyName = "manual"
+	// 固定GOGC的最长时间，避免忘记解除
+	maxPinTTL = 24 * time.Hour
+)
+
+// Pause 暂停调优，保持当前GOGC和软内存限制不变，直到Resume
+func (t *Tuner) Pause() {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.paused {
+		return
+	}
+	t.paused = true
+	t.logger.Info(logMsgPause, slog.Int(logKeyGOGC, t.currentGOGC))
+}
+
+// Resume 恢复调优并立即重新调整
+func (t *Tuner) Resume() {
+	t.mu.Lock()
+	if !t.paused {
+		t.mu.Unlock()
+		return
+	}
+	t.paused = false
+	t.logger.Info(logMsgResume, slog.Int(logKeyGOGC, t.currentGOGC))
+	t.mu.Unlock()
+
+	t.adjustGOGC()
+}
+
+// Pin 在ttl时间内将GOGC固定为gogc，期间策略不再调整，到期后自动恢复调优
+// gogc必须为正数或GOGCOff，且不受MinGOGC/MaxGOGC约束；ttl最长24小时
+func (t *Tuner) Pin(gogc int, ttl time.Duration) error {
+	if gogc <= 0 && gogc != GOGCOff {
+		return fmt.Errorf("gogctuner: 固定的GOGC必须为正数或GOGCOff(-1): %d", gogc)
+	}
+	if ttl <= 0 || ttl > maxPinTTL {
+		return fmt.Errorf("gogctuner: 固定时长必须在(0, %s]范围内: %s", maxPinTTL, ttl)
+	}
+
+	// 读取运行时指标可能较慢，在加锁前完成
+	stats := t.runtime.ReadStats()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.state != stateRunning {
+		return fmt.Errorf("gogctuner: 调优器未运行")
+	}
+
+	now := t.runtime.Now()
+	oldGOGC := t.currentGOGC
+	t.pinnedGOGC = gogc
+	t.pinnedUntil = now.Add(ttl)
+	if t.pinTimer != nil {
+		t.pinTimer.Stop()
+	}
+	until := t.pinnedUntil
+	t.pinTimer = t.runtime.AfterFunc(ttl, func() {
+		t.track(func() { t.unpin(until) })
+	})
+
+	if gogc != oldGOGC {
+		t.currentGOGC = gogc
+		t.runtime.SetGCPercent(gogc)
+		t.adjustments[adjustmentKey{Strategy: manualStrategyName, Reason: "pin"}]++
+		t.adjustmentLog.add(Adjustment{
+			Time:               now,
+			OldGOGC:            oldGOGC,
+			NewGOGC:            gogc,
+			OldSoftMemoryLimit: t.softLimit,
+			NewSoftMemoryLimit: t.softLimit,
+			LiveBytes:          stats.LiveBytes,
+			MemoryLimit:        t.memoryLimit.Load(),
+			Strategy:           manualStrategyName,
+			Reason:             "pin",
+		})
+	}
+
+	t.logger.Info(logMsgPin,
+		slog.Int(logKeyGOGC, gogc),
+		slog.Int(logKeyOldGOGC, oldGOGC),
+		slog.Duration(logKeyTTL, ttl),
+	)
+	return nil
+}
+
+// Unpin 提前解除GOGC固定并立即重新调整
+func (t *Tuner) Unpin() {
+	t.mu.Lock()
+	until := t.pinnedUntil
+	t.mu.Unlock()
+
+	t.unpin(until)
+}
+
+// unpin 解除截止时间为until的固定，until不匹配说明已被新的Pin替换
+func (t *Tuner) unpin(until time.Time) {
+	t.mu.Lock()
+	if t.pinnedUntil.IsZero() || !t.pinnedUntil.Equal(until) {
+		t.mu.Unlock()
+		return
+	}
+	if t.pinTimer != nil {
+		t.pinTimer.Stop()
+		t.pinTimer = nil
+	}
+	t.pinnedUntil = time.Time{}
+	t.logger.Info(logMsgUnpin, slog.Int(logKeyGOGC, t.pinnedGOGC))
+	t.pinnedGOGC = 0
+	t.mu.Unlock()
+
+	t.adjustGOGC()
+}
+
+// manualLocked 是否处于暂停或固定GOGC状态，调用方需持有t.mu
+func (t *Tuner) manualLocked() bool {
+	return t.paused || !t.pinnedUntil.IsZero()
+}
@@ -0,0 +1,219 @@
+package gogctuner
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// 默认注册表条目过期时间，需大于强制GC间隔，避免空闲进程被误判为已退出
+	defaultPeerStaleAfter = 3 * time.Minute
+	// 默认注册表同步间隔
+	defaultPeerRefreshInterval = time.Second
+	// 注册表条目文件后缀
+	peerFileSuffix = ".json"
+)
+
+// Coordinator 同一容器内多个调优器的协调器
+// 每个进程把自己的内存使用写入共享目录中的一个文件，并读取其他进程的文件，
+// 按存活堆(乘以权重)的比例分配安全限制，避免每个进程都以为独占整个内存限制。
+// 共享目录需要对所有进程可见，例如Pod内挂载的emptyDir
+type Coordinator struct {
+	// 注册表目录
+	Dir string
+	// 本进程的唯一标识，默认为 主机名-PID-随机后缀
+	// 同一Pod内的容器主机名相同，主进程通常都是PID 1，只用主机名和PID会互相覆盖条目
+	ID string
+	// 分配预算时的权重，默认1
+	Weight float64
+	// 超过该时间未更新的条目视为已退出，默认3分钟
+	StaleAfter time.Duration
+	// 同步注册表的最小间隔，默认1秒
+	RefreshInterval time.Duration
+
+	mu       sync.Mutex
+	lastSync time.Time
+	peers    []PeerUsage
+	// 未设置ID时首次同步生成的默认标识
+	defaultID string
+}
+
+// PeerUsage 注册表中一个进程的内存使用
+type PeerUsage struct {
+	ID  string `json:"id"`
+	PID int    `json:"pid"`
+	// 存活堆
+	LiveBytes uint64 `json:"live_bytes"`
+	// 运行时实际占用的内存
+	MappedBytes uint64 `json:"mapped_bytes"`
+	// 运行时非堆内存
+	RuntimeOverheadBytes uint64    `json:"runtime_overhead_bytes"`
+	Weight               float64   `json:"weight"`
+	UpdatedAt            time.Time `json:"updated_at"`
+}
+
+// NewCoordinator 创建使用dir作为注册表的协调器
+func NewCoordinator(dir string) *Coordinator {
+	return &Coordinator{Dir: dir}
+}
+
+// id 本进程的标识，调用方需持有c.mu
+func (c *Coordinator) id() string {
+	if c.ID != "" {
+		return c.ID
+	}
+	if c.defaultID == "" {
+		hostname, _ := os.Hostname()
+		suffix := make([]byte, 4)
+		rand.Read(suffix)
+		c.defaultID = fmt.Sprintf("%s-%d-%s", hostname, os.Getpid(), hex.EncodeToString(suffix))
+	}
+	return c.defaultID
+}
+
+func (c *Coordinator) weight() float64 {
+	if c.Weight <= 0 {
+		return 1
+	}
+	return c.Weight
+}
+
+// Peers 最近一次同步时注册表中的有效条目（包含本进程），按ID排序
+func (c *Coordinator) Peers() []PeerUsage {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]PeerUsage(nil), c.peers...)
+}
+
+// sync 写入本进程的条目并读取所有有效条目，间隔不足RefreshInterval时返回缓存
+func (c *Coordinator) sync(self PeerUsage) ([]PeerUsage, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	interval := c.RefreshInterval
+	if interval <= 0 {
+		interval = defaultPeerRefreshInterval
+	}
+	if c.peers != nil && self.UpdatedAt.Sub(c.lastSync) < interval {
+		return c.peers, nil
+	}
+
+	self.ID = c.id()
+	self.PID = os.Getpid()
+	self.Weight = c.weight()
+	if err := c.write(self); err != nil {
+		return nil, err
+	}
+	peers, err := c.read(self.UpdatedAt)
+	if err != nil {
+		return nil, err
+	}
+	c.peers = peers
+	c.lastSync = self.UpdatedAt
+	return peers, nil
+}
+
+// write 原子地写入本进程的条目
+func (c *Coordinator) write(self PeerUsage) error {
+	if err := os.MkdirAll(c.Dir, 0o755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(self)
+	if err != nil {
+		return err
+	}
+	tmp, err := os.CreateTemp(c.Dir, "."+self.ID+"-*")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return os.Rename(tmp.Name(), filepath.Join(c.Dir, self.ID+peerFileSuffix))
+}
+
+// read 读取所有未过期的条目，无法解析的条目忽略
+func (c *Coordinator) read(now time.Time) ([]PeerUsage, error) {
+	entries, err := os.ReadDir(c.Dir)
+	if err != nil {
+		return nil, err
+	}
+
+	staleAfter := c.StaleAfter
+	if staleAfter <= 0 {
+		staleAfter = defaultPeerStaleAfter
+	}
+
+	var peers []PeerUsage
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || strings.HasPrefix(name, ".") || !strings.HasSuffix(name, peerFileSuffix) {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(c.Dir, name))
+		if err != nil {
+			continue
+		}
+		var peer PeerUsage
+		if err := json.Unmarshal(data, &peer); err != nil {
+			continue
+		}
+		if now.Sub(peer.UpdatedAt) > staleAfter {
+			continue
+		}
+		peers = append(peers, peer)
+	}
+	sort.Slice(peers, func(i, j int) bool { return peers[i].ID < peers[j].ID })
+	return peers, nil
+}
+
+// leave 删除本进程的条目，其他进程下次同步时收回其预算
+func (c *Coordinator) leave() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.peers = nil
+	err := os.Remove(filepath.Join(c.Dir, c.id()+peerFileSuffix))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	return err
+}
+
+// budgetShare 本进程在所有进程中应得的预算比例
+// 按 存活堆 * 权重 分配，所有进程都还没有存活堆数据时按权重分配
+func budgetShare(selfID string, peers []PeerUsage) float64 {
+	var total, totalWeight, self, selfWeight float64
+	for _, peer := range peers {
+		demand := float64(peer.LiveBytes) * peer.Weight
+		total += demand
+		totalWeight += peer.Weight
+		if peer.ID == selfID {
+			self = demand
+			selfWeight = peer.Weight
+		}
+	}
+	switch {
+	case total > 0:
+		return self / total
+	case totalWeight > 0:
+		return selfWeight / totalWeight
+	default:
+		return 1
+	}
+}
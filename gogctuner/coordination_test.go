@@ -0,0 +1,262 @@
+package gogctuner
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 多进程测试中子进程使用的环境变量
+const (
+	envPeerDir   = "GOGCTUNER_TEST_PEER_DIR"
+	envPeerID    = "GOGCTUNER_TEST_PEER_ID"
+	envPeerLive  = "GOGCTUNER_TEST_PEER_LIVE_MB"
+	envPeerCount = "GOGCTUNER_TEST_PEER_COUNT"
+)
+
+// peerResult 子进程看到所有进程后的预算
+type peerResult struct {
+	Peers       int     `json:"peers"`
+	Share       float64 `json:"share"`
+	SafetyLimit int64   `json:"safety_limit"`
+	GOGC        int     `json:"gogc"`
+}
+
+// TestCoordinationProcesses 以子进程重新执行测试二进制，多个调优器共享同一注册表目录
+func TestCoordinationProcesses(t *testing.T) {
+	if dir := os.Getenv(envPeerDir); dir != "" {
+		runPeerProcess(t, dir)
+		return
+	}
+	if testing.Short() {
+		t.Skip("跳过多进程测试")
+	}
+
+	dir := t.TempDir()
+	lives := map[string]int{"a": 100, "b": 300, "c": 600}
+	cmds := make(map[string]*exec.Cmd)
+	outputs := make(map[string]*bytes.Buffer)
+	for id, live := range lives {
+		cmd := exec.Command(os.Args[0], "-test.run=^TestCoordinationProcesses$", "-test.v")
+		cmd.Env = append(os.Environ(),
+			envPeerDir+"="+dir,
+			envPeerID+"="+id,
+			envPeerLive+"="+strconv.Itoa(live),
+			envPeerCount+"="+strconv.Itoa(len(lives)),
+		)
+		outputs[id] = &bytes.Buffer{}
+		cmd.Stdout, cmd.Stderr = outputs[id], outputs[id]
+		if err := cmd.Start(); err != nil {
+			t.Fatalf("启动子进程%s: %v", id, err)
+		}
+		cmds[id] = cmd
+	}
+	defer func() {
+		for _, cmd := range cmds {
+			cmd.Process.Kill()
+		}
+	}()
+
+	// 按存活堆分配1000MB的安全限制：100/1000、300/1000、600/1000
+	deadline := time.Now().Add(30 * time.Second)
+	for id, live := range lives {
+		path := filepath.Join(dir, "results", id)
+		var data []byte
+		for {
+			var err error
+			if data, err = os.ReadFile(path); err == nil {
+				break
+			}
+			if time.Now().After(deadline) {
+				t.Fatalf("等待子进程%s的结果超时", id)
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		var res peerResult
+		if err := json.Unmarshal(data, &res); err != nil {
+			t.Fatalf("解析子进程%s的结果: %v", id, err)
+		}
+		want := float64(live) / 1000
+		if res.Peers != len(lives) || res.Share != want || res.SafetyLimit != int64(live)*mb {
+			t.Fatalf("子进程%s 结果 = %+v, want Peers=%d Share=%v SafetyLimit=%dMB", id, res, len(lives), want, live)
+		}
+		// 每个进程的GOGC = (分得的安全限制 - 存活堆) / 存活堆 = 0，限制为MinGOGC
+		if res.GOGC != 25 {
+			t.Fatalf("子进程%s GOGC = %d, want 25", id, res.GOGC)
+		}
+	}
+
+	// 通知子进程退出，退出时删除各自的条目
+	if err := os.WriteFile(filepath.Join(dir, "results", "release"), nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	for id, cmd := range cmds {
+		if err := cmd.Wait(); err != nil {
+			t.Fatalf("子进程%s退出: %v\n%s", id, err, outputs[id])
+		}
+	}
+	entries, err := filepath.Glob(filepath.Join(dir, "*"+peerFileSuffix))
+	if err != nil || len(entries) != 0 {
+		t.Fatalf("子进程退出后 注册表 = %v, %v", entries, err)
+	}
+}
+
+// runPeerProcess 子进程：按真实时钟同步注册表，看到所有进程后写出结果，等待父进程通知后停止
+func runPeerProcess(t *testing.T, dir string) {
+	id := os.Getenv(envPeerID)
+	live, _ := strconv.Atoi(os.Getenv(envPeerLive))
+	count, _ := strconv.Atoi(os.Getenv(envPeerCount))
+
+	config := simConfig()
+	config.Coordinator = NewCoordinator(dir)
+	config.Coordinator.ID = id
+	config.Coordinator.RefreshInterval = time.Millisecond
+	sim := newSimulation(t, config)
+
+	step := func() {
+		sim.rt.mu.Lock()
+		sim.rt.now = time.Now()
+		sim.rt.stats.GCCycles++
+		sim.rt.stats.LiveBytes = uint64(live) * mb
+		event := GCEvent{Cycles: sim.rt.stats.GCCycles, Time: sim.rt.now}
+		sim.rt.mu.Unlock()
+		sim.notifier.Notify(event)
+	}
+
+	deadline := time.Now().Add(20 * time.Second)
+	var snapshot Snapshot
+	for {
+		step()
+		snapshot = sim.tuner.Snapshot()
+		if snapshot.Peers == count {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("子进程%s 只看到%d个进程", id, snapshot.Peers)
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+
+	data, err := json.Marshal(peerResult{
+		Peers:       snapshot.Peers,
+		Share:       snapshot.BudgetShare,
+		SafetyLimit: snapshot.SafetyLimit,
+		GOGC:        snapshot.GOGC,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := filepath.Join(dir, "results")
+	if err := os.MkdirAll(results, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	// 先写临时文件再重命名，父进程不会读到不完整的结果
+	tmp := filepath.Join(results, fmt.Sprintf(".%s.tmp", id))
+	if err := os.WriteFile(tmp, data, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Rename(tmp, filepath.Join(results, id)); err != nil {
+		t.Fatal(err)
+	}
+
+	for {
+		if _, err := os.Stat(filepath.Join(results, "release")); err == nil {
+			break
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("子进程%s 等待退出通知超时", id)
+		}
+		// 继续同步，其他进程读取时本进程的条目不会过期
+		step()
+		time.Sleep(5 * time.Millisecond)
+	}
+	sim.tuner.Stop()
+}
+
+func TestCoordinatorStaleEntries(t *testing.T) {
+	dir := t.TempDir()
+	newPeer := func(id string) *simulation {
+		config := simConfig()
+		config.Coordinator = NewCoordinator(dir)
+		config.Coordinator.ID = id
+		config.Coordinator.StaleAfter = 30 * time.Second
+		return newSimulation(t, config)
+	}
+
+	// 崩溃的进程没有删除条目，超过StaleAfter后忽略
+	crashed, err := json.Marshal(PeerUsage{ID: "crashed", LiveBytes: 900 * mb, Weight: 1, UpdatedAt: newFakeRuntime().Now().Add(-time.Minute)})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "crashed"+peerFileSuffix), crashed, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	// 无法解析的条目和临时文件同样忽略
+	writeFile(t, filepath.Join(dir, "broken"+peerFileSuffix), "{")
+	writeFile(t, filepath.Join(dir, ".a-123"), "{}")
+
+	a := newPeer("a")
+	if got := a.step(traceStep{Live: 100 * mb}); got != 500 {
+		t.Fatalf("a GOGC = %d, want 500", got)
+	}
+	if snapshot := a.tuner.Snapshot(); snapshot.Peers != 1 || snapshot.BudgetShare != 1 {
+		t.Fatalf("a 快照 Peers=%d BudgetShare=%v", snapshot.Peers, snapshot.BudgetShare)
+	}
+
+	// b加入后按存活堆平分
+	b := newPeer("b")
+	b.step(traceStep{Live: 100 * mb})
+	if got := a.step(traceStep{Live: 100 * mb}); got != 400 {
+		t.Fatalf("b加入后 a GOGC = %d, want 400", got)
+	}
+
+	// b停止更新超过StaleAfter后，a收回全部预算
+	if got := a.step(traceStep{Live: 100 * mb, Interval: 31 * time.Second}); got != 500 {
+		t.Fatalf("b过期后 a GOGC = %d, want 500", got)
+	}
+	if peers := a.tuner.config.Coordinator.Peers(); len(peers) != 1 || peers[0].ID != "a" {
+		t.Fatalf("b过期后 注册表 = %+v", peers)
+	}
+}
+
+func TestCoordinatorDefaultID(t *testing.T) {
+	// 同一进程中的两个协调器模拟Pod内主机名和PID都相同的两个容器
+	dir := t.TempDir()
+	newPeer := func() *simulation {
+		config := simConfig()
+		config.Coordinator = NewCoordinator(dir)
+		return newSimulation(t, config)
+	}
+	a, b := newPeer(), newPeer()
+	a.step(traceStep{Live: 100 * mb})
+	b.step(traceStep{Live: 100 * mb})
+	if got := a.step(traceStep{Live: 100 * mb}); got != 400 {
+		t.Fatalf("a GOGC = %d, want 400", got)
+	}
+
+	peers := a.tuner.config.Coordinator.Peers()
+	if len(peers) != 2 || peers[0].ID == peers[1].ID {
+		t.Fatalf("注册表 = %+v, want 两个不同的条目", peers)
+	}
+	hostname, _ := os.Hostname()
+	prefix := fmt.Sprintf("%s-%d-", hostname, os.Getpid())
+	for _, peer := range peers {
+		if !strings.HasPrefix(peer.ID, prefix) || len(peer.ID) == len(prefix) {
+			t.Fatalf("默认ID = %q, want %s<随机后缀>", peer.ID, prefix)
+		}
+	}
+
+	// 默认ID在多次同步之间保持不变
+	b.step(traceStep{Live: 100 * mb, Interval: 2 * time.Second})
+	files, err := filepath.Glob(filepath.Join(dir, "*"+peerFileSuffix))
+	if err != nil || len(files) != 2 {
+		t.Fatalf("注册表文件 = %v, %v, want 2个", files, err)
+	}
+}
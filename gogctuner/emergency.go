@@ -0,0 +1,180 @@
+package gogctuner
+
+import (
+	"log/slog"
+	"time"
+)
+
+const (
+	// 默认紧急模式阈值（占内存硬限制的比例）
+	defaultEmergencyHeapRatio = 0.9
+	defaultEmergencyRSSRatio  = 0.95
+	// 默认退出紧急模式的回差
+	defaultEmergencyHysteresis = 0.1
+	// 默认紧急模式期间重复强制GC的最小间隔
+	defaultEmergencyCooldown = 10 * time.Second
+	// 保留的紧急事件条数
+	maxEmergencyEvents = 32
+	// 紧急模式下调整记录的原因
+	reasonEmergency = "emergency"
+)
+
+// EmergencyTrigger 触发紧急模式的指标
+type EmergencyTrigger string
+
+const (
+	// 存活堆超过阈值
+	EmergencyTriggerHeap EmergencyTrigger = "heap"
+	// 进程RSS超过阈值
+	EmergencyTriggerRSS EmergencyTrigger = "rss"
+)
+
+// EmergencyEvent 紧急模式事件
+type EmergencyEvent struct {
+	Time time.Time `json:"time"`
+	// true表示进入或重复触发紧急模式，false表示退出
+	Active bool `json:"active"`
+	// 紧急模式期间超过冷却时间后再次触发
+	Repeat bool `json:"repeat"`
+	// 触发指标，退出事件为空
+	Trigger EmergencyTrigger `json:"trigger,omitempty"`
+	// 触发时的存活堆和RSS，未读取RSS时为0
+	LiveBytes uint64 `json:"live_bytes"`
+	RSSBytes  int64  `json:"rss_bytes"`
+	// 强制GC后的存活堆，退出事件为0
+	LiveBytesAfterGC uint64 `json:"live_bytes_after_gc"`
+	MemoryLimit      int64  `json:"memory_limit_bytes"`
+	// 退出事件中为紧急模式持续时间
+	Duration time.Duration `json:"duration_ns"`
+}
+
+// emergencyState 紧急模式状态，由t.mu保护
+type emergencyState struct {
+	active     bool
+	since      time.Time
+	lastAction time.Time
+	count      uint64
+	events     []EmergencyEvent
+}
+
+// readEmergencyRSS 读取紧急模式检查使用的进程RSS，读取文件较慢，不能持有t.mu
+// 不检查RSS、非Linux或读取失败时返回0，此时只检查存活堆
+func readEmergencyRSS(config Config) int64 {
+	if config.EmergencyRSSRatio <= 0 {
+		return 0
+	}
+	rss, err := readProcessRSS(config.ProcRoot)
+	if err != nil {
+		return 0
+	}
+	return rss
+}
+
+// checkEmergency 根据存活堆和RSS判断是否进入或退出紧急模式，调用方需持有t.mu
+// rss为readEmergencyRSS的结果，返回需要处理的事件，没有状态变化时返回nil
+func (t *Tuner) checkEmergency(s State, rss int64) *EmergencyEvent {
+	if s.MemoryLimit <= 0 {
+		return nil
+	}
+	heapRatio, rssRatio := t.config.EmergencyHeapRatio, t.config.EmergencyRSSRatio
+	limit := float64(s.MemoryLimit)
+	usage := float64(s.LiveBytes) / limit
+
+	var rssUsage float64
+	if rssRatio > 0 {
+		rssUsage = float64(rss) / limit
+	} else {
+		rss = 0
+	}
+
+	var trigger EmergencyTrigger
+	switch {
+	case heapRatio > 0 && usage >= heapRatio:
+		trigger = EmergencyTriggerHeap
+	case rssRatio > 0 && rssUsage >= rssRatio:
+		trigger = EmergencyTriggerRSS
+	}
+
+	e := &t.emergency
+	event := &EmergencyEvent{
+		Time:        s.Time,
+		Active:      true,
+		Trigger:     trigger,
+		LiveBytes:   s.LiveBytes,
+		RSSBytes:    rss,
+		MemoryLimit: s.MemoryLimit,
+	}
+
+	if !e.active {
+		if trigger == "" {
+			return nil
+		}
+		e.active = true
+		e.since = s.Time
+		e.lastAction = s.Time
+		e.count++
+		return event
+	}
+
+	// 回差：所有指标都降到阈值减去回差以下才退出，避免在阈值附近反复进出
+	hysteresis := t.config.EmergencyHysteresis
+	heapClear := heapRatio <= 0 || usage < heapRatio-hysteresis
+	rssClear := rssRatio <= 0 || rssUsage < rssRatio-hysteresis
+	if heapClear && rssClear {
+		e.active = false
+		event.Active = false
+		event.Duration = s.Time.Sub(e.since)
+		return event
+	}
+
+	if trigger != "" && s.Time.Sub(e.lastAction) >= t.config.EmergencyCooldown {
+		e.lastAction = s.Time
+		e.count++
+		event.Repeat = true
+		return event
+	}
+	return nil
+}
+
+// handleEmergency 执行紧急动作并记录事件，不能持有t.mu
+func (t *Tuner) handleEmergency(event EmergencyEvent, onEmergency func(EmergencyEvent)) {
+	if event.Active {
+		// 立即回收并把空闲内存归还操作系统
+		// FreeOSMemory本身会强制GC
+		t.runtime.FreeOSMemory()
+		event.LiveBytesAfterGC = t.runtime.ReadStats().LiveBytes
+
+		t.logger.Warn(logMsgEmergency,
+			slog.String(logKeyTrigger, string(event.Trigger)),
+			slog.Uint64(logKeyLiveBytes, event.LiveBytes),
+			slog.Int64(logKeyRSSBytes, event.RSSBytes),
+			slog.Uint64(logKeyLiveAfterGC, event.LiveBytesAfterGC),
+			slog.Int64(logKeyMemoryLimit, event.MemoryLimit),
+		)
+	} else {
+		t.logger.Info(logMsgEmergencyEnd,
+			slog.Uint64(logKeyLiveBytes, event.LiveBytes),
+			slog.Int64(logKeyRSSBytes, event.RSSBytes),
+			slog.Duration(logKeyDuration, event.Duration),
+		)
+	}
+
+	t.mu.Lock()
+	events := append(t.emergency.events, event)
+	if len(events) > maxEmergencyEvents {
+		events = events[len(events)-maxEmergencyEvents:]
+	}
+	t.emergency.events = events
+	t.mu.Unlock()
+
+	if onEmergency != nil {
+		onEmergency(event)
+	}
+}
+
+// EmergencyEvents 获取最近的紧急模式事件，按时间从旧到新排列
+func (t *Tuner) EmergencyEvents() []EmergencyEvent {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return append([]EmergencyEvent(nil), t.emergency.events...)
+}
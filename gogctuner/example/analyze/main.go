@@ -2,6 +2,7 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
@@ -9,6 +10,8 @@ import (
 	"strconv"
 	"strings"
 	"time"
+
+	"github.com/xyzbit/go-tuning-practice/gogctuner"
 )
 
 // 指标数据点
@@ -26,6 +29,7 @@ func main() {
 	logFile := flag.String("log", "", "测试日志文件路径")
 	outputFile := flag.String("output", "report.txt", "输出报告文件路径")
 	chartOutput := flag.String("chart", "chart.html", "图表输出文件路径")
+	historyFile := flag.String("history", "", "调优器调整记录(JSON)文件路径，由stress示例的-history参数生成")
 	flag.Parse()
 
 	if *logFile == "" {
@@ -49,6 +53,16 @@ func main() {
 	// 生成报告
 	report := generateReport(dataPoints)
 
+	// 附加调优器的结构化调整记录分析
+	if *historyFile != "" {
+		adjustments, err := parseHistoryFile(*historyFile)
+		if err != nil {
+			fmt.Printf("解析调整记录失败: %v\n", err)
+			os.Exit(1)
+		}
+		report += generateHistoryReport(adjustments)
+	}
+
 	// 保存报告
 	err = os.WriteFile(*outputFile, []byte(report), 0o644)
 	if err != nil {
@@ -139,6 +153,58 @@ func parseLogFile(filePath string) ([]DataPoint, error) {
 	return dataPoints, nil
 }
 
+// 解析调优器调整记录文件
+func parseHistoryFile(filePath string) ([]gogctuner.Adjustment, error) {
+	data, err := os.ReadFile(filePath)
+	if err != nil {
+		return nil, err
+	}
+
+	var adjustments []gogctuner.Adjustment
+	if err := json.Unmarshal(data, &adjustments); err != nil {
+		return nil, err
+	}
+	return adjustments, nil
+}
+
+// 生成调整记录分析
+func generateHistoryReport(adjustments []gogctuner.Adjustment) string {
+	var report strings.Builder
+
+	report.WriteString("\n## 调优器调整记录\n\n")
+	if len(adjustments) == 0 {
+		report.WriteString("没有调整记录\n")
+		return report.String()
+	}
+
+	report.WriteString(fmt.Sprintf("调整次数: %d\n", len(adjustments)))
+	report.WriteString(fmt.Sprintf("记录时间范围: %s - %s\n\n",
+		adjustments[0].Time.Format("15:04:05"), adjustments[len(adjustments)-1].Time.Format("15:04:05")))
+
+	// 按原因统计
+	reasons := make(map[string]int)
+	var reasonOrder []string
+	for _, a := range adjustments {
+		key := a.Strategy + "/" + a.Reason
+		if _, ok := reasons[key]; !ok {
+			reasonOrder = append(reasonOrder, key)
+		}
+		reasons[key]++
+	}
+	report.WriteString("调整原因分布:\n")
+	for _, key := range reasonOrder {
+		report.WriteString(fmt.Sprintf("- %s: %d次\n", key, reasons[key]))
+	}
+
+	report.WriteString("\n调整明细:\n")
+	for _, a := range adjustments {
+		report.WriteString(fmt.Sprintf("- [%s] GOGC %d -> %d, 存活堆=%dMB, 内存限制=%dMB, 原因=%s, GC间隔=%v\n",
+			a.Time.Format("15:04:05"), a.OldGOGC, a.NewGOGC, a.LiveBytes>>20, a.MemoryLimit>>20,
+			a.Reason, a.GCInterval.Round(time.Millisecond)))
+	}
+	return report.String()
+}
+
 // 生成时间线图表
 func generateTimelineChart(dataPoints []DataPoint, outputPath string) error {
 	if len(dataPoints) == 0 {
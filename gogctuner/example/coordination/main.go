@@ -0,0 +1,131 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/xyzbit/go-tuning-practice/gogctuner"
+)
+
+var (
+	// 长期存活的对象，模拟每个进程的存活堆
+	liveObjects [][]byte
+	// 短暂对象，持续产生垃圾以触发GC
+	garbage []byte
+)
+
+func main() {
+	// 命令行参数
+	worker := flag.Bool("worker", false, "以工作进程运行(由主进程启动)")
+	dir := flag.String("dir", "", "注册表目录，默认在临时目录下创建")
+	memLimitMB := flag.Int("mem-limit", 512, "所有进程共享的内存限制(MB)，模拟容器限制")
+	livesMB := flag.String("lives", "32,64,128", "每个工作进程的存活堆大小(MB)，逗号分隔，进程数等于列表长度")
+	id := flag.String("id", "", "工作进程标识")
+	liveMB := flag.Int("live", 32, "工作进程的存活堆大小(MB)")
+	duration := flag.Int("duration", 10, "测试持续时间(秒)")
+	flag.Parse()
+
+	if *worker {
+		runWorker(*dir, *id, *liveMB, *memLimitMB, *duration)
+		return
+	}
+
+	registry := *dir
+	if registry == "" {
+		var err error
+		registry, err = os.MkdirTemp("", "gogctuner-registry-")
+		if err != nil {
+			log.Fatalf("创建注册表目录失败: %v", err)
+		}
+		defer os.RemoveAll(registry)
+	}
+	log.Printf("测试配置: 共享内存限制=%dMB, 存活堆=%sMB, 注册表=%s, 持续=%d秒",
+		*memLimitMB, *livesMB, registry, *duration)
+
+	// 启动工作进程，每个进程运行一个带协调器的调优器
+	var wg sync.WaitGroup
+	for i, value := range strings.Split(*livesMB, ",") {
+		live, err := strconv.Atoi(strings.TrimSpace(value))
+		if err != nil {
+			log.Fatalf("解析存活堆大小失败: %v", err)
+		}
+		workerID := fmt.Sprintf("worker-%d", i)
+		cmd := exec.Command(os.Args[0],
+			"-worker",
+			"-dir", registry,
+			"-id", workerID,
+			"-live", strconv.Itoa(live),
+			"-mem-limit", strconv.Itoa(*memLimitMB),
+			"-duration", strconv.Itoa(*duration),
+		)
+		stdout, err := cmd.StdoutPipe()
+		if err != nil {
+			log.Fatalf("创建输出管道失败: %v", err)
+		}
+		cmd.Stderr = os.Stderr
+		if err := cmd.Start(); err != nil {
+			log.Fatalf("启动工作进程失败: %v", err)
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			scanner := bufio.NewScanner(stdout)
+			for scanner.Scan() {
+				fmt.Println(scanner.Text())
+			}
+			if err := cmd.Wait(); err != nil {
+				log.Printf("%s 异常退出: %v", workerID, err)
+			}
+		}()
+	}
+	wg.Wait()
+	log.Println("测试完成")
+}
+
+// runWorker 分配指定大小的存活堆，定期输出分得的预算
+func runWorker(dir, id string, liveMB, memLimitMB, durationSec int) {
+	coordinator := gogctuner.NewCoordinator(dir)
+	coordinator.ID = id
+
+	tuner, err := gogctuner.NewTuner(gogctuner.Config{
+		MemoryHardLimit: int64(memLimitMB) << 20,
+		Coordinator:     coordinator,
+		// 示例中各进程不在独立cgroup内，只按各进程上报的数据分配预算
+		HeapOnlyBudget: true,
+	})
+	if err != nil {
+		log.Fatalf("GOGCTuner初始化失败: %v", err)
+	}
+	tuner.Start()
+	defer tuner.Stop()
+
+	for i := 0; i < liveMB; i++ {
+		liveObjects = append(liveObjects, make([]byte, 1<<20))
+	}
+
+	endTime := time.Now().Add(time.Duration(durationSec) * time.Second)
+	lastReport := time.Now()
+	for time.Now().Before(endTime) {
+		for i := 0; i < 256; i++ {
+			garbage = make([]byte, 64<<10)
+		}
+
+		if time.Since(lastReport) >= time.Second {
+			lastReport = time.Now()
+			snapshot := tuner.Snapshot()
+			fmt.Printf("%s: 存活堆=%dMB, 进程数=%d, 预算比例=%.2f, 安全限制=%dMB, GOGC=%d\n",
+				id, snapshot.HeapLiveBytes>>20, snapshot.Peers, snapshot.BudgetShare,
+				snapshot.SafetyLimit>>20, snapshot.GOGC)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"runtime"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/xyzbit/go-tuning-practice/gogctuner"
+)
+
+// 未能从轨迹估算时使用的模型参数
+const (
+	// 标记速度(MB/CPU秒)
+	defaultMarkRateMB = 1024
+	// 每个GC周期的固定CPU开销
+	defaultCycleCost = 100 * time.Microsecond
+)
+
+func main() {
+	// 命令行参数
+	traceFile := flag.String("trace", "", "轨迹文件：GODEBUG=gctrace=1的输出，或stress示例-trace参数记录的runtime/metrics序列")
+	memLimitMB := flag.Int("mem-limit", 0, "内存硬限制(MB)，必需")
+	fixedGOGC := flag.String("gogc", "50,100,200,400", "对比的固定GOGC，逗号分隔，off表示关闭")
+	goMemLimitMB := flag.Int("gomemlimit", 0, "固定GOGC策略同时设置的GOMEMLIMIT(MB)，0表示不设置")
+	modes := flag.String("modes", "gogc,memory_limit,gc_cpu,step,pid", "对比的调优器模式或策略，逗号分隔: gogc|memory_limit|gc_cpu|step|pid")
+	safetyFactor := flag.Float64("safety-factor", 0.7, "调优器安全系数")
+	minGOGC := flag.Int("min-gogc", 25, "调优器最小GOGC")
+	maxGOGC := flag.Int("max-gogc", 500, "调优器最大GOGC")
+	peakOverride := flag.Bool("peak-override", false, "调优器是否允许峰值突破")
+	peakThreshold := flag.Float64("peak-threshold", 1.5, "调优器峰值突破倍数")
+	targetGCCPU := flag.Float64("target-gc-cpu", 5, "gc_cpu模式的目标GC CPU占比(%)")
+	limitModeGOGC := flag.Int("limit-gogc", 0, "memory_limit模式使用的GOGC，0表示动态计算，-1表示关闭")
+	procs := flag.Int("procs", 0, "GOMAXPROCS，0表示从轨迹读取")
+	markRateMB := flag.Float64("mark-rate", 0, "标记速度(MB/CPU秒)，0表示由轨迹中的GC CPU时间估算")
+	cycleCost := flag.Duration("cycle-cost", 0, "每个GC周期的固定CPU开销，0表示由轨迹估算")
+	jsonOutput := flag.Bool("json", false, "以JSON输出结果")
+	flag.Parse()
+
+	if *traceFile == "" || *memLimitMB <= 0 {
+		fmt.Println("使用方法: go run . -trace gctrace.log -mem-limit 512 [-gogc 50,100,200] [-modes gogc,memory_limit]")
+		os.Exit(1)
+	}
+
+	tr, err := parseTraceFile(*traceFile)
+	if err != nil {
+		log.Fatalf("解析轨迹失败: %v", err)
+	}
+
+	memLimit := int64(*memLimitMB) << 20
+	m := model{
+		Procs:       *procs,
+		MarkRate:    *markRateMB * (1 << 20),
+		CycleCost:   *cycleCost,
+		MemoryLimit: memLimit,
+	}
+	if m.Procs <= 0 {
+		m.Procs = tr.Procs
+	}
+	if m.Procs <= 0 {
+		m.Procs = runtime.GOMAXPROCS(0)
+	}
+	// 命令行未指定时使用由轨迹校准的参数，轨迹无法校准时使用默认值
+	calibrated := tr.MarkRate > 0
+	if m.MarkRate <= 0 {
+		m.MarkRate = tr.MarkRate
+	}
+	if m.MarkRate <= 0 {
+		m.MarkRate = defaultMarkRateMB << 20
+	}
+	if m.CycleCost <= 0 {
+		m.CycleCost = tr.CycleCost
+	}
+	if m.CycleCost <= 0 && !calibrated {
+		m.CycleCost = defaultCycleCost
+	}
+
+	base := gogctuner.Config{
+		MemoryHardLimit:    memLimit,
+		SafetyFactor:       *safetyFactor,
+		MinGOGC:            *minGOGC,
+		MaxGOGC:            *maxGOGC,
+		AllowPeakOverride:  *peakOverride,
+		PeakThreshold:      *peakThreshold,
+		TargetGCCPUPercent: *targetGCCPU,
+		LimitModeGOGC:      *limitModeGOGC,
+	}
+	policies, err := buildPolicies(*fixedGOGC, int64(*goMemLimitMB)<<20, *modes, base)
+	if err != nil {
+		log.Fatalf("解析策略失败: %v", err)
+	}
+
+	var results []result
+	if tr.Observed != nil {
+		results = append(results, *tr.Observed)
+	}
+	for _, p := range policies {
+		res, err := simulate(tr, p, m)
+		if err != nil {
+			log.Fatalf("模拟%s失败: %v", p.Name, err)
+		}
+		results = append(results, res)
+	}
+
+	if *jsonOutput {
+		encoder := json.NewEncoder(os.Stdout)
+		encoder.SetIndent("", "  ")
+		if err := encoder.Encode(results); err != nil {
+			log.Fatalf("输出结果失败: %v", err)
+		}
+		return
+	}
+	printResults(tr, m, results)
+}
+
+// buildPolicies 根据命令行参数构造待评估的策略
+func buildPolicies(fixed string, goMemLimit int64, modes string, base gogctuner.Config) ([]policy, error) {
+	var policies []policy
+	for _, value := range splitList(fixed) {
+		gogc := gogctuner.GOGCOff
+		if value != "off" {
+			var err error
+			if gogc, err = strconv.Atoi(value); err != nil || gogc <= 0 {
+				return nil, fmt.Errorf("非法的GOGC: %s", value)
+			}
+		}
+		name := "GOGC=" + value
+		if goMemLimit > 0 {
+			name += fmt.Sprintf(",GOMEMLIMIT=%dMB", goMemLimit>>20)
+		}
+		policies = append(policies, policy{Name: name, GOGC: gogc, MemoryLimit: goMemLimit})
+	}
+
+	for _, mode := range splitList(modes) {
+		config := base
+		switch mode {
+		case "step":
+			config.Strategy = gogctuner.StepStrategy{}
+		case "pid":
+			config.Mode = gogctuner.ModeGCCPU
+			config.Strategy = gogctuner.NewPIDStrategy(0, 0, 0)
+		case string(gogctuner.ModeGOGC), string(gogctuner.ModeMemoryLimit), string(gogctuner.ModeGCCPU):
+			config.Mode = gogctuner.TuningMode(mode)
+		default:
+			// 压舱石模式会在模拟器进程中真实分配内存，不支持
+			return nil, fmt.Errorf("不支持的模式: %s", mode)
+		}
+		policies = append(policies, policy{Name: "tuner:" + mode, GOGC: 100, Tuner: &config})
+	}
+	return policies, nil
+}
+
+func splitList(s string) []string {
+	var values []string
+	for _, value := range strings.Split(s, ",") {
+		if value = strings.TrimSpace(value); value != "" {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// printResults 以表格输出结果
+func printResults(tr trace, m model, results []result) {
+	fmt.Printf("轨迹: %s, %d个采样点, 时长=%s, GOMAXPROCS=%d, 标记速度=%.0fMB/CPU秒, 周期固定开销=%s\n",
+		tr.Format, len(tr.Points), tr.Duration().Round(time.Millisecond), m.Procs, m.MarkRate/(1<<20), m.CycleCost)
+	fmt.Printf("内存硬限制: %dMB\n\n", m.MemoryLimit>>20)
+
+	w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "策略\tGC次数\tGC CPU\t峰值堆\t峰值/限制\t超限周期\t平均GOGC\t调整次数")
+	for _, r := range results {
+		avgGOGC := "off"
+		if r.AvgGOGC >= 0 {
+			avgGOGC = fmt.Sprintf("%.0f", r.AvgGOGC)
+		}
+		fmt.Fprintf(w, "%s\t%d\t%.2f%%\t%dMB\t%.1f%%\t%d\t%s\t%d\n",
+			r.Policy, r.Cycles, r.GCCPUFraction*100, r.PeakHeapBytes>>20,
+			float64(r.PeakHeapBytes)/float64(m.MemoryLimit)*100, r.OverLimit, avgGOGC, r.Adjustments)
+	}
+	w.Flush()
+
+	if len(results) > 0 && strings.HasPrefix(results[0].Policy, "实测") {
+		fmt.Println("\n实测行来自轨迹本身，可用来校准模型：以记录时的GOGC模拟的结果应与其接近")
+	}
+}
@@ -0,0 +1,283 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"math"
+	"time"
+
+	"github.com/xyzbit/go-tuning-practice/gogctuner"
+)
+
+const (
+	// 运行时的最小堆目标，实际为 4MB * GOGC / 100
+	heapMinimum = 4 << 20
+	// 堆目标至少比存活堆多出的空间，避免软内存限制低于存活堆时模拟陷入死循环
+	minHeadroom = 1 << 20
+	// 超过该时间没有GC时运行时会强制GC
+	forcedGCInterval = 2 * time.Minute
+)
+
+// model 模拟参数
+type model struct {
+	// GOMAXPROCS
+	Procs int
+	// 标记速度(字节/CPU秒)
+	MarkRate float64
+	// 每个GC周期的固定CPU开销（STW、清扫等）
+	CycleCost time.Duration
+	// 内存硬限制，用于统计超限次数
+	MemoryLimit int64
+}
+
+// policy 被评估的GC策略：固定的GOGC/GOMEMLIMIT，或调优器配置
+type policy struct {
+	Name string
+	// 固定的GOGC，Tuner不为空时为初始值
+	GOGC int
+	// 固定的软内存限制，0表示不设置
+	MemoryLimit int64
+	// 调优器配置，为空时表示固定策略
+	Tuner *gogctuner.Config
+}
+
+// result 一个策略的模拟结果
+type result struct {
+	Policy string `json:"policy"`
+	// GC周期数
+	Cycles int `json:"gc_cycles"`
+	// GC占用的CPU比例(0-1)
+	GCCPUFraction float64 `json:"gc_cpu_fraction"`
+	// 堆的峰值
+	PeakHeapBytes uint64 `json:"peak_heap_bytes"`
+	// 触发GC时堆加栈超过内存硬限制的周期数，实际运行时这些周期可能已被OOM Kill
+	OverLimit int `json:"over_limit"`
+	// 按时间加权的平均GOGC，GOGC一直关闭时为-1
+	AvgGOGC float64 `json:"avg_gogc"`
+	// 调优器调整GOGC或软内存限制的次数
+	Adjustments int `json:"adjustments"`
+}
+
+// simRuntime 按分配量推进的运行时模型，实现gogctuner.Runtime
+// 堆从存活堆开始随分配增长，达到堆目标时完成一次GC，存活堆取轨迹在该时刻的值
+type simRuntime struct {
+	model    model
+	start    time.Time
+	elapsed  time.Duration
+	notifier *gogctuner.GCNotifier
+
+	live, heap      uint64
+	stack, globals  uint64
+	gogc            int
+	memoryLimit     int64
+	cycles          uint64
+	lastGC          time.Duration
+	gcCPU, totalCPU float64
+
+	// 统计
+	peak       uint64
+	overLimit  int
+	gogcSum    float64
+	gogcWeight float64
+}
+
+func (r *simRuntime) ReadStats() gogctuner.RuntimeStats {
+	return gogctuner.RuntimeStats{
+		LiveBytes:       r.live,
+		GoalBytes:       r.goal(),
+		HeapObjectBytes: r.heap,
+		StackBytes:      r.stack,
+		GlobalBytes:     r.globals,
+		GCCycles:        r.cycles,
+		TotalBytes:      r.heap + r.stack,
+		GCCPUSeconds:    r.gcCPU,
+		TotalCPUSeconds: r.totalCPU,
+	}
+}
+
+func (r *simRuntime) GCSettings() gogctuner.GCSettings {
+	return gogctuner.GCSettings{GOGC: r.gogc, MemoryLimit: r.memoryLimit}
+}
+
+func (r *simRuntime) SetGCPercent(percent int) int {
+	old := r.gogc
+	r.gogc = percent
+	return old
+}
+
+func (r *simRuntime) SetMemoryLimit(limit int64) int64 {
+	old := r.memoryLimit
+	r.memoryLimit = limit
+	return old
+}
+
+func (r *simRuntime) GC() { r.collect(r.live) }
+
+func (r *simRuntime) FreeOSMemory() { r.collect(r.live) }
+
+func (r *simRuntime) Now() time.Time { return r.start.Add(r.elapsed) }
+
+// AfterFunc 模拟器不触发调优器的定时器：运行时每2分钟的强制GC已在allocate中模拟，
+// 也不能使用真实时间的定时器，否则模拟结果会受运行耗时影响
+func (r *simRuntime) AfterFunc(time.Duration, func()) gogctuner.Timer { return simTimer{} }
+
+// NewTicker 同AfterFunc，模拟时不刷新内存限制和配置文件
+func (r *simRuntime) NewTicker(time.Duration) gogctuner.Ticker { return simTicker{} }
+
+// simTimer 永不触发的定时器
+type simTimer struct{}
+
+func (simTimer) Stop() bool { return true }
+
+func (simTimer) Reset(time.Duration) bool { return true }
+
+// simTicker 永不触发的周期定时器
+type simTicker struct{}
+
+func (simTicker) C() <-chan time.Time { return nil }
+
+func (simTicker) Stop() {}
+
+// goal 当前的堆目标，同时受GOGC和软内存限制约束
+func (r *simRuntime) goal() uint64 {
+	goal := uint64(math.MaxUint64)
+	if r.gogc >= 0 {
+		goal = r.live + (r.live+r.stack+r.globals)*uint64(r.gogc)/100
+		goal = max(goal, heapMinimum*uint64(r.gogc)/100)
+	}
+	// 软内存限制约束堆和栈在内的全部运行时内存
+	if r.memoryLimit > 0 && r.memoryLimit < math.MaxInt64 {
+		if limitGoal := r.memoryLimit - int64(r.stack); limitGoal > 0 && uint64(limitGoal) < goal {
+			goal = uint64(limitGoal)
+		}
+	}
+	return max(goal, r.live+minHeadroom)
+}
+
+// advance 推进时钟并累计CPU时间
+func (r *simRuntime) advance(d time.Duration) {
+	r.elapsed += d
+	r.totalCPU += d.Seconds() * float64(r.model.Procs)
+	if r.gogc >= 0 {
+		r.gogcSum += float64(r.gogc) * d.Seconds()
+		r.gogcWeight += d.Seconds()
+	}
+}
+
+// allocate 分配bytes字节，期间堆达到目标时完成GC
+// liveAt返回经过的时间比例对应的存活堆
+func (r *simRuntime) allocate(bytes uint64, d time.Duration, liveAt func(float64) uint64) {
+	var done time.Duration
+	rate := float64(bytes) / d.Seconds()
+	for bytes > 0 && rate > 0 {
+		goal := r.goal()
+		if r.heap+bytes < goal {
+			break
+		}
+		// 分配到堆目标所需的时间
+		step := time.Duration(float64(goal-r.heap) / rate * float64(time.Second))
+		bytes -= goal - r.heap
+		r.advance(step)
+		done += step
+		r.heap = goal
+		r.collect(liveAt(float64(done) / float64(d)))
+	}
+	r.heap += bytes
+	r.peak = max(r.peak, r.heap)
+	r.advance(d - done)
+
+	// 长时间没有GC时运行时强制GC
+	if r.elapsed-r.lastGC >= forcedGCInterval {
+		r.collect(liveAt(1))
+	}
+}
+
+// collect 完成一次GC并通知调优器
+// 标记开销与存活堆、栈和全局变量成正比
+func (r *simRuntime) collect(live uint64) {
+	r.peak = max(r.peak, r.heap)
+	if r.model.MemoryLimit > 0 && int64(r.heap+r.stack) > r.model.MemoryLimit {
+		r.overLimit++
+	}
+	r.cycles++
+	r.lastGC = r.elapsed
+	r.gcCPU += float64(live+r.stack+r.globals)/r.model.MarkRate + r.model.CycleCost.Seconds()
+	r.live = live
+	r.heap = live
+
+	if r.notifier != nil {
+		r.notifier.Notify(gogctuner.GCEvent{Cycles: r.cycles, Time: r.Now()})
+	}
+}
+
+// simulate 以策略p重放轨迹
+func simulate(tr trace, p policy, m model) (result, error) {
+	first := tr.Points[0]
+	rt := &simRuntime{
+		model:       m,
+		start:       time.Unix(0, 0),
+		live:        first.LiveBytes,
+		heap:        first.LiveBytes,
+		stack:       first.StackBytes,
+		globals:     first.GlobalBytes,
+		gogc:        p.GOGC,
+		memoryLimit: math.MaxInt64,
+	}
+	if p.MemoryLimit > 0 {
+		rt.memoryLimit = p.MemoryLimit
+	}
+
+	var tuner *gogctuner.Tuner
+	if p.Tuner != nil {
+		config := *p.Tuner
+		rt.notifier = gogctuner.NewManualGCNotifier()
+		config.Runtime = rt
+		config.GCNotifier = rt.notifier
+		// 模拟器进程的cgroup、RSS与被模拟的程序无关，只按堆计算
+		config.HeapOnlyBudget = true
+		config.EmergencyRSSRatio = -1
+		config.LimitRefreshInterval = -1
+		config.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+		var err error
+		tuner, err = gogctuner.NewTuner(config)
+		if err != nil {
+			return result{}, err
+		}
+		tuner.Start()
+		defer tuner.Stop()
+	}
+
+	for i := 1; i < len(tr.Points); i++ {
+		prev, point := tr.Points[i-1], tr.Points[i]
+		d := point.Time - prev.Time
+		if d <= 0 {
+			continue
+		}
+		rt.stack, rt.globals = point.StackBytes, point.GlobalBytes
+		rt.allocate(point.AllocBytes, d, func(fraction float64) uint64 {
+			from, to := float64(prev.LiveBytes), float64(point.LiveBytes)
+			return uint64(from + fraction*(to-from))
+		})
+	}
+
+	res := result{
+		Policy:        p.Name,
+		Cycles:        int(rt.cycles),
+		PeakHeapBytes: rt.peak,
+		OverLimit:     rt.overLimit,
+		AvgGOGC:       -1,
+	}
+	if rt.totalCPU > 0 {
+		res.GCCPUFraction = rt.gcCPU / rt.totalCPU
+	}
+	if rt.gogcWeight > 0 {
+		res.AvgGOGC = rt.gogcSum / rt.gogcWeight
+	}
+	if tuner != nil {
+		for _, count := range tuner.Snapshot().Adjustments {
+			res.Adjustments += int(count.Count)
+		}
+	}
+	return res, nil
+}
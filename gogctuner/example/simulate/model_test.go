@@ -0,0 +1,87 @@
+package main
+
+import (
+	"math"
+	"testing"
+	"time"
+
+	"github.com/xyzbit/go-tuning-practice/gogctuner"
+)
+
+// steadyTrace 存活堆固定为live，每秒分配alloc，持续seconds秒
+func steadyTrace(live, alloc uint64, seconds int) trace {
+	tr := trace{Format: "metrics"}
+	for i := 0; i <= seconds; i++ {
+		point := tracePoint{Time: time.Duration(i) * time.Second, LiveBytes: live}
+		if i > 0 {
+			point.AllocBytes = alloc
+		}
+		tr.Points = append(tr.Points, point)
+	}
+	return tr
+}
+
+func TestSimulateFixedGOGC(t *testing.T) {
+	// 存活堆10MB，每秒分配100MB，共10秒；4个P共40 CPU秒
+	tr := steadyTrace(10*mb, 100*mb, 10)
+	m := model{Procs: 4, MarkRate: 1 << 30, CycleCost: time.Millisecond, MemoryLimit: 1 << 30}
+	// 每个周期标记10MB需要10/1024秒，加上1ms固定开销
+	cycleCPU := 10.0/1024 + 0.001
+
+	tests := []struct {
+		gogc   int
+		cycles int
+		peak   uint64
+	}{
+		// 堆目标 = 存活堆 * (1 + GOGC/100)，每个周期分配 存活堆 * GOGC/100
+		{50, 200, 15 * mb},
+		{100, 100, 20 * mb},
+		{200, 50, 30 * mb},
+	}
+	for _, tt := range tests {
+		res, err := simulate(tr, policy{Name: "fixed", GOGC: tt.gogc}, m)
+		if err != nil {
+			t.Fatalf("simulate: %v", err)
+		}
+		if res.Cycles != tt.cycles || res.PeakHeapBytes != tt.peak || math.Abs(res.AvgGOGC-float64(tt.gogc)) > 1e-9 || res.OverLimit != 0 {
+			t.Errorf("GOGC=%d 结果 = %+v, want %d个周期 峰值%dMB", tt.gogc, res, tt.cycles, tt.peak/mb)
+		}
+		if want := float64(tt.cycles) * cycleCPU / 40; math.Abs(res.GCCPUFraction-want) > 1e-9 {
+			t.Errorf("GOGC=%d GC CPU占比 = %v, want %v", tt.gogc, res.GCCPUFraction, want)
+		}
+	}
+}
+
+func TestSimulateForcedGC(t *testing.T) {
+	// 几乎不分配时每2分钟强制GC一次
+	tr := steadyTrace(10*mb, 1024, 600)
+	res, err := simulate(tr, policy{Name: "fixed", GOGC: 100}, model{Procs: 1, MarkRate: 1 << 30})
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if res.Cycles != 5 {
+		t.Fatalf("强制GC次数 = %d, want 5", res.Cycles)
+	}
+}
+
+func TestSimulateTunerDeterministic(t *testing.T) {
+	tr := steadyTrace(100*mb, 500*mb, 60)
+	m := model{Procs: 4, MarkRate: 1 << 30, CycleCost: time.Millisecond, MemoryLimit: 1 << 30}
+	p := policy{Name: "gogc", GOGC: 100, Tuner: &gogctuner.Config{MemoryHardLimit: 1 << 30, SafetyFactor: 0.7}}
+
+	first, err := simulate(tr, p, m)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	second, err := simulate(tr, p, m)
+	if err != nil {
+		t.Fatalf("simulate: %v", err)
+	}
+	if first != second {
+		t.Fatalf("两次模拟结果不同: %+v, %+v", first, second)
+	}
+	// 安全限制约717MB，GOGC = (717-100)/100 ≈ 616，受MaxGOGC=500约束
+	if first.AvgGOGC <= 100 || first.Adjustments == 0 || first.PeakHeapBytes > uint64(m.MemoryLimit) {
+		t.Fatalf("调优器模拟结果 = %+v", first)
+	}
+}
@@ -0,0 +1,277 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// runtime/metrics 指标名称，与stress示例-trace参数输出的字段一致
+const (
+	metricTime        = "time"
+	metricHeapLive    = "/gc/heap/live:bytes"
+	metricHeapAllocs  = "/gc/heap/allocs:bytes"
+	metricHeapObjects = "/memory/classes/heap/objects:bytes"
+	metricScanStack   = "/gc/scan/stack:bytes"
+	metricScanGlobals = "/gc/scan/globals:bytes"
+	metricGCCycles    = "/gc/cycles/total:gc-cycles"
+	metricGCCPU       = "/cpu/classes/gc/total:cpu-seconds"
+	metricTotalCPU    = "/cpu/classes/total:cpu-seconds"
+	metricGOGC        = "/gc/gogc:percent"
+)
+
+// tracePoint 轨迹中的一个采样点
+type tracePoint struct {
+	// 距轨迹开始的时间
+	Time time.Duration
+	// 最近一次GC后的存活堆
+	LiveBytes uint64
+	// 自上一个采样点以来的分配量
+	AllocBytes uint64
+	// 可扫描的栈和全局变量
+	StackBytes  uint64
+	GlobalBytes uint64
+}
+
+// trace 解析后的堆轨迹
+type trace struct {
+	// 轨迹格式: gctrace|metrics
+	Format string
+	Points []tracePoint
+	// 记录时的GOMAXPROCS，无法得到时为0
+	Procs int
+	// 由记录时的GC CPU时间估算的标记速度(字节/CPU秒)，无法估算时为0
+	MarkRate float64
+	// 由gctrace估算的每个GC周期的固定CPU开销（两次STW）；
+	// metrics轨迹无法区分STW和标记，全部计入标记速度，为0
+	CycleCost time.Duration
+	// 记录时的实际结果，无法得到时为nil
+	Observed *result
+}
+
+// Duration 轨迹时长
+func (t trace) Duration() time.Duration {
+	if len(t.Points) == 0 {
+		return 0
+	}
+	return t.Points[len(t.Points)-1].Time - t.Points[0].Time
+}
+
+// parseTraceFile 读取轨迹文件，按首个非空行自动识别格式：
+// 以{开头为runtime/metrics的JSON Lines，否则按GODEBUG=gctrace=1的输出解析（忽略其他行）
+func parseTraceFile(path string) (trace, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return trace{}, err
+	}
+
+	var tr trace
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte("{")) {
+		tr, err = parseMetricsTrace(bytes.NewReader(data))
+	} else {
+		tr, err = parseGCTrace(bytes.NewReader(data))
+	}
+	if err != nil {
+		return trace{}, err
+	}
+	if len(tr.Points) < 2 {
+		return trace{}, fmt.Errorf("%s 中有效的采样点不足2个", path)
+	}
+	return tr, nil
+}
+
+// gctraceRegex 匹配gctrace行，例如:
+// gc 12 @3.456s 2%: 0.018+1.2+0.003 ms clock, 0.14+0.45/1.0/2.1+0.030 ms cpu, 40->42->20 MB, 41 MB goal, 0 MB stacks, 0 MB globals, 8 P
+// Go 1.18之前没有stacks和globals字段
+var gctraceRegex = regexp.MustCompile(
+	`gc (\d+) @([\d.]+)s (\d+)%: [\d.+]+ ms clock, ([\d.+/]+) ms cpu, ` +
+		`(\d+)->(\d+)->(\d+) MB, (\d+) MB goal(?:, (\d+) MB stacks)?(?:, (\d+) MB globals)?, (\d+) P`)
+
+// parseGCTrace 解析gctrace输出，每个GC周期为一个采样点
+// 两次GC之间的分配量 = 本次GC结束时的堆 - 上次GC后的存活堆
+func parseGCTrace(r io.Reader) (trace, error) {
+	tr := trace{Format: "gctrace"}
+	observed := &result{Policy: "实测(gctrace)"}
+
+	var prevLive, prevScannable, scanned uint64
+	var markCPU, stwCPU float64
+	var gogcs []float64
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		m := gctraceRegex.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		at, _ := strconv.ParseFloat(m[2], 64)
+		percent, _ := strconv.Atoi(m[3])
+		start := parseMB(m[5])
+		end := parseMB(m[6])
+		live := parseMB(m[7])
+		goal := parseMB(m[8])
+		stacks := parseMB(m[9])
+		globals := parseMB(m[10])
+		procs, _ := strconv.Atoi(m[11])
+
+		var alloc uint64
+		if end > prevLive {
+			alloc = end - prevLive
+		}
+		// 由堆目标反推记录时的GOGC：目标 = 上次存活堆 + (上次存活堆 + 栈 + 全局变量) * GOGC / 100
+		if prevScannable > 0 && goal > prevLive {
+			gogcs = append(gogcs, float64(goal-prevLive)/float64(prevScannable)*100)
+		}
+		prevLive, prevScannable = live, live+stacks+globals
+
+		tr.Points = append(tr.Points, tracePoint{
+			Time:        time.Duration(at * float64(time.Second)),
+			LiveBytes:   live,
+			AllocBytes:  alloc,
+			StackBytes:  stacks,
+			GlobalBytes: globals,
+		})
+		tr.Procs = procs
+
+		// 标记的数据量近似为存活堆加栈和全局变量
+		scanned += live + stacks + globals
+		stw, mark := gcTraceCPU(m[4])
+		stwCPU += stw
+		markCPU += mark
+
+		observed.Cycles++
+		observed.GCCPUFraction = float64(percent) / 100
+		observed.PeakHeapBytes = max(observed.PeakHeapBytes, start, end)
+	}
+	if err := scanner.Err(); err != nil {
+		return trace{}, err
+	}
+	if markCPU > 0 {
+		tr.MarkRate = float64(scanned) / markCPU
+	}
+	if observed.Cycles > 0 {
+		tr.CycleCost = time.Duration(stwCPU / float64(observed.Cycles) * float64(time.Second))
+		// 堆很小时最小堆目标(4MB)会使反推值偏大，取中位数
+		observed.AvgGOGC = -1
+		if len(gogcs) > 0 {
+			sort.Float64s(gogcs)
+			observed.AvgGOGC = gogcs[len(gogcs)/2]
+		}
+		tr.Observed = observed
+	}
+	return tr, nil
+}
+
+// gcTraceCPU 解析gctrace中一个周期的GC CPU时间(秒)，分为两次STW和并发标记
+// 格式为 清扫终止+辅助标记/后台标记/空闲标记+标记终止，空闲标记使用的是本来空闲的CPU，不计入
+func gcTraceCPU(field string) (stw, mark float64) {
+	parts := strings.Split(field, "+")
+	if len(parts) != 3 {
+		return 0, 0
+	}
+	sweepTerm, _ := strconv.ParseFloat(parts[0], 64)
+	markTerm, _ := strconv.ParseFloat(parts[2], 64)
+	marks := strings.Split(parts[1], "/")
+	for i, value := range marks {
+		if i == 2 {
+			break
+		}
+		ms, _ := strconv.ParseFloat(value, 64)
+		mark += ms
+	}
+	return (sweepTerm + markTerm) / 1000, mark / 1000
+}
+
+func parseMB(s string) uint64 {
+	value, _ := strconv.ParseUint(s, 10, 64)
+	return value << 20
+}
+
+// parseMetricsTrace 解析runtime/metrics的JSON Lines轨迹，每行为一次采样：
+// {"time":"2025-04-18T15:55:26.1Z","/gc/heap/live:bytes":1048576,"/gc/heap/allocs:bytes":4194304,...}
+// /gc/heap/allocs:bytes为累计值，相邻两行之差即为期间的分配量
+func parseMetricsTrace(r io.Reader) (trace, error) {
+	tr := trace{Format: "metrics"}
+	observed := &result{Policy: "实测(metrics)"}
+
+	var first, prev map[string]any
+	var start time.Time
+	var gogcSum float64
+	var scanned uint64
+	decoder := json.NewDecoder(r)
+	decoder.UseNumber()
+	for line := 1; ; line++ {
+		var sample map[string]any
+		if err := decoder.Decode(&sample); err == io.EOF {
+			break
+		} else if err != nil {
+			return trace{}, fmt.Errorf("第%d条采样: %w", line, err)
+		}
+		value, _ := sample[metricTime].(string)
+		at, err := time.Parse(time.RFC3339Nano, value)
+		if err != nil {
+			return trace{}, fmt.Errorf("第%d条采样的time字段: %w", line, err)
+		}
+		if first == nil {
+			first, start = sample, at
+		}
+
+		point := tracePoint{
+			Time:        at.Sub(start),
+			LiveBytes:   metricUint(sample, metricHeapLive),
+			StackBytes:  metricUint(sample, metricScanStack),
+			GlobalBytes: metricUint(sample, metricScanGlobals),
+		}
+		if prev != nil {
+			if allocs, last := metricUint(sample, metricHeapAllocs), metricUint(prev, metricHeapAllocs); allocs > last {
+				point.AllocBytes = allocs - last
+			}
+			// 期间每个GC周期标记的数据量近似为存活堆加栈和全局变量
+			if cycles, last := metricUint(sample, metricGCCycles), metricUint(prev, metricGCCycles); cycles > last {
+				scanned += (cycles - last) * (point.LiveBytes + point.StackBytes + point.GlobalBytes)
+			}
+		}
+		tr.Points = append(tr.Points, point)
+		// 运行时以uint64导出int32的GOGC，关闭时为-1
+		gogcSum += float64(int32(metricUint(sample, metricGOGC)))
+		observed.PeakHeapBytes = max(observed.PeakHeapBytes, metricUint(sample, metricHeapObjects))
+		prev = sample
+	}
+	if first == nil {
+		return tr, nil
+	}
+
+	observed.Cycles = int(metricUint(prev, metricGCCycles) - metricUint(first, metricGCCycles))
+	observed.AvgGOGC = gogcSum / float64(len(tr.Points))
+	totalCPU := metricFloat(prev, metricTotalCPU) - metricFloat(first, metricTotalCPU)
+	if gcCPU := metricFloat(prev, metricGCCPU) - metricFloat(first, metricGCCPU); gcCPU > 0 {
+		tr.MarkRate = float64(scanned) / gcCPU
+	}
+	if totalCPU > 0 {
+		observed.GCCPUFraction = (metricFloat(prev, metricGCCPU) - metricFloat(first, metricGCCPU)) / totalCPU
+		if seconds := tr.Duration().Seconds(); seconds > 0 {
+			tr.Procs = int(totalCPU/seconds + 0.5)
+		}
+		tr.Observed = observed
+	}
+	return tr, nil
+}
+
+func metricUint(sample map[string]any, name string) uint64 {
+	number, _ := sample[name].(json.Number)
+	value, _ := strconv.ParseUint(number.String(), 10, 64)
+	return value
+}
+
+func metricFloat(sample map[string]any, name string) float64 {
+	number, _ := sample[name].(json.Number)
+	value, _ := number.Float64()
+	return value
+}
@@ -0,0 +1,153 @@
+package main
+
+import (
+	"math"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+const mb = 1 << 20
+
+// 真实的GODEBUG=gctrace=1输出，夹杂程序的其他输出；第三行为Go 1.18之前没有stacks和globals的格式
+const gctraceLog = `starting server
+gc 1 @0.010s 1%: 0.012+0.45+0.003 ms clock, 0.096+0.10/0.30/0.50+0.024 ms cpu, 4->4->1 MB, 4 MB goal, 0 MB stacks, 0 MB globals, 8 P
+gc 2 @1.500s 2%: 0.020+1.2+0.004 ms clock, 0.16+0.40/1.0/2.0+0.032 ms cpu, 8->9->3 MB, 9 MB goal, 1 MB stacks, 1 MB globals, 8 P (forced)
+gc 3 @x.xs 2%: truncated line
+gc 3 @2.000s 3%: 0.02+1+0.004 ms clock, 0.1+0.5/1/2+0.03 ms cpu, 10->11->5 MB, 12 MB goal, 4 P
+`
+
+func TestParseGCTrace(t *testing.T) {
+	tr, err := parseGCTrace(strings.NewReader(gctraceLog))
+	if err != nil {
+		t.Fatalf("parseGCTrace: %v", err)
+	}
+
+	// 分配量 = 本次GC结束时的堆 - 上次GC后的存活堆
+	want := []tracePoint{
+		{Time: 10 * time.Millisecond, LiveBytes: 1 * mb, AllocBytes: 4 * mb},
+		{Time: 1500 * time.Millisecond, LiveBytes: 3 * mb, AllocBytes: 8 * mb, StackBytes: 1 * mb, GlobalBytes: 1 * mb},
+		{Time: 2 * time.Second, LiveBytes: 5 * mb, AllocBytes: 8 * mb},
+	}
+	if len(tr.Points) != len(want) {
+		t.Fatalf("采样点 = %+v, want %d个", tr.Points, len(want))
+	}
+	for i := range want {
+		if tr.Points[i] != want[i] {
+			t.Errorf("第%d个采样点 = %+v, want %+v", i+1, tr.Points[i], want[i])
+		}
+	}
+	if tr.Format != "gctrace" || tr.Procs != 4 {
+		t.Errorf("Format=%s Procs=%d, want gctrace 4", tr.Format, tr.Procs)
+	}
+
+	// STW CPU: 0.12+0.192+0.13 ms，标记CPU(不含空闲标记): 0.4+1.4+1.5 ms，标记数据量 1+5+5 MB
+	if want := 0.442e-3 / 3; math.Abs(tr.CycleCost.Seconds()-want) > 1e-9 {
+		t.Errorf("CycleCost = %v, want %vs", tr.CycleCost, want)
+	}
+	if want := 11 * mb / 0.0033; math.Abs(tr.MarkRate-want)/want > 1e-9 {
+		t.Errorf("MarkRate = %.0f, want %.0f", tr.MarkRate, want)
+	}
+
+	// 反推的GOGC: (9-1)/1*100=800、(12-3)/(3+1+1)*100=180，取中位数
+	observed := tr.Observed
+	if observed == nil || observed.Cycles != 3 || observed.GCCPUFraction != 0.03 ||
+		observed.PeakHeapBytes != 11*mb || observed.AvgGOGC != 800 {
+		t.Fatalf("实测结果 = %+v", observed)
+	}
+}
+
+func TestGCTraceCPU(t *testing.T) {
+	tests := []struct {
+		field     string
+		stw, mark float64
+	}{
+		{"0.096+0.10/0.30/0.50+0.024", 0.00012, 0.0004},
+		{"1+2/3+4", 0.005, 0.005},
+		{"1+2", 0, 0},
+		{"", 0, 0},
+	}
+	for _, tt := range tests {
+		stw, mark := gcTraceCPU(tt.field)
+		if math.Abs(stw-tt.stw) > 1e-12 || math.Abs(mark-tt.mark) > 1e-12 {
+			t.Errorf("gcTraceCPU(%q) = %v, %v, want %v, %v", tt.field, stw, mark, tt.stw, tt.mark)
+		}
+	}
+}
+
+func TestParseMetricsTrace(t *testing.T) {
+	// GOGC关闭时运行时导出的是-1对应的无符号数
+	const metricsLog = `{"time":"2025-01-01T00:00:00Z","/gc/heap/live:bytes":1048576,"/gc/heap/allocs:bytes":0,"/memory/classes/heap/objects:bytes":2097152,"/gc/cycles/total:gc-cycles":0,"/cpu/classes/gc/total:cpu-seconds":0,"/cpu/classes/total:cpu-seconds":0,"/gc/gogc:percent":100}
+{"time":"2025-01-01T00:00:01Z","/gc/heap/live:bytes":2097152,"/gc/heap/allocs:bytes":10485760,"/memory/classes/heap/objects:bytes":4194304,"/gc/scan/stack:bytes":1048576,"/gc/cycles/total:gc-cycles":2,"/cpu/classes/gc/total:cpu-seconds":0.1,"/cpu/classes/total:cpu-seconds":4,"/gc/gogc:percent":100}
+{"time":"2025-01-01T00:00:02Z","/gc/heap/live:bytes":2097152,"/gc/heap/allocs:bytes":31457280,"/memory/classes/heap/objects:bytes":3145728,"/gc/scan/stack:bytes":1048576,"/gc/cycles/total:gc-cycles":5,"/cpu/classes/gc/total:cpu-seconds":0.2,"/cpu/classes/total:cpu-seconds":8,"/gc/gogc:percent":18446744073709551615}
+`
+	tr, err := parseMetricsTrace(strings.NewReader(metricsLog))
+	if err != nil {
+		t.Fatalf("parseMetricsTrace: %v", err)
+	}
+
+	// 分配量为累计分配的差值
+	want := []tracePoint{
+		{LiveBytes: 1 * mb},
+		{Time: time.Second, LiveBytes: 2 * mb, AllocBytes: 10 * mb, StackBytes: 1 * mb},
+		{Time: 2 * time.Second, LiveBytes: 2 * mb, AllocBytes: 20 * mb, StackBytes: 1 * mb},
+	}
+	if len(tr.Points) != len(want) {
+		t.Fatalf("采样点 = %+v, want %d个", tr.Points, len(want))
+	}
+	for i := range want {
+		if tr.Points[i] != want[i] {
+			t.Errorf("第%d个采样点 = %+v, want %+v", i+1, tr.Points[i], want[i])
+		}
+	}
+
+	// 标记数据量 2*(2+1) + 3*(2+1) MB，GC CPU 0.2秒；8 CPU秒/2秒 = 4个P
+	if want := 15 * mb / 0.2; math.Abs(tr.MarkRate-want)/want > 1e-9 {
+		t.Errorf("MarkRate = %.0f, want %.0f", tr.MarkRate, want)
+	}
+	if tr.Format != "metrics" || tr.Procs != 4 || tr.CycleCost != 0 {
+		t.Errorf("Format=%s Procs=%d CycleCost=%v, want metrics 4 0", tr.Format, tr.Procs, tr.CycleCost)
+	}
+	observed := tr.Observed
+	if observed == nil || observed.Cycles != 5 || math.Abs(observed.GCCPUFraction-0.025) > 1e-12 ||
+		observed.PeakHeapBytes != 4*mb || math.Abs(observed.AvgGOGC-199.0/3) > 1e-9 {
+		t.Fatalf("实测结果 = %+v", observed)
+	}
+}
+
+func TestParseTraceFileErrors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		err     string
+	}{
+		{"没有gctrace行", "hello\ngc 1 @x: broken\n", "有效的采样点不足2个"},
+		{"只有一个周期", strings.SplitAfter(gctraceLog, "\n")[1], "有效的采样点不足2个"},
+		{"非法的JSON", "{\"time\":\"2025-01-01T00:00:00Z\"}\n{\"time\":", "第2条采样"},
+		{"缺少time", "{\"/gc/heap/live:bytes\":1}\n", "第1条采样的time字段"},
+		{"非法的time", "{\"time\":\"yesterday\"}\n", "第1条采样的time字段"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "trace.log")
+			if err := os.WriteFile(path, []byte(tt.content), 0o644); err != nil {
+				t.Fatal(err)
+			}
+			_, err := parseTraceFile(path)
+			if err == nil || !strings.Contains(err.Error(), tt.err) {
+				t.Fatalf("parseTraceFile 错误 = %v, want 包含 %q", err, tt.err)
+			}
+		})
+	}
+
+	// 按首个非空行识别格式
+	path := filepath.Join(t.TempDir(), "gctrace.log")
+	if err := os.WriteFile(path, []byte(gctraceLog), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if tr, err := parseTraceFile(path); err != nil || tr.Format != "gctrace" {
+		t.Fatalf("parseTraceFile = %s, %v", tr.Format, err)
+	}
+}
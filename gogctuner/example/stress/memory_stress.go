@@ -1,12 +1,15 @@
 package main
 
 import (
+	"encoding/json"
 	"flag"
 	"log"
+	"log/slog"
 	"math"
 	"math/rand"
 	"os"
 	"runtime"
+	"runtime/metrics"
 	"strconv"
 	"sync"
 	"time"
@@ -37,6 +40,11 @@ func main() {
 	duration := flag.Int("duration", 60, "测试持续时间(秒)")
 	holdTime := flag.Int("hold", 5, "对象保留时间(秒)")
 	debugMode := flag.Bool("debug", true, "是否启用调试模式")
+	logFormat := flag.String("log-format", "text", "调优器日志格式: text|json")
+	tuningMode := flag.String("mode", "gogc", "调优模式: gogc|memory_limit|gc_cpu|ballast")
+	historyFile := flag.String("history", "", "测试结束后将调优器的调整记录以JSON写入该文件")
+	strategyName := flag.String("strategy", "", "调优策略: pid|step，为空时使用模式对应的内置策略")
+	traceFile := flag.String("trace", "", "每100ms将runtime/metrics采样以JSON Lines追加到该文件，供simulate示例重放")
 	flag.Parse()
 
 	// 设置内存对象保留时间
@@ -60,7 +68,17 @@ func main() {
 			MaxGOGC:           500,
 			AllowPeakOverride: true,
 			PeakThreshold:     1.5,
-			DebugMode:         *debugMode,
+			Logger:            newTunerLogger(*debugMode, *logFormat),
+			Mode:              gogctuner.TuningMode(*tuningMode),
+		}
+		switch *strategyName {
+		case "":
+		case "pid":
+			tunerConfig.Strategy = gogctuner.NewPIDStrategy(0, 0, 0)
+		case "step":
+			tunerConfig.Strategy = gogctuner.StepStrategy{}
+		default:
+			log.Fatalf("未知调优策略: %s", *strategyName)
 		}
 
 		tuner, err := gogctuner.NewTuner(tunerConfig)
@@ -69,6 +87,9 @@ func main() {
 		}
 		tuner.Start()
 		defer tuner.Stop()
+		if *historyFile != "" {
+			defer writeHistory(tuner, *historyFile)
+		}
 
 		// 启动指标报告协程
 		go reportMetrics(tuner)
@@ -76,6 +97,11 @@ func main() {
 		log.Println("使用默认GOGC=100")
 	}
 
+	// 记录堆轨迹
+	if *traceFile != "" {
+		go recordTrace(*traceFile, 100*time.Millisecond)
+	}
+
 	// 启动清理协程
 	go cleanupOldObjects()
 
@@ -253,9 +279,84 @@ func reportMetrics(tuner *gogctuner.Tuner) {
 			lastPauseNs = gcPauseTotal
 		}
 
-		metrics := tuner.GetMetrics()
-		log.Printf("指标报告 - GOGC: %d, 堆内存: %dMB, 对象数: %d, GC次数: %d, 内存使用率: %.2f%%, GC耗时: %.2fms",
-			metrics["current_gogc"], memStats.HeapAlloc>>20, memStats.HeapObjects,
-			memStats.NumGC, metrics["memory_usage_ratio"].(float64)*100, gcCPUTime)
+		snapshot := tuner.Snapshot()
+		log.Printf("指标报告 - GOGC: %d, 堆内存: %dMB, 对象数: %d, GC次数: %d, 内存使用率: %.2f%%, GC耗时: %.2fms, 压舱石: %dMB, RSS: %dMB",
+			snapshot.GOGC, memStats.HeapAlloc>>20, memStats.HeapObjects,
+			memStats.NumGC, snapshot.MemoryUsageRatio*100, gcCPUTime,
+			snapshot.BallastBytes>>20, snapshot.WorkingSetBytes>>20)
+	}
+}
+
+// 创建调优器日志器，调试模式输出Debug级别
+func newTunerLogger(debugMode bool, format string) *slog.Logger {
+	level := slog.LevelInfo
+	if debugMode {
+		level = slog.LevelDebug
+	}
+	opts := &slog.HandlerOptions{Level: level}
+	if format == "json" {
+		return slog.New(slog.NewJSONHandler(os.Stderr, opts))
+	}
+	return slog.New(slog.NewTextHandler(os.Stderr, opts))
+}
+
+// 将调优器的调整记录写入文件，供analyze工具分析
+func writeHistory(tuner *gogctuner.Tuner, path string) {
+	data, err := json.MarshalIndent(tuner.Adjustments(), "", "  ")
+	if err != nil {
+		log.Printf("序列化调整记录失败: %v", err)
+		return
+	}
+	if err := os.WriteFile(path, data, 0o644); err != nil {
+		log.Printf("写入调整记录失败: %v", err)
+		return
+	}
+	log.Printf("调整记录已写入 %s", path)
+}
+
+// 记录到轨迹中的runtime/metrics指标
+var traceMetrics = []string{
+	"/gc/heap/live:bytes",
+	"/gc/heap/allocs:bytes",
+	"/memory/classes/heap/objects:bytes",
+	"/gc/scan/stack:bytes",
+	"/gc/scan/globals:bytes",
+	"/gc/cycles/total:gc-cycles",
+	"/cpu/classes/gc/total:cpu-seconds",
+	"/cpu/classes/total:cpu-seconds",
+	"/gc/gogc:percent",
+}
+
+// 定期采样runtime/metrics，每次采样写入一行JSON
+func recordTrace(path string, interval time.Duration) {
+	file, err := os.Create(path)
+	if err != nil {
+		log.Printf("创建轨迹文件失败: %v", err)
+		return
+	}
+	defer file.Close()
+
+	samples := make([]metrics.Sample, len(traceMetrics))
+	for i, name := range traceMetrics {
+		samples[i].Name = name
+	}
+	encoder := json.NewEncoder(file)
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		metrics.Read(samples)
+		record := map[string]any{"time": now.Format(time.RFC3339Nano)}
+		for _, sample := range samples {
+			switch sample.Value.Kind() {
+			case metrics.KindUint64:
+				record[sample.Name] = sample.Value.Uint64()
+			case metrics.KindFloat64:
+				record[sample.Name] = sample.Value.Float64()
+			}
+		}
+		if err := encoder.Encode(record); err != nil {
+			log.Printf("写入轨迹失败: %v", err)
+			return
+		}
 	}
 }
@@ -0,0 +1,331 @@
+package gogctuner
+
+import (
+	"io"
+	"log/slog"
+	"math"
+	"sync"
+	"testing"
+	"time"
+)
+
+const mb = 1 << 20
+
+// fakeRuntime 可控的运行时：堆状态和时钟由测试设置，GOGC和软内存限制只记录不生效
+// 定时器只在advance推进时钟时触发，不使用真实时间
+type fakeRuntime struct {
+	mu           sync.Mutex
+	stats        RuntimeStats
+	settings     GCSettings
+	now          time.Time
+	gcCount      int
+	freeOSMemory int
+	// 每次强制GC后的存活堆，为0时不变
+	liveAfterGC uint64
+	// 未触发的定时器和未停止的周期定时器
+	timers []*fakeTimer
+	// 每次ReadStats前调用，为空时不调用
+	onReadStats func()
+}
+
+func newFakeRuntime() *fakeRuntime {
+	return &fakeRuntime{
+		settings: GCSettings{GOGC: 100, MemoryLimit: math.MaxInt64},
+		now:      time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC),
+	}
+}
+
+func (r *fakeRuntime) ReadStats() RuntimeStats {
+	if r.onReadStats != nil {
+		r.onReadStats()
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.stats
+}
+
+func (r *fakeRuntime) GCSettings() GCSettings {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.settings
+}
+
+func (r *fakeRuntime) SetGCPercent(percent int) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.settings.GOGC
+	r.settings.GOGC = percent
+	return old
+}
+
+func (r *fakeRuntime) SetMemoryLimit(limit int64) int64 {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	old := r.settings.MemoryLimit
+	r.settings.MemoryLimit = limit
+	return old
+}
+
+func (r *fakeRuntime) GC() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.gcCount++
+	r.collect()
+}
+
+func (r *fakeRuntime) FreeOSMemory() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.freeOSMemory++
+	r.collect()
+}
+
+// collect 模拟一次GC，调用方需持有r.mu
+func (r *fakeRuntime) collect() {
+	r.stats.GCCycles++
+	if r.liveAfterGC > 0 {
+		r.stats.LiveBytes = r.liveAfterGC
+	}
+}
+
+func (r *fakeRuntime) Now() time.Time {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.now
+}
+
+func (r *fakeRuntime) AfterFunc(d time.Duration, f func()) Timer {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	timer := &fakeTimer{rt: r, f: f}
+	timer.schedule(d)
+	return timer
+}
+
+func (r *fakeRuntime) NewTicker(d time.Duration) Ticker {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	timer := &fakeTimer{rt: r, period: d, c: make(chan time.Time, 1)}
+	timer.schedule(d)
+	return fakeTicker{timer}
+}
+
+// advance 推进时钟，按到期时间依次触发定时器
+// AfterFunc的回调在当前协程中同步执行；周期定时器与time.Ticker一样，接收方来不及读取时丢弃
+func (r *fakeRuntime) advance(d time.Duration) {
+	r.mu.Lock()
+	end := r.now.Add(d)
+	for {
+		var next *fakeTimer
+		for _, timer := range r.timers {
+			if !timer.when.After(end) && (next == nil || timer.when.Before(next.when)) {
+				next = timer
+			}
+		}
+		if next == nil {
+			break
+		}
+		// step可能已把时钟推进到到期时间之后，时钟不回退
+		if next.when.After(r.now) {
+			r.now = next.when
+		}
+		if next.period > 0 {
+			next.when = next.when.Add(next.period)
+			select {
+			case next.c <- r.now:
+			default:
+			}
+			continue
+		}
+		next.remove()
+		r.mu.Unlock()
+		next.f()
+		r.mu.Lock()
+	}
+	r.now = end
+	r.mu.Unlock()
+}
+
+// tickers 未停止的周期定时器个数
+func (r *fakeRuntime) tickers() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var n int
+	for _, timer := range r.timers {
+		if timer.period > 0 {
+			n++
+		}
+	}
+	return n
+}
+
+// fakeTimer fakeRuntime的定时器，period>0时为周期定时器
+type fakeTimer struct {
+	rt     *fakeRuntime
+	when   time.Time
+	period time.Duration
+	f      func()
+	c      chan time.Time
+}
+
+// schedule 在d之后触发，返回此前是否未触发，调用方需持有rt.mu
+func (t *fakeTimer) schedule(d time.Duration) bool {
+	active := t.remove()
+	t.when = t.rt.now.Add(d)
+	t.rt.timers = append(t.rt.timers, t)
+	return active
+}
+
+// remove 从待触发列表中移除，返回此前是否未触发，调用方需持有rt.mu
+func (t *fakeTimer) remove() bool {
+	for i, timer := range t.rt.timers {
+		if timer == t {
+			t.rt.timers = append(t.rt.timers[:i], t.rt.timers[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+func (t *fakeTimer) Reset(d time.Duration) bool {
+	t.rt.mu.Lock()
+	defer t.rt.mu.Unlock()
+	return t.schedule(d)
+}
+
+func (t *fakeTimer) Stop() bool {
+	t.rt.mu.Lock()
+	defer t.rt.mu.Unlock()
+	return t.remove()
+}
+
+// fakeTicker 以周期定时器实现Ticker
+type fakeTicker struct {
+	timer *fakeTimer
+}
+
+func (t fakeTicker) C() <-chan time.Time { return t.timer.c }
+
+func (t fakeTicker) Stop() { t.timer.Stop() }
+
+// traceStep 合成堆轨迹中的一个GC周期
+type traceStep struct {
+	// GC后的存活堆
+	Live uint64
+	// 可扫描的栈和全局变量
+	Stack   uint64
+	Globals uint64
+	// 本周期GC占用的CPU比例
+	GCCPU float64
+	// 距上一周期的时间，为0时为1秒
+	Interval time.Duration
+}
+
+// liveTrace 只有存活堆变化的轨迹，单位MB
+func liveTrace(livesMB ...uint64) []traceStep {
+	trace := make([]traceStep, len(livesMB))
+	for i, live := range livesMB {
+		trace[i] = traceStep{Live: live * mb}
+	}
+	return trace
+}
+
+// simulation 用假运行时驱动调优器，逐个GC周期重放堆轨迹
+type simulation struct {
+	t        *testing.T
+	rt       *fakeRuntime
+	notifier *GCNotifier
+	tuner    *Tuner
+}
+
+// newSimulation 创建并启动调优器
+// 默认只按堆计算预算、不检查RSS、不刷新内存限制，GC通知器不感知真实GC，
+// 调整只在step中发生，结果完全确定
+func newSimulation(t *testing.T, config Config) *simulation {
+	t.Helper()
+
+	rt := newFakeRuntime()
+	notifier := NewManualGCNotifier()
+
+	config.Runtime = rt
+	config.GCNotifier = notifier
+	config.HeapOnlyBudget = true
+	config.LimitRefreshInterval = -1
+	if config.EmergencyRSSRatio == 0 {
+		config.EmergencyRSSRatio = -1
+	}
+	if config.Logger == nil {
+		config.Logger = slog.New(slog.NewTextHandler(io.Discard, nil))
+	}
+
+	tuner, err := NewTuner(config)
+	if err != nil {
+		t.Fatalf("NewTuner: %v", err)
+	}
+	tuner.Start()
+	t.Cleanup(tuner.Stop)
+	return &simulation{t: t, rt: rt, notifier: notifier, tuner: tuner}
+}
+
+// step 推进时钟、更新堆状态并发布一次GC事件，返回调整后的GOGC
+func (s *simulation) step(step traceStep) int {
+	s.t.Helper()
+
+	interval := step.Interval
+	if interval <= 0 {
+		interval = time.Second
+	}
+
+	s.rt.mu.Lock()
+	s.rt.now = s.rt.now.Add(interval)
+	// 模拟4个P，GC CPU按比例累计
+	total := interval.Seconds() * 4
+	s.rt.stats.TotalCPUSeconds += total
+	s.rt.stats.GCCPUSeconds += total * step.GCCPU
+	s.rt.stats.GCCycles++
+	s.rt.stats.LiveBytes = step.Live
+	s.rt.stats.StackBytes = step.Stack
+	s.rt.stats.GlobalBytes = step.Globals
+	event := GCEvent{Cycles: s.rt.stats.GCCycles, Time: s.rt.now}
+	s.rt.mu.Unlock()
+
+	s.notifier.Notify(event)
+
+	gogc := s.tuner.GetCurrentGOGC()
+	if applied := s.rt.GCSettings().GOGC; applied != gogc {
+		s.t.Fatalf("运行时GOGC=%d 与调优器记录的GOGC=%d 不一致", applied, gogc)
+	}
+	return gogc
+}
+
+// replay 重放整条轨迹，返回每个周期后的GOGC
+func (s *simulation) replay(trace []traceStep) []int {
+	s.t.Helper()
+
+	trajectory := make([]int, len(trace))
+	for i, step := range trace {
+		trajectory[i] = s.step(step)
+	}
+	return trajectory
+}
+
+// softLimit 运行时当前的软内存限制，未设置时为0
+func (s *simulation) softLimit() int64 {
+	limit := s.rt.GCSettings().MemoryLimit
+	if limit == math.MaxInt64 {
+		return 0
+	}
+	return limit
+}
+
+func assertTrajectory(t *testing.T, got, want []int) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("GOGC轨迹长度 = %d, want %d", len(got), len(want))
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("GOGC轨迹 = %v, want %v (第%d步不同)", got, want, i)
+		}
+	}
+}
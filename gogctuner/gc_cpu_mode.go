@@ -0,0 +1,56 @@
+package gogctuner
+
+const (
+	// 默认GC CPU目标占比(%)
+	defaultTargetGCCPUPercent = 5.0
+	// 单次调整GOGC的最大倍数，避免GC CPU测量噪声引起震荡
+	maxGCCPUAdjustFactor = 2.0
+)
+
+// GCCPUStrategy GC CPU预算策略
+// GC频率近似与GOGC成反比，因此按 实测占比/目标占比 等比例缩放当前GOGC，
+// 结果不超过内存安全限制对应的GOGC，并受MinGOGC约束
+type GCCPUStrategy struct {
+	// GOGC变化阈值，默认0.1
+	Hysteresis float64
+}
+
+// Name 策略名称
+func (GCCPUStrategy) Name() string { return "gc_cpu" }
+
+// Decide 根据GC CPU占比计算GOGC
+func (g GCCPUStrategy) Decide(s State) Decision {
+	current := s.CurrentGOGC
+	if current < s.Config.MinGOGC {
+		current = s.Config.MinGOGC
+	}
+
+	newGOGC := current
+	reason := "gc_cpu_no_sample"
+	// 采样窗口内没有GC时无法评估，保持当前值
+	if s.GCCPUFraction > 0 {
+		factor := s.GCCPUFraction * 100 / s.Config.TargetGCCPUPercent
+		if factor > maxGCCPUAdjustFactor {
+			factor = maxGCCPUAdjustFactor
+		} else if factor < 1/maxGCCPUAdjustFactor {
+			factor = 1 / maxGCCPUAdjustFactor
+		}
+		newGOGC = int(float64(current) * factor)
+		reason = "gc_cpu_target"
+	}
+
+	safetyGOGC, _ := heapTargetGOGC(s)
+	if newGOGC > safetyGOGC {
+		newGOGC = safetyGOGC
+		reason = "gc_cpu_memory_bound"
+	}
+
+	hysteresis := g.Hysteresis
+	if hysteresis <= 0 {
+		hysteresis = defaultHysteresis
+	}
+	return Decision{
+		GOGC:   applyHysteresis(s.CurrentGOGC, newGOGC, hysteresis),
+		Reason: reason,
+	}
+}
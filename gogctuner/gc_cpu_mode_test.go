@@ -0,0 +1,39 @@
+package gogctuner
+
+import "testing"
+
+func TestGCCPUStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		live       uint64
+		current    int
+		gcCPU      float64
+		want       int
+		wantReason string
+	}{
+		{name: "over target", live: 100, current: 100, gcCPU: 0.1, want: 200, wantReason: "gc_cpu_target"},
+		{name: "max factor", live: 100, current: 100, gcCPU: 0.5, want: 200, wantReason: "gc_cpu_target"},
+		{name: "under target", live: 100, current: 100, gcCPU: 0.01, want: 50, wantReason: "gc_cpu_target"},
+		{name: "no sample", live: 100, current: 100, gcCPU: 0, want: 100, wantReason: "gc_cpu_no_sample"},
+		{name: "hysteresis", live: 100, current: 100, gcCPU: 0.052, want: 100, wantReason: "gc_cpu_target"},
+		{name: "below MinGOGC", live: 100, current: 10, gcCPU: 0.05, want: 25, wantReason: "gc_cpu_target"},
+		// 安全限制1000MB：存活堆100MB时GOGC上限为MaxGOGC(500)，400MB时为150
+		{name: "memory bound", live: 400, current: 100, gcCPU: 0.2, want: 150, wantReason: "gc_cpu_memory_bound"},
+		{name: "over safety limit", live: 1200, current: 100, gcCPU: 0.2, want: 25, wantReason: "gc_cpu_memory_bound"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := GCCPUStrategy{}.Decide(State{
+				LiveBytes:     tt.live * mb,
+				MemoryLimit:   2000 * mb,
+				SafetyLimit:   1000 * mb,
+				CurrentGOGC:   tt.current,
+				GCCPUFraction: tt.gcCPU,
+				Config:        applyDefaults(Config{Mode: ModeGCCPU}),
+			})
+			if got.GOGC != tt.want || got.Reason != tt.wantReason {
+				t.Fatalf("Decide = %d, %s, want %d, %s", got.GOGC, got.Reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package gogctuner
+
+import (
+	"runtime"
+	"runtime/metrics"
+	"sync"
+	"time"
+)
+
+// GCEvent GC周期完成事件
+type GCEvent struct {
+	// 截至本次通知已完成的GC周期总数
+	Cycles uint64
+	// 自上次通知以来未单独通知的GC周期数
+	// finalizer在两次GC之间才能重新挂载，GC过于频繁时多个周期会合并为一次通知
+	Missed uint64
+	// 通知时间
+	Time time.Time
+}
+
+// GCNotifier GC周期通知器
+// 利用finalizer在每次GC后重新挂载自身，并通过/gc/cycles/total:gc-cycles校正漏掉的周期
+type GCNotifier struct {
+	mu         sync.Mutex
+	subs       map[uint64]func(GCEvent)
+	nextID     uint64
+	lastCycles uint64
+	stopped    bool
+
+	events chan struct{}
+	done   chan struct{}
+	sample []metrics.Sample
+}
+
+// gcSentinel 用于感知GC的哨兵对象
+// 含指针字段，避免被分配到tiny allocator的合并块中导致finalizer迟迟不执行
+type gcSentinel struct {
+	n *GCNotifier
+}
+
+// NewGCNotifier 创建并启动GC通知器
+func NewGCNotifier() *GCNotifier {
+	n := &GCNotifier{
+		subs:   make(map[uint64]func(GCEvent)),
+		events: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+		sample: []metrics.Sample{{Name: metricGCCycles}},
+	}
+	n.lastCycles = n.readCycles()
+
+	runtime.SetFinalizer(&gcSentinel{n: n}, onSentinelCollected)
+	go n.dispatch()
+	return n
+}
+
+// NewManualGCNotifier 创建不感知真实GC的通知器，事件只由Notify发布
+// 用于离线模拟和测试：以模拟的GC周期驱动调优器，不受当前进程GC的干扰
+func NewManualGCNotifier() *GCNotifier {
+	return &GCNotifier{
+		subs: make(map[uint64]func(GCEvent)),
+		done: make(chan struct{}),
+	}
+}
+
+// Notify 发布一次GC事件，订阅者在调用方协程中同步执行
+// 只对NewManualGCNotifier创建的通知器有效：NewGCNotifier创建的通知器的事件只来自真实GC，
+// 调用Notify不做任何操作。通知器已停止时同样不做任何操作。
+// event.Missed为0时按与上一次事件的周期差补全
+func (n *GCNotifier) Notify(event GCEvent) {
+	n.mu.Lock()
+	// events非nil表示由真实GC驱动的通知器
+	if n.stopped || n.events != nil {
+		n.mu.Unlock()
+		return
+	}
+	if event.Missed == 0 && n.lastCycles > 0 && event.Cycles > n.lastCycles+1 {
+		event.Missed = event.Cycles - n.lastCycles - 1
+	}
+	if event.Cycles > n.lastCycles {
+		n.lastCycles = event.Cycles
+	}
+	subs := make([]func(GCEvent), 0, len(n.subs))
+	for _, fn := range n.subs {
+		subs = append(subs, fn)
+	}
+	n.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(event)
+	}
+}
+
+// onSentinelCollected 哨兵被回收时触发，通知分发协程后重新挂载
+// finalizer在runtime唯一的finalizer协程中执行，这里不能阻塞
+func onSentinelCollected(s *gcSentinel) {
+	if s.n.isStopped() {
+		return
+	}
+	select {
+	case s.n.events <- struct{}{}:
+	default:
+		// 上一次通知尚未处理，本次合并，由周期计数补偿
+	}
+	runtime.SetFinalizer(s, onSentinelCollected)
+}
+
+// Subscribe 注册GC回调，返回取消订阅函数
+// 回调在通知器的分发协程中串行执行
+func (n *GCNotifier) Subscribe(fn func(GCEvent)) (unsubscribe func()) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	id := n.nextID
+	n.nextID++
+	n.subs[id] = fn
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			n.mu.Lock()
+			delete(n.subs, id)
+			n.mu.Unlock()
+		})
+	}
+}
+
+// Stop 停止通知器，哨兵对象在下一次GC后不再重新挂载
+func (n *GCNotifier) Stop() {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if n.stopped {
+		return
+	}
+	n.stopped = true
+	close(n.done)
+}
+
+func (n *GCNotifier) isStopped() bool {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	return n.stopped
+}
+
+// dispatch 分发GC事件给所有订阅者
+func (n *GCNotifier) dispatch() {
+	for {
+		select {
+		case <-n.events:
+		case <-n.done:
+			return
+		}
+
+		cycles := n.readCycles()
+
+		n.mu.Lock()
+		var missed uint64
+		if cycles > n.lastCycles+1 {
+			missed = cycles - n.lastCycles - 1
+		}
+		n.lastCycles = cycles
+		subs := make([]func(GCEvent), 0, len(n.subs))
+		for _, fn := range n.subs {
+			subs = append(subs, fn)
+		}
+		n.mu.Unlock()
+
+		event := GCEvent{Cycles: cycles, Missed: missed, Time: time.Now()}
+		for _, fn := range subs {
+			fn(event)
+		}
+	}
+}
+
+func (n *GCNotifier) readCycles() uint64 {
+	// 仅在构造函数和分发协程中调用，不存在并发读取
+	metrics.Read(n.sample)
+	if n.sample[0].Value.Kind() != metrics.KindUint64 {
+		return 0
+	}
+	return n.sample[0].Value.Uint64()
+}
@@ -0,0 +1,146 @@
+package gogctuner
+
+import (
+	"runtime"
+	"testing"
+	"time"
+)
+
+// waitGCEvent 等待下一个GC事件
+func waitGCEvent(t *testing.T, events <-chan GCEvent) GCEvent {
+	t.Helper()
+	select {
+	case event := <-events:
+		return event
+	case <-time.After(5 * time.Second):
+		t.Fatal("等待GC事件超时")
+		return GCEvent{}
+	}
+}
+
+func TestGCNotifierRearms(t *testing.T) {
+	n := NewGCNotifier()
+	defer n.Stop()
+
+	events := make(chan GCEvent, 16)
+	n.Subscribe(func(e GCEvent) { events <- e })
+
+	// finalizer只挂载一次时第二次GC后就收不到通知
+	var last uint64
+	for i := 0; i < 5; i++ {
+		runtime.GC()
+		event := waitGCEvent(t, events)
+		if event.Cycles <= last {
+			t.Fatalf("第%d次 Cycles = %d, 上一次 %d", i, event.Cycles, last)
+		}
+		last = event.Cycles
+	}
+}
+
+func TestGCNotifierMissedCycles(t *testing.T) {
+	n := NewGCNotifier()
+	defer n.Stop()
+
+	release := make(chan struct{})
+	events := make(chan GCEvent, 16)
+	first := true
+	n.Subscribe(func(e GCEvent) {
+		events <- e
+		if first {
+			first = false
+			<-release
+		}
+	})
+
+	runtime.GC()
+	blocked := waitGCEvent(t, events)
+	// 订阅者阻塞期间的多次GC合并为一次通知，由周期计数补偿
+	for i := 0; i < 3; i++ {
+		runtime.GC()
+	}
+	close(release)
+
+	event := waitGCEvent(t, events)
+	if event.Missed == 0 || event.Cycles != blocked.Cycles+event.Missed+1 {
+		t.Fatalf("阻塞后的事件 = %+v, 阻塞时 Cycles = %d", event, blocked.Cycles)
+	}
+}
+
+func TestGCNotifierUnsubscribeAndStop(t *testing.T) {
+	n := NewGCNotifier()
+
+	removed := make(chan GCEvent, 16)
+	events := make(chan GCEvent, 16)
+	unsubscribe := n.Subscribe(func(e GCEvent) { removed <- e })
+	n.Subscribe(func(e GCEvent) { events <- e })
+	unsubscribe()
+	unsubscribe()
+
+	// 第二个事件送达时，第一次分发中的所有回调都已执行完
+	for i := 0; i < 2; i++ {
+		runtime.GC()
+		waitGCEvent(t, events)
+	}
+	if len(removed) != 0 {
+		t.Fatalf("取消订阅后仍收到 %d 个事件", len(removed))
+	}
+
+	n.Stop()
+	n.Stop()
+	runtime.GC()
+	runtime.GC()
+	select {
+	case event := <-events:
+		t.Fatalf("停止后仍收到事件 %+v", event)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestManualGCNotifier(t *testing.T) {
+	n := NewManualGCNotifier()
+	var events []GCEvent
+	n.Subscribe(func(e GCEvent) { events = append(events, e) })
+
+	n.Notify(GCEvent{Cycles: 1})
+	n.Notify(GCEvent{Cycles: 2})
+	// 跳过的周期计入Missed，调用方指定的Missed保持不变
+	n.Notify(GCEvent{Cycles: 5})
+	n.Notify(GCEvent{Cycles: 9, Missed: 1})
+	want := []uint64{0, 0, 2, 1}
+	if len(events) != len(want) {
+		t.Fatalf("事件 = %+v", events)
+	}
+	for i, missed := range want {
+		if events[i].Missed != missed {
+			t.Fatalf("第%d个事件 Missed = %d, want %d", i, events[i].Missed, missed)
+		}
+	}
+
+	n.Stop()
+	n.Notify(GCEvent{Cycles: 10})
+	if len(events) != len(want) {
+		t.Fatalf("停止后仍收到事件: %+v", events[len(want):])
+	}
+}
+
+func TestGCNotifierIgnoresNotify(t *testing.T) {
+	n := NewGCNotifier()
+	defer n.Stop()
+
+	events := make(chan GCEvent, 16)
+	n.Subscribe(func(e GCEvent) { events <- e })
+
+	// 真实通知器的事件只来自GC，Notify不会注入伪造的周期
+	n.Notify(GCEvent{Cycles: 1 << 40})
+	select {
+	case event := <-events:
+		if event.Cycles == 1<<40 {
+			t.Fatalf("Notify注入了事件: %+v", event)
+		}
+	default:
+	}
+	runtime.GC()
+	if event := waitGCEvent(t, events); event.Cycles >= 1<<40 {
+		t.Fatalf("GC后 Cycles = %d, 被Notify修改", event.Cycles)
+	}
+}
@@ -0,0 +1,85 @@
+package gogctuner
+
+import (
+	"log/slog"
+	"time"
+)
+
+// LimitChangeEvent 内存限制变化事件
+type LimitChangeEvent struct {
+	// 变化前的内存限制(字节)
+	OldLimit int64
+	// 变化后的内存限制(字节)
+	NewLimit int64
+	// 新限制的来源
+	Source LimitSource
+	// 发现变化的时间
+	Time time.Time
+}
+
+// syncLimitWatcher 运行中且自动探测内存限制时启动限制刷新协程，否则停止，调用方需持有t.mu
+// MemoryHardLimit可能在UpdateConfig中与0之间切换，启动和配置更新时都需要调用
+func (t *Tuner) syncLimitWatcher() {
+	want := t.state == stateRunning && t.config.MemoryHardLimit == 0 && t.config.LimitRefreshInterval > 0
+	switch {
+	case want && t.stopCh == nil:
+		t.stopCh = make(chan struct{})
+		ticker := t.runtime.NewTicker(t.config.LimitRefreshInterval)
+		t.wg.Add(1)
+		go func(stopCh <-chan struct{}) {
+			defer t.wg.Done()
+			t.watchLimit(ticker, stopCh)
+		}(t.stopCh)
+	case !want && t.stopCh != nil:
+		close(t.stopCh)
+		t.stopCh = nil
+	}
+}
+
+// watchLimit 每次ticker触发时重新读取内存限制，直到stopCh关闭
+// 用于跟随Kubernetes VPA原地扩缩容或手动修改cgroup等场景
+func (t *Tuner) watchLimit(ticker Ticker, stopCh <-chan struct{}) {
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C():
+			t.refreshLimit()
+		case <-stopCh:
+			return
+		}
+	}
+}
+
+// refreshLimit 重新探测内存限制，发生变化时更新并立即调整GOGC
+func (t *Tuner) refreshLimit() {
+	config := t.Config()
+	newLimit, source, err := resolveMemoryLimit(config)
+	if err != nil {
+		t.logger.Warn(logMsgLimitRefresh, slog.Any(logKeyError, err))
+		return
+	}
+
+	oldLimit := t.memoryLimit.Swap(newLimit)
+	t.limitSource.Store(source)
+	if oldLimit == newLimit {
+		return
+	}
+
+	event := LimitChangeEvent{
+		OldLimit: oldLimit,
+		NewLimit: newLimit,
+		Source:   source,
+		Time:     t.runtime.Now(),
+	}
+	t.logger.Info(logMsgLimitChange,
+		slog.Int64(logKeyOldLimit, oldLimit),
+		slog.Int64(logKeyMemoryLimit, newLimit),
+		slog.String(logKeySource, string(source)),
+	)
+	if config.OnLimitChange != nil {
+		config.OnLimitChange(event)
+	}
+
+	t.adjustGOGC()
+}
@@ -0,0 +1,71 @@
+package gogctuner
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRefreshLimit(t *testing.T) {
+	detector := fakeRoot(t, map[string]string{
+		"proc/self/cgroup":          "0::/\n",
+		"proc/meminfo":              hostMemInfo,
+		"cgroup/cgroup.controllers": "memory\n",
+		"cgroup/memory.max":         "2097152000\n",
+	})
+
+	var events []LimitChangeEvent
+	sim := newSimulation(t, Config{
+		SafetyFactor:  simSafetyFactor,
+		CgroupRoot:    detector.CgroupRoot,
+		ProcRoot:      detector.ProcRoot,
+		OnLimitChange: func(e LimitChangeEvent) { events = append(events, e) },
+	})
+	if got := sim.step(traceStep{Live: 200 * mb}); got != 400 {
+		t.Fatalf("GOGC = %d, want 400", got)
+	}
+
+	// 限制未变化时不产生事件
+	sim.tuner.refreshLimit()
+	if len(events) != 0 {
+		t.Fatalf("限制未变化时 事件 = %+v", events)
+	}
+
+	// 容器缩容到1000MB：安全限制500MB，立即按新限制调整
+	writeFile(t, filepath.Join(detector.CgroupRoot, "memory.max"), "1048576000\n")
+	sim.tuner.refreshLimit()
+	if len(events) != 1 {
+		t.Fatalf("事件 = %+v, want 1个", events)
+	}
+	want := LimitChangeEvent{OldLimit: simMemoryLimit, NewLimit: 1000 * mb, Source: LimitSourceCgroupV2, Time: sim.rt.Now()}
+	if events[0] != want {
+		t.Fatalf("事件 = %+v, want %+v", events[0], want)
+	}
+	if got := sim.tuner.MemoryLimit(); got != 1000*mb {
+		t.Fatalf("MemoryLimit = %d, want %d", got, 1000*mb)
+	}
+	if got := sim.tuner.GetCurrentGOGC(); got != 150 {
+		t.Fatalf("缩容后 GOGC = %d, want 150", got)
+	}
+
+	// cgroup限制被移除时回退到宿主机内存
+	writeFile(t, filepath.Join(detector.CgroupRoot, "memory.max"), "max\n")
+	sim.tuner.refreshLimit()
+	if len(events) != 2 || events[1].Source != LimitSourceHost || sim.tuner.LimitSource() != LimitSourceHost {
+		t.Fatalf("移除限制后 事件 = %+v, 来源 = %s", events, sim.tuner.LimitSource())
+	}
+
+	// 探测失败时保持原来的限制
+	writeFile(t, filepath.Join(detector.CgroupRoot, "memory.max"), "garbage\n")
+	sim.tuner.refreshLimit()
+	if len(events) != 2 || sim.tuner.MemoryLimit() != 16<<30 {
+		t.Fatalf("探测失败后 事件 = %d个, MemoryLimit = %d", len(events), sim.tuner.MemoryLimit())
+	}
+}
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
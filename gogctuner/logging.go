@@ -0,0 +1,77 @@
+package gogctuner
+
+import (
+	"context"
+	"log/slog"
+	"os"
+)
+
+// 结构化日志的消息名，下游可按消息名过滤
+const (
+	logMsgInit         = "gogctuner.init"
+	logMsgAdjust       = "gogctuner.adjust"
+	logMsgSoftLimit    = "gogctuner.soft_limit"
+	logMsgLimitChange  = "gogctuner.limit_change"
+	logMsgLimitRefresh = "gogctuner.limit_refresh_failed"
+	logMsgStop         = "gogctuner.stop"
+	logMsgDecision     = "gogctuner.decision"
+	logMsgConfigUpdate = "gogctuner.config_update"
+	logMsgConfigReload = "gogctuner.config_reload_failed"
+	logMsgPause        = "gogctuner.pause"
+	logMsgResume       = "gogctuner.resume"
+	logMsgPin          = "gogctuner.pin"
+	logMsgUnpin        = "gogctuner.unpin"
+	logMsgEmergency    = "gogctuner.emergency"
+	logMsgEmergencyEnd = "gogctuner.emergency_exit"
+	logMsgBallast      = "gogctuner.ballast"
+	logMsgCoordination = "gogctuner.coordination_failed"
+)
+
+// 结构化日志的字段名
+const (
+	logKeyError         = "error"
+	logKeyGOGC          = "gogc"
+	logKeyOldGOGC       = "old_gogc"
+	logKeyLiveBytes     = "live_bytes"
+	logKeyMemoryLimit   = "memory_limit_bytes"
+	logKeyOldLimit      = "old_memory_limit_bytes"
+	logKeySoftLimit     = "soft_memory_limit_bytes"
+	logKeyUsageRatio    = "usage_ratio"
+	logKeyGCInterval    = "gc_interval"
+	logKeyGCCPUFraction = "gc_cpu_fraction"
+	logKeyStrategy      = "strategy"
+	logKeyReason        = "reason"
+	logKeyMode          = "mode"
+	logKeySource        = "source"
+	logKeySafetyFactor  = "safety_factor"
+	logKeyMinGOGC       = "min_gogc"
+	logKeyMaxGOGC       = "max_gogc"
+	logKeyPath          = "path"
+	logKeyTTL           = "ttl"
+	logKeyTrigger       = "trigger"
+	logKeyRSSBytes      = "rss_bytes"
+	logKeyLiveAfterGC   = "live_bytes_after_gc"
+	logKeyDuration      = "duration"
+	logKeyBallastBytes  = "ballast_bytes"
+	logKeyOldBallast    = "old_ballast_bytes"
+)
+
+// newLogger 根据配置创建日志器
+// 未设置Logger时：DebugMode为true输出Debug及以上级别到标准错误，否则不输出
+func newLogger(config Config) *slog.Logger {
+	if config.Logger != nil {
+		return config.Logger
+	}
+	if config.DebugMode {
+		return slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	}
+	return slog.New(discardHandler{})
+}
+
+// discardHandler 丢弃所有日志
+type discardHandler struct{}
+
+func (discardHandler) Enabled(context.Context, slog.Level) bool  { return false }
+func (discardHandler) Handle(context.Context, slog.Record) error { return nil }
+func (d discardHandler) WithAttrs([]slog.Attr) slog.Handler      { return d }
+func (d discardHandler) WithGroup(string) slog.Handler           { return d }
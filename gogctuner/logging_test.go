@@ -0,0 +1,74 @@
+package gogctuner
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/json"
+	"log/slog"
+	"testing"
+)
+
+func TestStructuredLogs(t *testing.T) {
+	var buf bytes.Buffer
+	config := simConfig()
+	config.Logger = slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	sim := newSimulation(t, config)
+	sim.replay(liveTrace(200, 205))
+	sim.tuner.Stop()
+
+	var records []map[string]any
+	scanner := bufio.NewScanner(&buf)
+	for scanner.Scan() {
+		var record map[string]any
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			t.Fatalf("日志不是JSON: %s", scanner.Text())
+		}
+		records = append(records, record)
+	}
+
+	want := []struct {
+		msg   string
+		level string
+		attrs map[string]any
+	}{
+		{logMsgInit, "INFO", map[string]any{logKeyMode: "gogc", logKeyStrategy: "heap_target", logKeySource: "config", logKeyGOGC: 100.0}},
+		{logMsgAdjust, "INFO", map[string]any{logKeyGOGC: 400.0, logKeyOldGOGC: 100.0, logKeyLiveBytes: float64(200 * mb), logKeyReason: "heap_target"}},
+		// 滞回范围内不调整，只输出Debug级别的决策
+		{logMsgDecision, "DEBUG", map[string]any{logKeyGOGC: 400.0, logKeyLiveBytes: float64(205 * mb)}},
+		{logMsgStop, "INFO", map[string]any{logKeyGOGC: 100.0}},
+	}
+	// 启动时的首次调整没有存活堆数据，输出一条决策日志
+	if len(records) != len(want)+1 {
+		t.Fatalf("日志 = %d条, want %d: %v", len(records), len(want)+1, records)
+	}
+	if first := records[1]; first["msg"] != logMsgDecision || first[logKeyReason] != "no_live_heap" {
+		t.Fatalf("首次调整的日志 = %v", first)
+	}
+	records = append(records[:1], records[2:]...)
+	for i, w := range want {
+		record := records[i]
+		if record["msg"] != w.msg || record["level"] != w.level {
+			t.Fatalf("第%d条 = %s %s, want %s %s", i, record["level"], record["msg"], w.level, w.msg)
+		}
+		for key, value := range w.attrs {
+			if record[key] != value {
+				t.Fatalf("%s 的 %s = %v, want %v", w.msg, key, record[key], value)
+			}
+		}
+	}
+}
+
+func TestNewLogger(t *testing.T) {
+	ctx := context.Background()
+	if newLogger(Config{}).Enabled(ctx, slog.LevelError) {
+		t.Fatal("未设置Logger和DebugMode时不应输出日志")
+	}
+	if !newLogger(Config{DebugMode: true}).Enabled(ctx, slog.LevelDebug) {
+		t.Fatal("DebugMode时应输出Debug日志")
+	}
+	logger := slog.New(slog.NewTextHandler(&bytes.Buffer{}, nil))
+	if newLogger(Config{Logger: logger, DebugMode: true}) != logger {
+		t.Fatal("设置Logger时应使用该Logger")
+	}
+}
@@ -0,0 +1,77 @@
+package gogctuner
+
+// TuningMode 调优模式
+type TuningMode string
+
+const (
+	// ModeGOGC 仅通过debug.SetGCPercent动态调整GOGC（默认）
+	ModeGOGC TuningMode = "gogc"
+	// ModeMemoryLimit 混合模式：以debug.SetMemoryLimit设置软内存限制，并配合GOGC
+	ModeMemoryLimit TuningMode = "memory_limit"
+	// ModeGCCPU GC CPU预算模式：根据实测GC CPU占比调整GOGC以逼近目标占比
+	ModeGCCPU TuningMode = "gc_cpu"
+	// ModeBallast 内存压舱石模式：GOGC固定，用一块不写入的大对象抬高堆目标
+	ModeBallast TuningMode = "ballast"
+)
+
+const (
+	// GOGCOff 表示关闭基于GOGC的GC触发，仅依赖软内存限制
+	GOGCOff = -1
+	// 默认GC CPU占比上限
+	defaultGCCPUCeiling = 0.25
+	// 每次放宽/收紧软内存限制的步长（占硬限制与安全限制差值的比例）
+	memoryLimitStep = 0.25
+)
+
+// MemoryLimitStrategy 混合模式策略：设置软内存限制并配合GOGC
+// 软限制以安全限制为基准；GC CPU占比超过GCCPUCeiling时逐步放宽至硬限制（扣除运行时之外的内存），
+// 避免存活堆接近软限制时运行时不断GC的死亡螺旋；回落到上限一半以下时逐步收紧回基准
+// GOGC由Config.LimitModeGOGC决定：0表示按存活堆动态计算，GOGCOff表示关闭
+type MemoryLimitStrategy struct {
+	// 动态计算GOGC时使用的策略
+	HeapTarget HeapTargetStrategy
+}
+
+// Name 策略名称
+func (MemoryLimitStrategy) Name() string { return "memory_limit" }
+
+// Decide 计算软内存限制和GOGC
+func (m MemoryLimitStrategy) Decide(s State) Decision {
+	// 运行时内存限制同时约束栈和元数据等非堆内存，基准需加回运行时非堆内存；
+	// 运行时之外的内存不受其约束，上限需扣除
+	base := s.SafetyLimit + s.RuntimeOverheadBytes
+	ceiling := s.MemoryLimit - s.ExternalBytes
+	if ceiling < base {
+		ceiling = base
+	}
+	step := int64(float64(ceiling-base) * memoryLimitStep)
+
+	softLimit := s.CurrentMemoryLimit
+	if softLimit < base {
+		softLimit = base
+	}
+
+	reason := "memory_limit"
+	switch {
+	case s.GCCPUFraction > s.Config.GCCPUCeiling:
+		softLimit += step
+		reason = "gc_cpu_over_ceiling"
+	case s.GCCPUFraction < s.Config.GCCPUCeiling/2:
+		softLimit -= step
+	}
+
+	if softLimit > ceiling {
+		softLimit = ceiling
+	}
+	if softLimit < base {
+		softLimit = base
+	}
+
+	decision := Decision{MemoryLimit: softLimit, Reason: reason}
+	if s.Config.LimitModeGOGC != 0 {
+		decision.GOGC = s.Config.LimitModeGOGC
+	} else {
+		decision.GOGC = m.HeapTarget.Decide(s).GOGC
+	}
+	return decision
+}
@@ -0,0 +1,61 @@
+package gogctuner
+
+import "testing"
+
+func TestMemoryLimitStrategy(t *testing.T) {
+	// 基准 = 安全限制 + 运行时非堆内存 = 1100MB，上限 = 硬限制 - 运行时之外的内存 = 1800MB，步长175MB
+	state := func(current int64, gcCPU float64) State {
+		return State{
+			LiveBytes:            200 * mb,
+			MemoryLimit:          2000 * mb,
+			SafetyLimit:          1000 * mb,
+			RuntimeOverheadBytes: 100 * mb,
+			ExternalBytes:        200 * mb,
+			CurrentGOGC:          100,
+			CurrentMemoryLimit:   current,
+			GCCPUFraction:        gcCPU,
+			Config:               applyDefaults(Config{LimitModeGOGC: GOGCOff}),
+		}
+	}
+
+	tests := []struct {
+		name       string
+		state      State
+		wantLimit  int64
+		wantReason string
+	}{
+		{name: "initial", state: state(0, 0.15), wantLimit: 1100 * mb, wantReason: "memory_limit"},
+		{name: "relax", state: state(1100*mb, 0.3), wantLimit: 1275 * mb, wantReason: "gc_cpu_over_ceiling"},
+		{name: "relax to ceiling", state: state(1750*mb, 0.3), wantLimit: 1800 * mb, wantReason: "gc_cpu_over_ceiling"},
+		{name: "hold", state: state(1450*mb, 0.2), wantLimit: 1450 * mb, wantReason: "memory_limit"},
+		{name: "tighten", state: state(1275*mb, 0.05), wantLimit: 1100 * mb, wantReason: "memory_limit"},
+		{name: "tighten to base", state: state(1200*mb, 0.05), wantLimit: 1100 * mb, wantReason: "memory_limit"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MemoryLimitStrategy{}.Decide(tt.state)
+			if got.MemoryLimit != tt.wantLimit || got.Reason != tt.wantReason || got.GOGC != GOGCOff {
+				t.Fatalf("Decide = {GOGC:%d MemoryLimit:%dMB Reason:%s}, want {GOGC:-1 MemoryLimit:%dMB Reason:%s}",
+					got.GOGC, got.MemoryLimit/mb, got.Reason, tt.wantLimit/mb, tt.wantReason)
+			}
+		})
+	}
+
+	// 运行时之外的内存过多时上限不低于基准
+	s := state(1100*mb, 0.5)
+	s.ExternalBytes = 1500 * mb
+	if got := (MemoryLimitStrategy{}).Decide(s); got.MemoryLimit != 1100*mb {
+		t.Fatalf("上限低于基准时 软限制 = %dMB, want 1100MB", got.MemoryLimit/mb)
+	}
+
+	// LimitModeGOGC为0时按存活堆动态计算GOGC
+	s = state(0, 0.15)
+	s.Config.LimitModeGOGC = 0
+	if got := (MemoryLimitStrategy{}).Decide(s); got.GOGC != 400 {
+		t.Fatalf("动态GOGC = %d, want 400", got.GOGC)
+	}
+	s.Config.LimitModeGOGC = 150
+	if got := (MemoryLimitStrategy{}).Decide(s); got.GOGC != 150 {
+		t.Fatalf("固定GOGC = %d, want 150", got.GOGC)
+	}
+}
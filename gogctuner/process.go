@@ -0,0 +1,40 @@
+package gogctuner
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// readProcessRSS 从/proc/self/status读取当前进程的常驻内存(VmRSS)
+func readProcessRSS(procRoot string) (int64, error) {
+	if procRoot == "" {
+		procRoot = defaultProcRoot
+	}
+	file, err := os.Open(filepath.Join(procRoot, "self", "status"))
+	if err != nil {
+		return 0, err
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || fields[0] != "VmRSS:" {
+			continue
+		}
+		kb, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("gogctuner: 解析VmRSS失败: %w", err)
+		}
+		return kb * 1024, nil
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, err
+	}
+	return 0, errors.New("gogctuner: /proc/self/status 中未找到VmRSS")
+}
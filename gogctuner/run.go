@@ -0,0 +1,52 @@
+package gogctuner
+
+import (
+	"context"
+	"errors"
+)
+
+// ErrAlreadyRunning 调优器已通过Start或Run启动
+var ErrAlreadyRunning = errors.New("gogctuner: 调优器已在运行")
+
+// Run 启动调优器并阻塞直到ctx取消，返回前调用Stop恢复启动前的GC设置
+// 调优器已在运行时返回ErrAlreadyRunning；启动前重新探测内存限制，失败时直接返回错误。
+// ctx取消时返回nil，调用前ctx已取消时同样返回nil且不启动调优器。
+// 适合与errgroup或服务的优雅退出配合使用:
+//
+//	g, ctx := errgroup.WithContext(ctx)
+//	g.Go(func() error { return tuner.Run(ctx) })
+func (t *Tuner) Run(ctx context.Context) error {
+	t.mu.Lock()
+	running := t.state != stateStopped
+	config := t.config
+	t.mu.Unlock()
+	if running {
+		return ErrAlreadyRunning
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+
+	// 内存限制可能在NewTuner之后发生变化（例如cgroup被移除），启动前再确认一次
+	// 探测需要读取文件，在锁外进行
+	memLimit, source, err := resolveMemoryLimit(config)
+	if err != nil {
+		return err
+	}
+
+	t.mu.Lock()
+	if t.state != stateStopped {
+		t.mu.Unlock()
+		return ErrAlreadyRunning
+	}
+	t.memoryLimit.Store(memLimit)
+	t.limitSource.Store(source)
+	t.start()
+	t.mu.Unlock()
+	defer t.Stop()
+
+	// 立即进行首次调整
+	t.adjustGOGC()
+	<-ctx.Done()
+	return nil
+}
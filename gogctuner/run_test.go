@@ -0,0 +1,161 @@
+package gogctuner
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+// newRunTuner 自动探测内存限制的调优器，cgroup限制初始为1000MB
+func newRunTuner(t *testing.T) (*Tuner, *fakeRuntime, MemoryLimitDetector) {
+	t.Helper()
+
+	t.Setenv("MEMORY_LIMIT_BYTES", "")
+	detector := fakeRoot(t, map[string]string{
+		"proc/self/cgroup":          "0::/\n",
+		"proc/meminfo":              hostMemInfo,
+		"cgroup/cgroup.controllers": "memory\n",
+		"cgroup/memory.max":         "1048576000\n",
+	})
+	rt := newFakeRuntime()
+	tuner, err := NewTuner(Config{
+		CgroupRoot:           detector.CgroupRoot,
+		ProcRoot:             detector.ProcRoot,
+		LimitRefreshInterval: -1,
+		HeapOnlyBudget:       true,
+		Runtime:              rt,
+		GCNotifier:           NewManualGCNotifier(),
+		Logger:               slog.New(slog.NewTextHandler(io.Discard, nil)),
+	})
+	if err != nil {
+		t.Fatalf("NewTuner: %v", err)
+	}
+	t.Cleanup(tuner.Stop)
+	return tuner, rt, detector
+}
+
+// runAsync 在后台运行Run，返回结果通道
+func runAsync(tuner *Tuner, ctx context.Context) <-chan error {
+	done := make(chan error, 1)
+	go func() {
+		done <- tuner.Run(ctx)
+	}()
+	return done
+}
+
+func waitRunning(t *testing.T, tuner *Tuner) {
+	t.Helper()
+
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		tuner.mu.Lock()
+		running := tuner.state == stateRunning
+		tuner.mu.Unlock()
+		if running {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatal("调优器没有启动")
+		}
+		time.Sleep(time.Millisecond)
+	}
+}
+
+func TestRun(t *testing.T) {
+	tuner, rt, detector := newRunTuner(t)
+	rt.stats.LiveBytes = 100 * mb
+
+	// 启动前重新探测内存限制
+	writeFile(t, filepath.Join(detector.CgroupRoot, "memory.max"), "2097152000\n")
+	ctx, cancel := context.WithCancel(context.Background())
+	done := runAsync(tuner, ctx)
+	waitRunning(t, tuner)
+	if got := tuner.MemoryLimit(); got != 2000*mb {
+		t.Fatalf("Run后 MemoryLimit = %d, want %d", got, 2000*mb)
+	}
+	if got := rt.GCSettings().GOGC; got == 100 {
+		t.Fatal("Run后没有进行首次调整")
+	}
+
+	cancel()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatalf("ctx取消后 Run = %v, want nil", err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("ctx取消后Run没有返回")
+	}
+	if got := rt.GCSettings().GOGC; got != 100 {
+		t.Fatalf("Run返回后 GOGC = %d, want 100", got)
+	}
+
+	// 停止后可以再次Run
+	ctx, cancel = context.WithCancel(context.Background())
+	done = runAsync(tuner, ctx)
+	waitRunning(t, tuner)
+	cancel()
+	if err := <-done; err != nil {
+		t.Fatalf("再次Run = %v, want nil", err)
+	}
+}
+
+func TestRunCancelledContext(t *testing.T) {
+	tuner, rt, _ := newRunTuner(t)
+	rt.stats.LiveBytes = 100 * mb
+
+	// 调用前已取消与运行中取消一样返回nil，但不启动调优器
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := tuner.Run(ctx); err != nil {
+		t.Fatalf("Run = %v, want nil", err)
+	}
+	if got := rt.GCSettings().GOGC; got != 100 {
+		t.Fatalf("GOGC = %d, want 100", got)
+	}
+	if n := len(tuner.Adjustments()); n != 0 {
+		t.Fatalf("调整次数 = %d, want 0", n)
+	}
+}
+
+func TestRunAlreadyRunning(t *testing.T) {
+	tuner, _, detector := newRunTuner(t)
+	tuner.Start()
+
+	// 已在运行时不修改内存限制
+	writeFile(t, filepath.Join(detector.CgroupRoot, "memory.max"), "524288000\n")
+	for _, ctx := range []context.Context{context.Background(), cancelledContext()} {
+		if err := tuner.Run(ctx); !errors.Is(err, ErrAlreadyRunning) {
+			t.Fatalf("Run = %v, want ErrAlreadyRunning", err)
+		}
+	}
+	if got := tuner.MemoryLimit(); got != 1000*mb {
+		t.Fatalf("MemoryLimit = %d, want %d", got, 1000*mb)
+	}
+}
+
+func TestRunDetectError(t *testing.T) {
+	tuner, rt, detector := newRunTuner(t)
+
+	// 内存限制无法探测时返回错误，不启动调优器
+	if err := os.RemoveAll(filepath.Dir(detector.CgroupRoot)); err != nil {
+		t.Fatal(err)
+	}
+	if err := tuner.Run(context.Background()); err == nil {
+		t.Fatal("探测失败时Run应返回错误")
+	}
+	if got := rt.GCSettings().GOGC; got != 100 {
+		t.Fatalf("GOGC = %d, want 100", got)
+	}
+}
+
+func cancelledContext() context.Context {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	return ctx
+}
@@ -0,0 +1,84 @@
+package gogctuner
+
+import (
+	"runtime"
+	"runtime/debug"
+	"time"
+)
+
+// Runtime 调优器与Go运行时交互的接口
+// 默认实现直接读取runtime/metrics并调用runtime/debug；
+// 测试和离线模拟时可以替换为假实现，确定性地重放堆变化
+type Runtime interface {
+	// ReadStats 读取当前运行时状态
+	ReadStats() RuntimeStats
+	// GCSettings 读取当前的GOGC和软内存限制
+	GCSettings() GCSettings
+	// SetGCPercent 设置GOGC，返回之前的值
+	SetGCPercent(percent int) int
+	// SetMemoryLimit 设置软内存限制，返回之前的值
+	SetMemoryLimit(limit int64) int64
+	// GC 执行一次完整的GC
+	GC()
+	// FreeOSMemory 强制GC并尽可能把空闲内存归还操作系统
+	FreeOSMemory()
+	// Now 当前时间
+	Now() time.Time
+	// AfterFunc 在d之后调用f，调优器的强制GC和固定GOGC到期都通过它计时
+	AfterFunc(d time.Duration, f func()) Timer
+	// NewTicker 每隔d触发一次的周期定时器，用于刷新内存限制和配置文件
+	NewTicker(d time.Duration) Ticker
+}
+
+// Timer Runtime.AfterFunc返回的定时器，语义与*time.Timer相同
+type Timer interface {
+	// Stop 停止定时器，返回定时器是否仍未触发
+	Stop() bool
+	// Reset 在d之后重新触发，返回定时器此前是否仍未触发
+	Reset(d time.Duration) bool
+}
+
+// Ticker Runtime.NewTicker返回的周期定时器，语义与*time.Ticker相同
+type Ticker interface {
+	// C 触发时间的通道
+	C() <-chan time.Time
+	// Stop 停止触发
+	Stop()
+}
+
+// goRuntime 当前进程的Go运行时
+type goRuntime struct {
+	stats *heapStatsReader
+}
+
+// DefaultRuntime 返回当前进程的Go运行时
+func DefaultRuntime() Runtime {
+	return &goRuntime{stats: newHeapStatsReader()}
+}
+
+func (r *goRuntime) ReadStats() RuntimeStats { return r.stats.Read() }
+
+func (r *goRuntime) GCSettings() GCSettings { return readGCSettings() }
+
+func (r *goRuntime) SetGCPercent(percent int) int { return debug.SetGCPercent(percent) }
+
+func (r *goRuntime) SetMemoryLimit(limit int64) int64 { return debug.SetMemoryLimit(limit) }
+
+func (r *goRuntime) GC() { runtime.GC() }
+
+func (r *goRuntime) FreeOSMemory() { debug.FreeOSMemory() }
+
+func (r *goRuntime) Now() time.Time { return time.Now() }
+
+func (r *goRuntime) AfterFunc(d time.Duration, f func()) Timer { return time.AfterFunc(d, f) }
+
+func (r *goRuntime) NewTicker(d time.Duration) Ticker { return goTicker{time.NewTicker(d)} }
+
+// goTicker 把*time.Ticker的C字段包装为方法
+type goTicker struct {
+	ticker *time.Ticker
+}
+
+func (t goTicker) C() <-chan time.Time { return t.ticker.C }
+
+func (t goTicker) Stop() { t.ticker.Stop() }
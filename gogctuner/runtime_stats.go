@@ -0,0 +1,215 @@
+package gogctuner
+
+import (
+	"math"
+	"runtime/metrics"
+	"sync"
+)
+
+// runtime/metrics 指标名称
+const (
+	metricHeapLive    = "/gc/heap/live:bytes"
+	metricHeapGoal    = "/gc/heap/goal:bytes"
+	metricHeapObjects = "/memory/classes/heap/objects:bytes"
+	metricObjectCount = "/gc/heap/objects:objects"
+	metricScanStack   = "/gc/scan/stack:bytes"
+	metricScanGlobals = "/gc/scan/globals:bytes"
+	metricGCCycles    = "/gc/cycles/total:gc-cycles"
+	metricGCCPU       = "/cpu/classes/gc/total:cpu-seconds"
+	metricTotalCPU    = "/cpu/classes/total:cpu-seconds"
+	metricGOGC        = "/gc/gogc:percent"
+	metricMemLimit    = "/gc/gomemlimit:bytes"
+	metricMemTotal    = "/memory/classes/total:bytes"
+	metricHeapRelease = "/memory/classes/heap/released:bytes"
+	metricHeapUnused  = "/memory/classes/heap/unused:bytes"
+	metricHeapFree    = "/memory/classes/heap/free:bytes"
+)
+
+// RuntimeStats 运行时状态快照
+// 与runtime.ReadMemStats不同，读取runtime/metrics不需要STW
+type RuntimeStats struct {
+	// 最近一次GC标记完成后的存活堆大小
+	LiveBytes uint64
+	// 当前GC周期的堆目标
+	GoalBytes uint64
+	// 已分配堆对象占用（含尚未回收的垃圾，等价于HeapAlloc）
+	HeapObjectBytes uint64
+	// 已分配堆对象数量
+	HeapObjects uint64
+	// 可扫描的goroutine栈大小
+	StackBytes uint64
+	// 可扫描的全局变量大小
+	GlobalBytes uint64
+	// 已完成的GC周期数
+	GCCycles uint64
+	// 运行时映射的全部内存
+	TotalBytes uint64
+	// 已归还操作系统的堆内存
+	ReleasedBytes uint64
+	// 堆span中未分配给对象的部分（碎片）
+	UnusedBytes uint64
+	// 空闲且未归还操作系统的堆内存
+	FreeBytes uint64
+	// 进程启动以来GC累计占用的CPU时间(秒)
+	GCCPUSeconds float64
+	// 进程启动以来可用的CPU时间(秒)，即 GOMAXPROCS * 墙钟时间 的累计
+	TotalCPUSeconds float64
+}
+
+// ScannableBytes 计入GC步调的非堆根大小
+// Go 1.18+ 的堆目标为 live + (live + stacks + globals) * GOGC / 100
+func (s RuntimeStats) ScannableBytes() uint64 {
+	return s.LiveBytes + s.StackBytes + s.GlobalBytes
+}
+
+// MappedBytes 运行时实际占用的内存，即debug.SetMemoryLimit约束的部分
+func (s RuntimeStats) MappedBytes() uint64 {
+	if s.TotalBytes < s.ReleasedBytes {
+		return 0
+	}
+	return s.TotalBytes - s.ReleasedBytes
+}
+
+// RuntimeOverheadBytes 运行时的非堆内存：goroutine栈、元数据、profiling等
+func (s RuntimeStats) RuntimeOverheadBytes() uint64 {
+	heap := s.HeapObjectBytes + s.UnusedBytes + s.FreeBytes
+	if s.MappedBytes() < heap {
+		return 0
+	}
+	return s.MappedBytes() - heap
+}
+
+// heapStatsReader 复用采样切片读取堆状态
+type heapStatsReader struct {
+	mu      sync.Mutex
+	samples []metrics.Sample
+}
+
+func newHeapStatsReader() *heapStatsReader {
+	names := []string{
+		metricHeapLive,
+		metricHeapGoal,
+		metricHeapObjects,
+		metricObjectCount,
+		metricScanStack,
+		metricScanGlobals,
+		metricGCCycles,
+		metricMemTotal,
+		metricHeapRelease,
+		metricHeapUnused,
+		metricHeapFree,
+		metricGCCPU,
+		metricTotalCPU,
+	}
+	samples := make([]metrics.Sample, len(names))
+	for i, name := range names {
+		samples[i].Name = name
+	}
+	return &heapStatsReader{samples: samples}
+}
+
+// Read 读取当前堆状态，当前Go版本不支持的指标记为0
+func (r *heapStatsReader) Read() RuntimeStats {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	metrics.Read(r.samples)
+
+	var stats RuntimeStats
+	for _, sample := range r.samples {
+		switch sample.Value.Kind() {
+		case metrics.KindUint64:
+			stats.setUint64(sample.Name, sample.Value.Uint64())
+		case metrics.KindFloat64:
+			stats.setFloat64(sample.Name, sample.Value.Float64())
+		}
+	}
+	return stats
+}
+
+func (s *RuntimeStats) setUint64(name string, value uint64) {
+	switch name {
+	case metricHeapLive:
+		s.LiveBytes = value
+	case metricHeapGoal:
+		s.GoalBytes = value
+	case metricHeapObjects:
+		s.HeapObjectBytes = value
+	case metricObjectCount:
+		s.HeapObjects = value
+	case metricScanStack:
+		s.StackBytes = value
+	case metricScanGlobals:
+		s.GlobalBytes = value
+	case metricGCCycles:
+		s.GCCycles = value
+	case metricMemTotal:
+		s.TotalBytes = value
+	case metricHeapRelease:
+		s.ReleasedBytes = value
+	case metricHeapUnused:
+		s.UnusedBytes = value
+	case metricHeapFree:
+		s.FreeBytes = value
+	}
+}
+
+func (s *RuntimeStats) setFloat64(name string, value float64) {
+	switch name {
+	case metricGCCPU:
+		s.GCCPUSeconds = value
+	case metricTotalCPU:
+		s.TotalCPUSeconds = value
+	}
+}
+
+// gcCPUTracker 计算两次采样之间GC占用的CPU比例
+// 分母为/cpu/classes/total:cpu-seconds，即 GOMAXPROCS * 墙钟时间
+type gcCPUTracker struct {
+	mu        sync.Mutex
+	lastGC    float64
+	lastTotal float64
+}
+
+func newGCCPUTracker(stats RuntimeStats) *gcCPUTracker {
+	return &gcCPUTracker{lastGC: stats.GCCPUSeconds, lastTotal: stats.TotalCPUSeconds}
+}
+
+// Fraction 返回自上次调用以来GC CPU占比(0-1)，数据不足时返回0
+func (c *gcCPUTracker) Fraction(stats RuntimeStats) float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	deltaGC := stats.GCCPUSeconds - c.lastGC
+	deltaTotal := stats.TotalCPUSeconds - c.lastTotal
+	if deltaTotal <= 0 {
+		return 0
+	}
+	c.lastGC, c.lastTotal = stats.GCCPUSeconds, stats.TotalCPUSeconds
+	return deltaGC / deltaTotal
+}
+
+// GCSettings 运行时当前的GC设置，包括GOGC/GOMEMLIMIT环境变量和
+// debug.SetGCPercent/debug.SetMemoryLimit设置的值
+type GCSettings struct {
+	// GOGC，GOGCOff表示关闭
+	GOGC int
+	// 软内存限制，math.MaxInt64表示未设置
+	MemoryLimit int64
+}
+
+// readGCSettings 读取运行时当前的GC设置
+func readGCSettings() GCSettings {
+	samples := []metrics.Sample{{Name: metricGOGC}, {Name: metricMemLimit}}
+	metrics.Read(samples)
+
+	settings := GCSettings{GOGC: 100, MemoryLimit: math.MaxInt64}
+	if samples[0].Value.Kind() == metrics.KindUint64 {
+		// 运行时以uint64导出int32的GOGC，关闭时为-1
+		settings.GOGC = int(int32(samples[0].Value.Uint64()))
+	}
+	if samples[1].Value.Kind() == metrics.KindUint64 {
+		settings.MemoryLimit = int64(samples[1].Value.Uint64())
+	}
+	return settings
+}
@@ -0,0 +1,52 @@
+package gogctuner
+
+import (
+	"runtime"
+	"testing"
+)
+
+func TestHeapStatsReader(t *testing.T) {
+	reader := newHeapStatsReader()
+	runtime.GC()
+	before := reader.Read()
+
+	// 保留64MB存活对象，GC后存活堆应包含它们
+	retained := make([][]byte, 64)
+	for i := range retained {
+		retained[i] = make([]byte, mb)
+	}
+	runtime.GC()
+	stats := reader.Read()
+	runtime.KeepAlive(retained)
+
+	if stats.GCCycles <= before.GCCycles {
+		t.Fatalf("GCCycles = %d, 之前 %d", stats.GCCycles, before.GCCycles)
+	}
+	if stats.LiveBytes < 64*mb {
+		t.Fatalf("LiveBytes = %dMB, want >= 64MB", stats.LiveBytes/mb)
+	}
+	if stats.GoalBytes < stats.LiveBytes {
+		t.Fatalf("GoalBytes = %d < LiveBytes = %d", stats.GoalBytes, stats.LiveBytes)
+	}
+	if stats.HeapObjectBytes < stats.LiveBytes/2 || stats.HeapObjects == 0 {
+		t.Fatalf("HeapObjectBytes = %d, HeapObjects = %d", stats.HeapObjectBytes, stats.HeapObjects)
+	}
+	if stats.TotalBytes == 0 || stats.MappedBytes() < stats.HeapObjectBytes {
+		t.Fatalf("TotalBytes = %d, MappedBytes = %d", stats.TotalBytes, stats.MappedBytes())
+	}
+	if stats.TotalCPUSeconds <= 0 || stats.GCCPUSeconds <= 0 {
+		t.Fatalf("TotalCPUSeconds = %v, GCCPUSeconds = %v", stats.TotalCPUSeconds, stats.GCCPUSeconds)
+	}
+}
+
+func TestLiveHeapIgnoresGarbage(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+
+	// 已分配堆中有700MB尚未回收的垃圾，GOGC只按标记后的存活堆计算
+	sim.rt.mu.Lock()
+	sim.rt.stats.HeapObjectBytes = 900 * mb
+	sim.rt.mu.Unlock()
+	if got := sim.step(traceStep{Live: 200 * mb}); got != 400 {
+		t.Fatalf("GOGC = %d, want 400", got)
+	}
+}
@@ -0,0 +1,187 @@
+package gogctuner
+
+import (
+	"sort"
+	"time"
+)
+
+// 默认保留的调整记录条数
+const defaultHistorySize = 128
+
+// Snapshot 调优器状态快照
+type Snapshot struct {
+	Time     time.Time  `json:"time"`
+	Enabled  bool       `json:"enabled"`
+	Mode     TuningMode `json:"mode"`
+	Strategy string     `json:"strategy"`
+	// 当前GOGC，GOGCOff表示关闭
+	GOGC int `json:"gogc"`
+	// 是否暂停调优
+	Paused bool `json:"paused"`
+	// 固定的GOGC及截止时间，未固定时PinnedUntil为nil
+	PinnedGOGC  int        `json:"pinned_gogc,omitempty"`
+	PinnedUntil *time.Time `json:"pinned_until,omitempty"`
+	// 内存硬限制及来源
+	MemoryLimit int64       `json:"memory_limit_bytes"`
+	LimitSource LimitSource `json:"memory_limit_source"`
+	// 堆可用的安全限制 = MemoryLimit * SafetyFactor - 非堆内存
+	SafetyLimit  int64   `json:"safety_limit_bytes"`
+	SafetyFactor float64 `json:"safety_factor"`
+	// 容器工作集，以及计入预算的运行时非堆内存和运行时之外的内存
+	WorkingSetBytes      int64 `json:"working_set_bytes"`
+	RuntimeOverheadBytes int64 `json:"runtime_overhead_bytes"`
+	ExternalBytes        int64 `json:"external_bytes"`
+	// 软内存限制，0表示未设置
+	SoftMemoryLimit int64 `json:"soft_memory_limit_bytes"`
+	// 堆状态
+	HeapLiveBytes  uint64 `json:"heap_live_bytes"`
+	HeapGoalBytes  uint64 `json:"heap_goal_bytes"`
+	HeapAllocBytes uint64 `json:"heap_alloc_bytes"`
+	HeapObjects    uint64 `json:"heap_objects"`
+	GCCycles       uint64 `json:"gc_cycles"`
+	// 存活堆占内存硬限制的比例
+	MemoryUsageRatio float64 `json:"memory_usage_ratio"`
+	// 多进程协调时本进程分得的预算比例和进程数，未协调时为1和0
+	BudgetShare float64 `json:"budget_share"`
+	Peers       int     `json:"peers"`
+	// 压舱石大小，非压舱石模式为0；存活堆包含压舱石
+	BallastBytes int64 `json:"ballast_bytes"`
+	// 是否处于紧急模式，以及累计触发次数（含重复触发）
+	Emergency      bool   `json:"emergency"`
+	EmergencyCount uint64 `json:"emergency_count"`
+	// 累计调整次数，按策略和原因统计
+	Adjustments []AdjustmentCount `json:"adjustments"`
+}
+
+// AdjustmentCount 按策略和原因统计的调整次数
+type AdjustmentCount struct {
+	Strategy string `json:"strategy"`
+	Reason   string `json:"reason"`
+	Count    uint64 `json:"count"`
+}
+
+// TotalAdjustments 累计调整次数
+func (s Snapshot) TotalAdjustments() uint64 {
+	var total uint64
+	for _, c := range s.Adjustments {
+		total += c.Count
+	}
+	return total
+}
+
+// Adjustment 一次GOGC或软内存限制的调整记录
+type Adjustment struct {
+	Time    time.Time `json:"time"`
+	OldGOGC int       `json:"old_gogc"`
+	NewGOGC int       `json:"new_gogc"`
+	// 软内存限制，0表示未设置
+	OldSoftMemoryLimit int64  `json:"old_soft_memory_limit_bytes"`
+	NewSoftMemoryLimit int64  `json:"new_soft_memory_limit_bytes"`
+	LiveBytes          uint64 `json:"live_bytes"`
+	MemoryLimit        int64  `json:"memory_limit_bytes"`
+	Strategy           string `json:"strategy"`
+	Reason             string `json:"reason"`
+	// 距上次调优的间隔
+	GCInterval time.Duration `json:"gc_interval_ns"`
+}
+
+// adjustmentRing 固定容量的调整记录环形缓冲区
+type adjustmentRing struct {
+	buf  []Adjustment
+	next int
+	full bool
+}
+
+func newAdjustmentRing(size int) *adjustmentRing {
+	return &adjustmentRing{buf: make([]Adjustment, size)}
+}
+
+func (r *adjustmentRing) add(a Adjustment) {
+	r.buf[r.next] = a
+	r.next = (r.next + 1) % len(r.buf)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// list 按时间从旧到新返回所有记录
+func (r *adjustmentRing) list() []Adjustment {
+	if !r.full {
+		return append([]Adjustment(nil), r.buf[:r.next]...)
+	}
+	out := make([]Adjustment, 0, len(r.buf))
+	out = append(out, r.buf[r.next:]...)
+	return append(out, r.buf[:r.next]...)
+}
+
+// Snapshot 获取调优器当前状态
+// 堆状态为调用时读取的值，安全限制、工作集和协调预算为最近一次调整时的值，
+// 快照没有副作用，可以频繁调用
+func (t *Tuner) Snapshot() Snapshot {
+	stats := t.runtime.ReadStats()
+	memoryLimit := t.memoryLimit.Load()
+
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	// 使用最近一次决策时的预算，快照不读取cgroup文件也不写入协调注册表
+	budget := t.budget
+
+	snapshot := Snapshot{
+		Time:                 t.runtime.Now(),
+		Enabled:              t.state == stateRunning,
+		Mode:                 t.config.Mode,
+		Strategy:             t.strategy.Name(),
+		GOGC:                 t.currentGOGC,
+		MemoryLimit:          memoryLimit,
+		LimitSource:          t.LimitSource(),
+		SafetyLimit:          budget.SafetyLimit,
+		SafetyFactor:         t.config.SafetyFactor,
+		WorkingSetBytes:      budget.WorkingSetBytes,
+		RuntimeOverheadBytes: budget.RuntimeOverheadBytes,
+		ExternalBytes:        budget.ExternalBytes,
+		SoftMemoryLimit:      t.softLimit,
+		HeapLiveBytes:        stats.LiveBytes,
+		HeapGoalBytes:        stats.GoalBytes,
+		HeapAllocBytes:       stats.HeapObjectBytes,
+		HeapObjects:          stats.HeapObjects,
+		GCCycles:             stats.GCCycles,
+		Paused:               t.paused,
+		Emergency:            t.emergency.active,
+		EmergencyCount:       t.emergency.count,
+		BallastBytes:         t.ballast.Size(),
+		BudgetShare:          budget.Share,
+		Peers:                budget.Peers,
+	}
+	if !t.pinnedUntil.IsZero() {
+		until := t.pinnedUntil
+		snapshot.PinnedGOGC = t.pinnedGOGC
+		snapshot.PinnedUntil = &until
+	}
+	if memoryLimit > 0 {
+		snapshot.MemoryUsageRatio = float64(stats.LiveBytes) / float64(memoryLimit)
+	}
+
+	for key, count := range t.adjustments {
+		snapshot.Adjustments = append(snapshot.Adjustments, AdjustmentCount{
+			Strategy: key.Strategy,
+			Reason:   key.Reason,
+			Count:    count,
+		})
+	}
+	sort.Slice(snapshot.Adjustments, func(i, j int) bool {
+		a, b := snapshot.Adjustments[i], snapshot.Adjustments[j]
+		if a.Strategy != b.Strategy {
+			return a.Strategy < b.Strategy
+		}
+		return a.Reason < b.Reason
+	})
+	return snapshot
+}
+
+// Adjustments 获取最近的调整记录，按时间从旧到新排列，最多保留Config.HistorySize条
+func (t *Tuner) Adjustments() []Adjustment {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.adjustmentLog.list()
+}
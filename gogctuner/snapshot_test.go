@@ -0,0 +1,132 @@
+package gogctuner
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestAdjustmentRing(t *testing.T) {
+	ring := newAdjustmentRing(3)
+	if got := ring.list(); len(got) != 0 {
+		t.Fatalf("空缓冲区 = %+v", got)
+	}
+	for i := 1; i <= 5; i++ {
+		ring.add(Adjustment{NewGOGC: i})
+		want := min(i, 3)
+		got := ring.list()
+		if len(got) != want || got[len(got)-1].NewGOGC != i || got[0].NewGOGC != i-want+1 {
+			t.Fatalf("添加%d条后 = %+v", i, got)
+		}
+	}
+}
+
+func TestAdjustmentHistory(t *testing.T) {
+	config := simConfig()
+	config.HistorySize = 2
+	sim := newSimulation(t, config)
+
+	sim.replay([]traceStep{
+		{Live: 100 * mb},
+		{Live: 200 * mb, Interval: 3 * time.Second},
+		{Live: 400 * mb, Interval: 5 * time.Second},
+	})
+
+	history := sim.tuner.Adjustments()
+	if len(history) != 2 {
+		t.Fatalf("调整记录 = %d条, want 2", len(history))
+	}
+	want := Adjustment{
+		Time:        sim.rt.Now(),
+		OldGOGC:     400,
+		NewGOGC:     150,
+		LiveBytes:   400 * mb,
+		MemoryLimit: simMemoryLimit,
+		Strategy:    "heap_target",
+		Reason:      "heap_target",
+		GCInterval:  5 * time.Second,
+	}
+	if history[1] != want {
+		t.Fatalf("最近的调整 = %+v, want %+v", history[1], want)
+	}
+	if history[0].OldGOGC != 500 || history[0].NewGOGC != 400 {
+		t.Fatalf("较早的调整 = %+v", history[0])
+	}
+
+	// 计数不受记录条数限制
+	snapshot := sim.tuner.Snapshot()
+	if snapshot.TotalAdjustments() != 3 {
+		t.Fatalf("TotalAdjustments = %d, want 3", snapshot.TotalAdjustments())
+	}
+}
+
+func TestSnapshot(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+	sim.step(traceStep{Live: 500 * mb})
+
+	snapshot := sim.tuner.Snapshot()
+	if !snapshot.Enabled || snapshot.Mode != ModeGOGC || snapshot.GOGC != 100 ||
+		snapshot.HeapLiveBytes != 500*mb || snapshot.MemoryUsageRatio != 0.25 ||
+		snapshot.SafetyLimit != 1000*mb || snapshot.LimitSource != LimitSourceConfig {
+		t.Fatalf("Snapshot = %+v", snapshot)
+	}
+
+	// 未固定GOGC时不输出pinned字段
+	data, err := json.Marshal(snapshot)
+	if err != nil {
+		t.Fatal(err)
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(data, &fields); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := fields["pinned_until"]; ok {
+		t.Fatalf("未固定时输出了pinned_until: %s", data)
+	}
+	if fields["heap_live_bytes"] != float64(500*mb) {
+		t.Fatalf("heap_live_bytes = %v", fields["heap_live_bytes"])
+	}
+
+	metrics := sim.tuner.GetMetrics()
+	if metrics["current_gogc"] != 100 || metrics["memory_usage_ratio"] != 0.25 || metrics["tuner_enabled"] != true {
+		t.Fatalf("GetMetrics = %+v", metrics)
+	}
+}
+
+func TestSnapshotNoSideEffects(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "a"+peerFileSuffix)
+	config := simConfig()
+	config.Coordinator = NewCoordinator(dir)
+	config.Coordinator.ID = "a"
+	sim := newSimulation(t, config)
+	sim.step(traceStep{Live: 100 * mb})
+	before, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("读取注册表条目: %v", err)
+	}
+
+	// 超过刷新间隔后快照仍使用最近一次调整时的预算，不写入注册表
+	sim.rt.mu.Lock()
+	sim.rt.now = sim.rt.now.Add(time.Minute)
+	sim.rt.stats.LiveBytes = 300 * mb
+	sim.rt.mu.Unlock()
+	snapshot := sim.tuner.Snapshot()
+	if snapshot.HeapLiveBytes != 300*mb || snapshot.SafetyLimit != 1000*mb || snapshot.Peers != 1 {
+		t.Fatalf("Snapshot HeapLiveBytes=%dMB SafetyLimit=%dMB Peers=%d",
+			snapshot.HeapLiveBytes/mb, snapshot.SafetyLimit/mb, snapshot.Peers)
+	}
+	if after, err := os.ReadFile(path); err != nil || !bytes.Equal(before, after) {
+		t.Fatalf("快照后 注册表条目 = %s, %v, want %s", after, err, before)
+	}
+
+	// 停止后快照不会重新创建已删除的条目
+	sim.tuner.Stop()
+	sim.tuner.Snapshot()
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Fatalf("停止后 注册表条目仍存在: %v", err)
+	}
+}
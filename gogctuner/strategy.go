@@ -0,0 +1,174 @@
+package gogctuner
+
+import (
+	"time"
+)
+
+// 默认GOGC变化阈值，变化比例不超过该值时不调整，避免频繁抖动
+const defaultHysteresis = 0.1
+
+// Strategy 调优策略
+// 每个GC周期调优器构造State交给策略，由策略给出下一步的GOGC和软内存限制
+// 调优器会把结果GOGC限制在[MinGOGC, MaxGOGC]内（GOGCOff除外）
+type Strategy interface {
+	// Name 策略名称，用于日志和监控
+	Name() string
+	// Decide 根据当前状态给出决策
+	Decide(state State) Decision
+}
+
+// State 策略输入：当前堆、内存限制、GC历史及GOGC
+type State struct {
+	// 采样时间
+	Time time.Time
+	// 最近一次GC标记后的存活堆
+	LiveBytes uint64
+	// 当前堆目标
+	HeapGoalBytes uint64
+	// 可扫描的栈大小
+	StackBytes uint64
+	// 可扫描的全局变量大小
+	GlobalBytes uint64
+	// 内存硬限制
+	MemoryLimit int64
+	// 堆可用的安全限制 = MemoryLimit * SafetyFactor - RuntimeOverheadBytes - ExternalBytes
+	// Config.HeapOnlyBudget为true时为 MemoryLimit * SafetyFactor
+	SafetyLimit int64
+	// 运行时的非堆内存：goroutine栈、元数据等
+	RuntimeOverheadBytes int64
+	// 运行时之外的内存：cgo、mmap、活跃的页缓存等，即容器工作集减去运行时占用
+	ExternalBytes int64
+	// 当前GOGC
+	CurrentGOGC int
+	// 当前软内存限制，0表示未设置
+	CurrentMemoryLimit int64
+	// 自上次决策以来GC CPU占比(0-1)
+	GCCPUFraction float64
+	// 距上次决策的间隔
+	GCInterval time.Duration
+	// 最近的GC采样，按时间从旧到新排列
+	History []GCSample
+	// 调优器配置
+	Config Config
+}
+
+// GCSample 一次决策时的GC采样
+type GCSample struct {
+	Time          time.Time
+	LiveBytes     uint64
+	GOGC          int
+	GCCPUFraction float64
+	Interval      time.Duration
+}
+
+// Decision 策略输出
+type Decision struct {
+	// 下一步的GOGC，GOGCOff表示关闭
+	GOGC int
+	// 软内存限制，0表示不设置
+	MemoryLimit int64
+	// 决策原因，用于日志和监控
+	Reason string
+}
+
+// MaxSafeGOGC 使堆目标恰好等于安全限制的GOGC，已限制在[MinGOGC, MaxGOGC]内
+// 堆目标 = 存活堆 + (存活堆 + 栈 + 全局变量) * GOGC / 100
+func (s State) MaxSafeGOGC() int {
+	return s.targetGOGC(float64(s.SafetyLimit))
+}
+
+// targetGOGC 使堆目标等于limit的GOGC，尚无存活堆数据时返回100
+func (s State) targetGOGC(limit float64) int {
+	if s.LiveBytes == 0 {
+		return 100
+	}
+	if float64(s.LiveBytes) > limit {
+		return s.Config.MinGOGC
+	}
+	scannable := float64(s.LiveBytes + s.StackBytes + s.GlobalBytes)
+	return clampGOGC(int((limit-float64(s.LiveBytes))/scannable*100), s.Config)
+}
+
+// clampGOGC 将GOGC限制在[MinGOGC, MaxGOGC]内，GOGCOff保持不变
+func clampGOGC(gogc int, config Config) int {
+	if gogc == GOGCOff {
+		return gogc
+	}
+	if gogc < config.MinGOGC {
+		return config.MinGOGC
+	}
+	if gogc > config.MaxGOGC {
+		return config.MaxGOGC
+	}
+	return gogc
+}
+
+// applyHysteresis 变化比例不超过threshold时保持当前GOGC
+func applyHysteresis(current, next int, threshold float64) int {
+	if current <= 0 || next == current {
+		return next
+	}
+	change := float64(next-current) / float64(current)
+	if change > threshold || change < -threshold {
+		return next
+	}
+	return current
+}
+
+// HeapTargetStrategy 默认策略：让堆目标贴近安全限制
+// 存活堆超过安全限制时使用MinGOGC；允许峰值突破且存活堆低于安全限制一半时，以峰值限制为目标
+type HeapTargetStrategy struct {
+	// GOGC变化阈值，默认0.1
+	Hysteresis float64
+}
+
+// Name 策略名称
+func (HeapTargetStrategy) Name() string { return "heap_target" }
+
+// Decide 根据存活堆计算GOGC
+func (h HeapTargetStrategy) Decide(s State) Decision {
+	gogc, reason := heapTargetGOGC(s)
+	return Decision{
+		GOGC:   applyHysteresis(s.CurrentGOGC, gogc, h.hysteresis()),
+		Reason: reason,
+	}
+}
+
+func (h HeapTargetStrategy) hysteresis() float64 {
+	if h.Hysteresis <= 0 {
+		return defaultHysteresis
+	}
+	return h.Hysteresis
+}
+
+// heapTargetGOGC 按存活堆计算GOGC及原因
+func heapTargetGOGC(s State) (int, string) {
+	safetyLimit := float64(s.SafetyLimit)
+	switch {
+	case s.LiveBytes == 0:
+		// 尚未完成首次GC，没有存活堆数据，使用默认值
+		return 100, "no_live_heap"
+	case float64(s.LiveBytes) > safetyLimit:
+		// 存活对象已超过安全限制，降低GOGC以更频繁GC
+		return s.Config.MinGOGC, "over_safety_limit"
+	case s.Config.AllowPeakOverride && float64(s.LiveBytes) < safetyLimit*0.5:
+		// 存活对象远低于安全限制，可以适当提高GOGC
+		return s.targetGOGC(safetyLimit * s.Config.PeakThreshold), "peak_override"
+	default:
+		return s.targetGOGC(safetyLimit), "heap_target"
+	}
+}
+
+// StrategyForMode 返回调优模式对应的内置策略
+func StrategyForMode(mode TuningMode) Strategy {
+	switch mode {
+	case ModeMemoryLimit:
+		return MemoryLimitStrategy{}
+	case ModeGCCPU:
+		return GCCPUStrategy{}
+	case ModeBallast:
+		return BallastStrategy{}
+	default:
+		return HeapTargetStrategy{}
+	}
+}
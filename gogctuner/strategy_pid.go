@@ -0,0 +1,96 @@
+package gogctuner
+
+import "sync"
+
+// 默认PID参数，单位为 GOGC / GC CPU百分点
+const (
+	defaultPIDKp = 10.0
+	defaultPIDKi = 2.0
+	defaultPIDKd = 4.0
+)
+
+// PIDStrategy PID控制器策略
+// 以GC CPU占比为被控量，误差 = 实测占比 - 目标占比（百分点），控制量为GOGC增量：
+// GC CPU偏高时提高GOGC，偏低时降低GOGC；结果不超过内存安全限制对应的GOGC
+// PIDStrategy有内部状态，不能在多个调优器之间共享
+type PIDStrategy struct {
+	Kp float64
+	Ki float64
+	Kd float64
+	// 目标GC CPU占比(%)，为0时使用Config.TargetGCCPUPercent
+	TargetGCCPUPercent float64
+
+	mu        sync.Mutex
+	integral  float64
+	lastError float64
+	hasLast   bool
+}
+
+// NewPIDStrategy 创建PID策略，参数为0时使用默认值
+func NewPIDStrategy(kp, ki, kd float64) *PIDStrategy {
+	if kp == 0 {
+		kp = defaultPIDKp
+	}
+	if ki == 0 {
+		ki = defaultPIDKi
+	}
+	if kd == 0 {
+		kd = defaultPIDKd
+	}
+	return &PIDStrategy{Kp: kp, Ki: ki, Kd: kd}
+}
+
+// Name 策略名称
+func (p *PIDStrategy) Name() string { return "pid" }
+
+// Decide 根据GC CPU误差计算GOGC
+func (p *PIDStrategy) Decide(s State) Decision {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	// 当前GOGC关闭(GOGC=off)或未设置时，以MinGOGC为起点接管，否则控制器永远无法介入
+	current := s.CurrentGOGC
+	if current <= 0 {
+		current = s.Config.MinGOGC
+		if current <= 0 {
+			current = 100
+		}
+	}
+
+	// 采样窗口内没有GC时无法评估，保持当前值
+	if s.GCCPUFraction <= 0 {
+		return Decision{GOGC: clampGOGC(current, s.Config), Reason: "pid_no_sample"}
+	}
+
+	target := p.TargetGCCPUPercent
+	if target <= 0 {
+		target = s.Config.TargetGCCPUPercent
+	}
+	err := s.GCCPUFraction*100 - target
+
+	var derivative float64
+	if p.hasLast {
+		derivative = err - p.lastError
+	}
+	p.lastError = err
+	p.hasLast = true
+
+	integral := p.integral + err
+	output := p.Kp*err + p.Ki*integral + p.Kd*derivative
+	newGOGC := current + int(output)
+
+	reason := "pid"
+	upper, _ := heapTargetGOGC(s)
+	switch {
+	case newGOGC > upper:
+		// 输出饱和时不累积积分，避免积分饱和(windup)
+		newGOGC = upper
+		reason = "pid_memory_bound"
+	case newGOGC < s.Config.MinGOGC:
+		newGOGC = s.Config.MinGOGC
+	default:
+		p.integral = integral
+	}
+
+	return Decision{GOGC: newGOGC, Reason: reason}
+}
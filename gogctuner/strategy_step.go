@@ -0,0 +1,49 @@
+package gogctuner
+
+import "sort"
+
+// Step 阶梯策略的一级：存活堆占安全限制的比例不超过MaxUsage时使用GOGC
+type Step struct {
+	MaxUsage float64
+	GOGC     int
+}
+
+// DefaultSteps 默认阶梯
+var DefaultSteps = []Step{
+	{MaxUsage: 0.3, GOGC: 400},
+	{MaxUsage: 0.5, GOGC: 200},
+	{MaxUsage: 0.7, GOGC: 100},
+	{MaxUsage: 0.9, GOGC: 50},
+}
+
+// StepStrategy 阶梯策略：按存活堆占安全限制的比例选择固定的GOGC档位
+// 超过最后一级时使用MinGOGC。档位离散，天然不会频繁抖动
+type StepStrategy struct {
+	// 阶梯，为空时使用DefaultSteps
+	Steps []Step
+}
+
+// Name 策略名称
+func (StepStrategy) Name() string { return "step" }
+
+// Decide 选择当前使用率对应的档位
+func (st StepStrategy) Decide(s State) Decision {
+	if s.LiveBytes == 0 || s.SafetyLimit <= 0 {
+		return Decision{GOGC: 100, Reason: "no_live_heap"}
+	}
+
+	steps := st.Steps
+	if len(steps) == 0 {
+		steps = DefaultSteps
+	}
+	steps = append([]Step(nil), steps...)
+	sort.Slice(steps, func(i, j int) bool { return steps[i].MaxUsage < steps[j].MaxUsage })
+
+	usage := float64(s.LiveBytes) / float64(s.SafetyLimit)
+	for _, step := range steps {
+		if usage <= step.MaxUsage {
+			return Decision{GOGC: step.GOGC, Reason: "step"}
+		}
+	}
+	return Decision{GOGC: s.Config.MinGOGC, Reason: "over_last_step"}
+}
@@ -0,0 +1,120 @@
+package gogctuner
+
+import "testing"
+
+func TestPIDStrategy(t *testing.T) {
+	state := func(current int, gcCPU float64) State {
+		return State{
+			LiveBytes:     100 * mb,
+			MemoryLimit:   2000 * mb,
+			SafetyLimit:   1000 * mb,
+			CurrentGOGC:   current,
+			GCCPUFraction: gcCPU,
+			Config:        applyDefaults(Config{}),
+		}
+	}
+
+	pid := NewPIDStrategy(0, 0, 0)
+	steps := []struct {
+		current    int
+		gcCPU      float64
+		want       int
+		wantReason string
+	}{
+		// GOGC=off时以MinGOGC为起点接管
+		{current: GOGCOff, gcCPU: 0, want: 25, wantReason: "pid_no_sample"},
+		// 误差5个百分点：10*5 + 2*5 = 60
+		{current: GOGCOff, gcCPU: 0.1, want: 85, wantReason: "pid"},
+		// 积分累积到10：10*5 + 2*10 = 70
+		{current: 85, gcCPU: 0.1, want: 155, wantReason: "pid"},
+		// 误差为0：积分项2*10，微分项4*(0-5)
+		{current: 155, gcCPU: 0.05, want: 155, wantReason: "pid"},
+		// 超过内存安全限制对应的GOGC时饱和，不累积积分
+		{current: 480, gcCPU: 0.1, want: 500, wantReason: "pid_memory_bound"},
+		// 误差-4.9：-49 + 2*(10-4.9) + 4*(-4.9-5) = -78.4
+		{current: 60, gcCPU: 0.001, want: 25, wantReason: "pid"},
+	}
+	for i, step := range steps {
+		got := pid.Decide(state(step.current, step.gcCPU))
+		if got.GOGC != step.want || got.Reason != step.wantReason {
+			t.Fatalf("第%d步 Decide = %d, %s, want %d, %s", i, got.GOGC, got.Reason, step.want, step.wantReason)
+		}
+	}
+}
+
+func TestPIDStrategyFromGOGCOff(t *testing.T) {
+	config := simConfig()
+	config.Strategy = NewPIDStrategy(0, 0, 0)
+	sim := newSimulation(t, config)
+
+	// 进程以GOGC=off启动
+	sim.tuner.Stop()
+	sim.rt.settings.GOGC = GOGCOff
+	sim.tuner.Start()
+
+	got := sim.replay([]traceStep{
+		{Live: 100 * mb, GCCPU: 0.1},
+		{Live: 100 * mb, GCCPU: 0.1},
+	})
+	assertTrajectory(t, got, []int{85, 155})
+}
+
+func TestStepStrategy(t *testing.T) {
+	tests := []struct {
+		name       string
+		steps      []Step
+		live       uint64
+		want       int
+		wantReason string
+	}{
+		{name: "no live heap", live: 0, want: 100, wantReason: "no_live_heap"},
+		{name: "first step", live: 300, want: 400, wantReason: "step"},
+		{name: "boundary", live: 500, want: 200, wantReason: "step"},
+		{name: "last step", live: 850, want: 50, wantReason: "step"},
+		{name: "over last step", live: 950, want: 25, wantReason: "over_last_step"},
+		{
+			// 自定义阶梯不要求有序
+			name:       "custom unsorted",
+			steps:      []Step{{MaxUsage: 0.8, GOGC: 80}, {MaxUsage: 0.4, GOGC: 300}},
+			live:       300,
+			want:       300,
+			wantReason: "step",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := StepStrategy{Steps: tt.steps}.Decide(State{
+				LiveBytes:   tt.live * mb,
+				SafetyLimit: 1000 * mb,
+				Config:      applyDefaults(Config{}),
+			})
+			if got.GOGC != tt.want || got.Reason != tt.wantReason {
+				t.Fatalf("Decide = %d, %s, want %d, %s", got.GOGC, got.Reason, tt.want, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestStrategySelection(t *testing.T) {
+	for mode, want := range map[TuningMode]string{
+		"":              "heap_target",
+		ModeGOGC:        "heap_target",
+		ModeMemoryLimit: "memory_limit",
+		ModeGCCPU:       "gc_cpu",
+		ModeBallast:     "ballast",
+	} {
+		config := simConfig()
+		config.Mode = mode
+		if got := newSimulation(t, config).tuner.Snapshot().Strategy; got != want {
+			t.Fatalf("Mode=%q 策略 = %s, want %s", mode, got, want)
+		}
+	}
+
+	// Config.Strategy优先于Mode
+	config := simConfig()
+	config.Mode = ModeGCCPU
+	config.Strategy = StepStrategy{}
+	if got := newSimulation(t, config).tuner.Snapshot().Strategy; got != "step" {
+		t.Fatalf("策略 = %s, want step", got)
+	}
+}
@@ -1,12 +1,11 @@
 package gogctuner
 
 import (
-	"log"
+	"log/slog"
 	"os"
-	"runtime"
-	"runtime/debug"
 	"strconv"
 	"sync"
+	"sync/atomic"
 	"time"
 )
 
@@ -15,6 +14,10 @@ const (
 	defaultSafetyFactor = 0.7
 	// 强制GC时间间隔（保持与Go运行时一致）
 	forcedGCInterval = 2 * time.Minute
+	// 默认内存限制刷新间隔
+	defaultLimitRefreshInterval = 30 * time.Second
+	// 提供给策略的GC采样历史条数
+	maxGCHistory = 16
 )
 
 // Config 调优器配置
@@ -31,125 +34,307 @@ type Config struct {
 	AllowPeakOverride bool
 	// 突破阈值倍数
 	PeakThreshold float64
-	// 调试模式
+	// 调试模式，未设置Logger时输出Debug级别日志到标准错误
+	//
+	// Deprecated: 使用Logger，通过Handler的级别控制日志输出
 	DebugMode bool
+	// 结构化日志器，为空时由DebugMode决定是否输出
+	Logger *slog.Logger
+	// cgroup文件系统根目录，为空时使用/sys/fs/cgroup
+	CgroupRoot string
+	// proc文件系统根目录，为空时使用/proc
+	ProcRoot string
+	// 内存限制刷新间隔，0表示使用默认值30s，负数表示不刷新
+	// 仅在MemoryHardLimit为0（自动探测）时生效
+	LimitRefreshInterval time.Duration
+	// 内存限制变化时的回调
+	OnLimitChange func(LimitChangeEvent)
+	// GC通知器，为空时调优器自行创建，多个组件可共享同一个通知器
+	GCNotifier *GCNotifier
+	// 调优模式，默认ModeGOGC
+	Mode TuningMode
+	// 混合模式下使用的GOGC，0表示沿用动态计算的GOGC，GOGCOff(-1)表示关闭GOGC仅依赖内存限制
+	LimitModeGOGC int
+	// 混合模式下GC CPU占比上限(0-1)，超过时放宽软内存限制，默认0.25
+	GCCPUCeiling float64
+	// GC CPU预算模式下的目标GC CPU占比(%)，默认5
+	TargetGCCPUPercent float64
+	// 调优策略，为空时使用Mode对应的内置策略
+	Strategy Strategy
+	// 保留的调整记录条数，默认128
+	HistorySize int
+	// 压舱石模式下压舱石大小占内存硬限制的比例，默认0.25
+	BallastRatio float64
+	// 压舱石模式下固定的GOGC，默认100
+	BallastGOGC int
+	// 多进程协调器，同一容器内有多个Go进程时按存活堆比例分配安全限制
+	Coordinator *Coordinator
+	// 只按堆计算安全限制，不扣除栈、运行时元数据、cgo/mmap和页缓存等非堆内存
+	HeapOnlyBudget bool
+	// 紧急模式：存活堆占内存硬限制的比例达到该值时进入，默认0.9，负数表示不检查
+	EmergencyHeapRatio float64
+	// 紧急模式：进程RSS占内存硬限制的比例达到该值时进入，默认0.95，负数表示不检查
+	EmergencyRSSRatio float64
+	// 退出紧急模式的回差，比例降到阈值减去该值以下时退出，默认0.1
+	EmergencyHysteresis float64
+	// 紧急模式期间重复强制GC的最小间隔，默认10秒
+	EmergencyCooldown time.Duration
+	// 进入、重复触发和退出紧急模式时的回调，可用于降级限流或清理缓存
+	OnEmergency func(EmergencyEvent)
+	// 调优器读取和修改的Go运行时，为空时使用当前进程的运行时；测试和模拟时替换
+	Runtime Runtime
 }
 
 // Tuner GC调优器
 type Tuner struct {
-	config       Config
-	mu           sync.Mutex
-	currentGOGC  int
-	lastGCTime   time.Time
-	memoryLimit  int64
-	enabled      bool
-	forceGCTimer *time.Timer
+	config Config
+	// 每次替换配置时递增，用于检测并发的配置修改
+	configVersion uint64
+	mu            sync.Mutex
+	currentGOGC   int
+	lastGCTime    time.Time
+	memoryLimit   atomic.Int64
+	limitSource   atomic.Value // LimitSource
+	state         lifecycleState
+	forceGCTimer  Timer
+	// 内存限制刷新协程的停止信号，未运行刷新协程时为nil
+	stopCh       chan struct{}
+	runtime      Runtime
+	notifier     *GCNotifier
+	ownsNotifier bool
+	unsubscribe  func()
+	gcCPU        *gcCPUTracker
+	softLimit    int64
+	strategy     Strategy
+	history      []GCSample
+	adjustments  map[adjustmentKey]uint64
+	// adjustmentLog 最近的调整记录
+	adjustmentLog *adjustmentRing
+	logger        *slog.Logger
+	// 人工干预：暂停调优或在TTL内固定GOGC
+	paused      bool
+	pinnedGOGC  int
+	pinnedUntil time.Time
+	pinTimer    Timer
+	// 启动前的GC设置，停止时恢复
+	original GCSettings
+	// 压舱石模式下的压舱石
+	ballast *Ballast
+	// 最近一次决策的内存预算
+	budget memoryBudget
+	// 进行中的后台调整，停止时等待其完成
+	wg sync.WaitGroup
+	// 紧急模式状态
+	emergency emergencyState
+}
+
+// lifecycleState 调优器生命周期状态
+type lifecycleState int
+
+const (
+	// 已创建或已停止，可以Start
+	stateStopped lifecycleState = iota
+	// 运行中
+	stateRunning
+	// 正在停止，等待进行中的调整完成
+	stateStopping
+)
+
+// adjustmentKey 按策略和原因统计调整次数
+type adjustmentKey struct {
+	Strategy string
+	Reason   string
 }
 
 // NewTuner 创建新的调优器
 func NewTuner(config Config) (*Tuner, error) {
-	// 应用默认值
-	if config.SafetyFactor <= 0 || config.SafetyFactor > 1 {
-		config.SafetyFactor = defaultSafetyFactor
-	}
+	config = applyDefaults(config)
 
-	if config.MinGOGC <= 0 {
-		config.MinGOGC = 25 // 最小值避免GC太频繁
+	memLimit, source, err := resolveMemoryLimit(config)
+	if err != nil {
+		return nil, err
 	}
 
-	if config.MaxGOGC <= 0 {
-		config.MaxGOGC = 500 // 最大值避免内存占用过高
+	rt := config.Runtime
+	if rt == nil {
+		rt = DefaultRuntime()
 	}
+	// 读取当前GOGC值，包括GOGC环境变量
+	settings := rt.GCSettings()
 
-	if !config.AllowPeakOverride {
-		config.PeakThreshold = 1.0
-	} else if config.PeakThreshold < 1.0 {
-		config.PeakThreshold = 1.5
+	tuner := &Tuner{
+		config:        config,
+		currentGOGC:   settings.GOGC,
+		lastGCTime:    rt.Now(),
+		runtime:       rt,
+		gcCPU:         newGCCPUTracker(rt.ReadStats()),
+		strategy:      config.Strategy,
+		adjustments:   make(map[adjustmentKey]uint64),
+		adjustmentLog: newAdjustmentRing(config.HistorySize),
+		logger:        newLogger(config),
+		original:      settings,
+		ballast:       NewBallast(0),
+	}
+	if tuner.strategy == nil {
+		tuner.strategy = StrategyForMode(config.Mode)
 	}
+	tuner.memoryLimit.Store(memLimit)
+	tuner.limitSource.Store(source)
+
+	tuner.logger.Info(logMsgInit,
+		slog.String(logKeyMode, string(config.Mode)),
+		slog.String(logKeyStrategy, tuner.strategy.Name()),
+		slog.Int64(logKeyMemoryLimit, memLimit),
+		slog.String(logKeySource, string(source)),
+		slog.Float64(logKeySafetyFactor, config.SafetyFactor),
+		slog.Int(logKeyGOGC, settings.GOGC),
+	)
 
-	memLimit := config.MemoryHardLimit
-	if memLimit == 0 {
-		// 真实场景中应该读取cgroup内存限制
-		// 这里使用环境变量模拟容器内存限制
-		if envLimit := os.Getenv("MEMORY_LIMIT_BYTES"); envLimit != "" {
-			if parsed, err := strconv.ParseInt(envLimit, 10, 64); err == nil {
-				memLimit = parsed
-			}
-		}
+	return tuner, nil
+}
 
-		// 如果没有设置环境变量，使用系统内存的一部分作为模拟值
-		if memLimit == 0 {
-			var memStats runtime.MemStats
-			runtime.ReadMemStats(&memStats)
-			memLimit = int64(float64(memStats.Sys) * 0.8) // 使用当前申请内存的80%作为限制
-		}
+// resolveMemoryLimit 确定内存限制：配置 > MEMORY_LIMIT_BYTES环境变量 > cgroup > 宿主机内存
+func resolveMemoryLimit(config Config) (int64, LimitSource, error) {
+	if config.MemoryHardLimit > 0 {
+		return config.MemoryHardLimit, LimitSourceConfig, nil
 	}
 
-	// 读取当前GOGC值
-	currentGOGC := 100 // 默认GOGC
-	if envGOGC := os.Getenv("GOGC"); envGOGC != "" {
-		if parsed, err := strconv.Atoi(envGOGC); err == nil {
-			currentGOGC = parsed
+	if envLimit := os.Getenv("MEMORY_LIMIT_BYTES"); envLimit != "" {
+		if parsed, err := strconv.ParseInt(envLimit, 10, 64); err == nil && parsed > 0 {
+			return parsed, LimitSourceEnv, nil
 		}
 	}
 
-	tuner := &Tuner{
-		config:      config,
-		currentGOGC: currentGOGC,
-		lastGCTime:  time.Now(),
-		memoryLimit: memLimit,
-		enabled:     true,
-	}
-
-	if config.DebugMode {
-		log.Printf("GOGCTuner初始化: 内存限制=%d字节, 安全系数=%.2f, 当前GOGC=%d",
-			memLimit, config.SafetyFactor, currentGOGC)
+	detector := MemoryLimitDetector{
+		CgroupRoot: config.CgroupRoot,
+		ProcRoot:   config.ProcRoot,
 	}
-
-	return tuner, nil
+	return detector.Detect()
 }
 
 // Start 启动调优循环
+// 已在运行或正在停止时不做任何操作；Stop之后可以再次Start
 func (t *Tuner) Start() {
-	if !t.enabled {
-		t.enabled = true
+	t.mu.Lock()
+	if t.state == stateStopped {
+		t.start()
 	}
+	t.mu.Unlock()
+
+	// 立即进行首次调整
+	t.adjustGOGC()
+}
+
+// start 记录启动前的GC设置并启动后台任务，调用方需持有t.mu
+func (t *Tuner) start() {
+	t.original = t.runtime.GCSettings()
+	t.currentGOGC = t.original.GOGC
+	t.softLimit = 0
+	t.lastGCTime = t.runtime.Now()
+	t.state = stateRunning
 
 	// 设置强制GC定时器
-	t.forceGCTimer = time.AfterFunc(forcedGCInterval, func() {
-		t.adjustGOGC()
-		runtime.GC()
-		t.forceGCTimer.Reset(forcedGCInterval)
-	})
+	t.forceGCTimer = t.runtime.AfterFunc(forcedGCInterval, t.forceGC)
 
-	// 设置finalizer观察GC事件
-	runtime.SetFinalizer(new(byte), func(_ *byte) {
+	// 订阅GC事件，每个GC周期结束后调整
+	t.notifier = t.config.GCNotifier
+	t.ownsNotifier = t.notifier == nil
+	if t.ownsNotifier {
+		t.notifier = NewGCNotifier()
+	}
+	t.unsubscribe = t.notifier.Subscribe(func(GCEvent) {
 		t.adjustGOGC()
-		// 重新设置finalizer保持监听
-		runtime.SetFinalizer(new(byte), func(_ *byte) {
-			t.adjustGOGC()
-		})
 	})
 
-	// 立即进行首次调整，但在goroutine中异步执行避免死锁
-	t.adjustGOGC()
+	t.syncLimitWatcher()
+}
+
+// forceGC 强制GC定时器的回调：调整后执行一次GC，仍在运行时重新设置定时器
+func (t *Tuner) forceGC() {
+	t.track(func() {
+		t.adjust()
+		t.runtime.GC()
+
+		// 定时器在持有t.mu时读取，Stop开始后不再重新设置
+		t.mu.Lock()
+		if t.state == stateRunning {
+			t.forceGCTimer.Reset(forcedGCInterval)
+		}
+		t.mu.Unlock()
+	})
 }
 
-// Stop 停止调优
+// Stop 停止调优，等待进行中的调整完成后恢复启动前的GOGC和软内存限制
+// 可以重复调用，未运行时不做任何操作
 func (t *Tuner) Stop() {
+	t.mu.Lock()
+	if t.state != stateRunning {
+		t.mu.Unlock()
+		return
+	}
+	t.state = stateStopping
+
+	forceGCTimer, pinTimer := t.forceGCTimer, t.pinTimer
+	stopCh, unsubscribe := t.stopCh, t.unsubscribe
+	notifier, ownsNotifier := t.notifier, t.ownsNotifier
+	t.pinTimer = nil
+	t.stopCh = nil
+	t.unsubscribe = nil
+	t.mu.Unlock()
+
+	// 先停止所有事件来源，再等待已开始的调整结束，等待期间不能持有t.mu
+	forceGCTimer.Stop()
+	if pinTimer != nil {
+		pinTimer.Stop()
+	}
+	if stopCh != nil {
+		close(stopCh)
+	}
+	unsubscribe()
+	if ownsNotifier {
+		notifier.Stop()
+	}
+	t.wg.Wait()
+
 	t.mu.Lock()
 	defer t.mu.Unlock()
 
-	if t.forceGCTimer != nil {
-		t.forceGCTimer.Stop()
+	t.pinnedUntil = time.Time{}
+	t.pinnedGOGC = 0
+	t.paused = false
+	t.emergency.active = false
+	t.ballast.Release()
+	if t.config.Coordinator != nil {
+		if err := t.config.Coordinator.leave(); err != nil {
+			t.logger.Warn(logMsgCoordination, slog.String(logKeyPath, t.config.Coordinator.Dir), slog.Any(logKeyError, err))
+		}
 	}
-	t.enabled = false
 
-	// 恢复默认GOGC
-	debug.SetGCPercent(100)
-	if t.config.DebugMode {
-		log.Println("GOGCTuner已停止，GOGC恢复为默认值100")
+	// 恢复启动前的GOGC和软内存限制
+	t.runtime.SetGCPercent(t.original.GOGC)
+	t.runtime.SetMemoryLimit(t.original.MemoryLimit)
+	t.currentGOGC = t.original.GOGC
+	t.softLimit = 0
+	t.state = stateStopped
+
+	t.logger.Info(logMsgStop,
+		slog.Int(logKeyGOGC, t.original.GOGC),
+		slog.Int64(logKeySoftLimit, t.original.MemoryLimit),
+	)
+}
+
+// track 在运行状态下执行后台任务fn，Stop会等待fn返回
+func (t *Tuner) track(fn func()) {
+	t.mu.Lock()
+	if t.state != stateRunning {
+		t.mu.Unlock()
+		return
 	}
+	t.wg.Add(1)
+	t.mu.Unlock()
+
+	defer t.wg.Done()
+	fn()
 }
 
 // GetCurrentGOGC 获取当前GOGC值
@@ -159,89 +344,207 @@ func (t *Tuner) GetCurrentGOGC() int {
 	return t.currentGOGC
 }
 
-// adjustGOGC 核心算法：根据当前内存占用调整GOGC
-func (t *Tuner) adjustGOGC() {
+// MemoryLimit 获取当前生效的内存限制(字节)
+func (t *Tuner) MemoryLimit() int64 {
+	return t.memoryLimit.Load()
+}
+
+// LimitSource 获取当前内存限制的来源
+func (t *Tuner) LimitSource() LimitSource {
+	source, _ := t.limitSource.Load().(LimitSource)
+	return source
+}
+
+// OnGC 注册每个GC周期完成后的回调，返回取消订阅函数
+// 需在Start之后调用
+func (t *Tuner) OnGC(fn func(GCEvent)) (unsubscribe func()) {
 	t.mu.Lock()
-	defer t.mu.Unlock()
+	notifier := t.notifier
+	t.mu.Unlock()
+	if notifier == nil {
+		return func() {}
+	}
+	return notifier.Subscribe(fn)
+}
+
+// adjustGOGC 在运行状态下进行一次调整，Stop会等待其完成
+func (t *Tuner) adjustGOGC() {
+	t.track(t.adjust)
+}
 
-	if !t.enabled {
+// adjust 核心算法：根据当前内存占用由策略决定GOGC和软内存限制
+// 读取运行时状态、cgroup和/proc文件以及同步协调注册表较慢，都在锁外进行，
+// 持有t.mu时只做决策和应用。只能在track中调用，保证Stop之后不再写入注册表
+func (t *Tuner) adjust() {
+	config := t.Config()
+	stats := t.runtime.ReadStats()
+	memoryLimit := t.memoryLimit.Load()
+	budget := t.computeBudget(config, stats, memoryLimit)
+	rss := readEmergencyRSS(config)
+
+	t.mu.Lock()
+	if t.state != stateRunning {
+		t.mu.Unlock()
 		return
 	}
 
 	// 记录GC间隔
-	now := time.Now()
+	now := t.runtime.Now()
 	gcInterval := now.Sub(t.lastGCTime)
 	t.lastGCTime = now
 
-	// 读取当前内存状态
-	var memStats runtime.MemStats
-	runtime.ReadMemStats(&memStats)
-
-	// 核心指标：存活对象占用内存
-	liveBytes := memStats.HeapAlloc
-
-	// 计算可用内存上限
-	safetyLimit := float64(t.memoryLimit) * t.config.SafetyFactor
-	peakLimit := float64(t.memoryLimit) * t.config.SafetyFactor * t.config.PeakThreshold
-
-	// 计算新的GOGC值
-	// GOGC = (可用内存 / 存活对象大小 - 1) * 100
-	var newGOGC int
-	if liveBytes > 0 {
-		if float64(liveBytes) > safetyLimit {
-			// 存活对象已超过安全限制，降低GOGC以更频繁GC
-			newGOGC = t.config.MinGOGC
-		} else if t.config.AllowPeakOverride && float64(liveBytes) < safetyLimit*0.5 {
-			// 存活对象远低于安全限制，可以适当提高GOGC
-			maxAvailableBytes := peakLimit
-			newGOGC = int((maxAvailableBytes/float64(liveBytes) - 1) * 100)
-		} else {
-			// 正常计算
-			maxAvailableBytes := safetyLimit
-			newGOGC = int((maxAvailableBytes/float64(liveBytes) - 1) * 100)
+	t.budget = budget
+	state := t.buildState(now, gcInterval, stats, memoryLimit)
+	event := t.checkEmergency(state, rss)
+	onEmergency := t.config.OnEmergency
+	if t.manualLocked() {
+		// 暂停或固定GOGC时仍检查紧急模式并强制回收，只是不由策略调整GOGC
+		t.recordSample(state)
+		t.mu.Unlock()
+		if event != nil {
+			t.handleEmergency(*event, onEmergency)
 		}
+		return
+	}
 
-		// 应用上下限
-		if newGOGC < t.config.MinGOGC {
-			newGOGC = t.config.MinGOGC
-		} else if newGOGC > t.config.MaxGOGC {
-			newGOGC = t.config.MaxGOGC
-		}
-	} else {
-		// 异常情况，使用默认值
-		newGOGC = 100
+	decision := t.strategy.Decide(state)
+	if t.emergency.active {
+		// 紧急模式下以最小GOGC尽快回收
+		decision.GOGC = t.config.MinGOGC
+		decision.Reason = reasonEmergency
+	}
+	t.recordSample(state)
+	t.applyDecision(state, decision)
+	t.syncBallast()
+	t.mu.Unlock()
+
+	// 强制GC和回调可能耗时较长，在锁外执行
+	if event != nil {
+		t.handleEmergency(*event, onEmergency)
+	}
+}
+
+// buildState 由锁外读取的内存状态和t.budget构造策略输入，调用方需持有t.mu
+// 存活堆为最近一次GC标记后的结果，不包含尚未回收的垃圾
+func (t *Tuner) buildState(now time.Time, gcInterval time.Duration, stats RuntimeStats, memoryLimit int64) State {
+	return State{
+		Time:                 now,
+		LiveBytes:            stats.LiveBytes,
+		HeapGoalBytes:        stats.GoalBytes,
+		StackBytes:           stats.StackBytes,
+		GlobalBytes:          stats.GlobalBytes,
+		MemoryLimit:          memoryLimit,
+		SafetyLimit:          t.budget.SafetyLimit,
+		RuntimeOverheadBytes: t.budget.RuntimeOverheadBytes,
+		ExternalBytes:        t.budget.ExternalBytes,
+		CurrentGOGC:          t.currentGOGC,
+		CurrentMemoryLimit:   t.softLimit,
+		GCCPUFraction:        t.gcCPU.Fraction(stats),
+		GCInterval:           gcInterval,
+		History:              append([]GCSample(nil), t.history...),
+		Config:               t.config,
 	}
+}
+
+// recordSample 记录GC采样供策略参考，只保留最近maxGCHistory条
+func (t *Tuner) recordSample(state State) {
+	if len(t.history) == maxGCHistory {
+		copy(t.history, t.history[1:])
+		t.history = t.history[:maxGCHistory-1]
+	}
+	t.history = append(t.history, GCSample{
+		Time:          state.Time,
+		LiveBytes:     state.LiveBytes,
+		GOGC:          state.CurrentGOGC,
+		GCCPUFraction: state.GCCPUFraction,
+		Interval:      state.GCInterval,
+	})
+}
 
-	// 仅当变化超过10%时才更新
-	if newGOGC != t.currentGOGC && (float64(newGOGC-t.currentGOGC)/float64(t.currentGOGC) > 0.1 ||
-		float64(t.currentGOGC-newGOGC)/float64(t.currentGOGC) > 0.1) {
-		t.currentGOGC = newGOGC
-		debug.SetGCPercent(newGOGC)
+// applyDecision 应用策略决策，调用方需持有t.mu
+func (t *Tuner) applyDecision(state State, decision Decision) {
+	newGOGC := clampGOGC(decision.GOGC, t.config)
+	if decision.MemoryLimit == t.softLimit && newGOGC == t.currentGOGC {
+		t.logger.Debug(logMsgDecision,
+			slog.Int(logKeyGOGC, newGOGC),
+			slog.Uint64(logKeyLiveBytes, state.LiveBytes),
+			slog.Float64(logKeyGCCPUFraction, state.GCCPUFraction),
+			slog.String(logKeyStrategy, t.strategy.Name()),
+			slog.String(logKeyReason, decision.Reason),
+		)
+		return
+	}
+	t.adjustments[adjustmentKey{Strategy: t.strategy.Name(), Reason: decision.Reason}]++
+	t.adjustmentLog.add(Adjustment{
+		Time:               state.Time,
+		OldGOGC:            t.currentGOGC,
+		NewGOGC:            newGOGC,
+		OldSoftMemoryLimit: t.softLimit,
+		NewSoftMemoryLimit: decision.MemoryLimit,
+		LiveBytes:          state.LiveBytes,
+		MemoryLimit:        state.MemoryLimit,
+		Strategy:           t.strategy.Name(),
+		Reason:             decision.Reason,
+		GCInterval:         state.GCInterval,
+	})
 
-		if t.config.DebugMode {
-			memUsageRatio := float64(liveBytes) / float64(t.memoryLimit)
-			log.Printf("GOGCTuner: 调整GOGC=%d, 存活对象=%dMB, 内存限制=%dMB, 占比=%.2f%%, GC间隔=%v",
-				newGOGC, liveBytes>>20, t.memoryLimit>>20, memUsageRatio*100, gcInterval)
+	if decision.MemoryLimit != t.softLimit {
+		if decision.MemoryLimit > 0 {
+			t.runtime.SetMemoryLimit(decision.MemoryLimit)
+		} else {
+			// 恢复启动前的软内存限制，保留通过GOMEMLIMIT等方式设置的值
+			t.runtime.SetMemoryLimit(t.original.MemoryLimit)
 		}
+		t.softLimit = decision.MemoryLimit
+
+		t.logger.Info(logMsgSoftLimit,
+			slog.Int64(logKeySoftLimit, decision.MemoryLimit),
+			slog.Int64(logKeyMemoryLimit, state.MemoryLimit),
+			slog.Float64(logKeyGCCPUFraction, state.GCCPUFraction),
+			slog.String(logKeyStrategy, t.strategy.Name()),
+			slog.String(logKeyReason, decision.Reason),
+		)
+	}
+
+	if newGOGC == t.currentGOGC {
+		return
 	}
+	oldGOGC := t.currentGOGC
+	t.currentGOGC = newGOGC
+	t.runtime.SetGCPercent(newGOGC)
+
+	t.logger.Info(logMsgAdjust,
+		slog.Int(logKeyGOGC, newGOGC),
+		slog.Int(logKeyOldGOGC, oldGOGC),
+		slog.Uint64(logKeyLiveBytes, state.LiveBytes),
+		slog.Int64(logKeyMemoryLimit, state.MemoryLimit),
+		slog.Float64(logKeyUsageRatio, float64(state.LiveBytes)/float64(state.MemoryLimit)),
+		slog.Duration(logKeyGCInterval, state.GCInterval),
+		slog.String(logKeyStrategy, t.strategy.Name()),
+		slog.String(logKeyReason, decision.Reason),
+	)
 }
 
 // GetMetrics 获取调优器指标（用于监控）
+//
+// Deprecated: 使用类型化的Snapshot
 func (t *Tuner) GetMetrics() map[string]interface{} {
-	t.mu.Lock()
-	defer t.mu.Unlock()
-
-	var memStats runtime.MemStats
-	runtime.ReadMemStats(&memStats)
+	snapshot := t.Snapshot()
 
 	return map[string]interface{}{
-		"current_gogc":       t.currentGOGC,
-		"memory_limit_bytes": t.memoryLimit,
-		"heap_alloc_bytes":   memStats.HeapAlloc,
-		"heap_objects":       memStats.HeapObjects,
-		"gc_cycles":          memStats.NumGC,
-		"memory_usage_ratio": float64(memStats.HeapAlloc) / float64(t.memoryLimit),
-		"safety_factor":      t.config.SafetyFactor,
-		"tuner_enabled":      t.enabled,
+		"current_gogc":        snapshot.GOGC,
+		"memory_limit_bytes":  snapshot.MemoryLimit,
+		"memory_limit_source": string(snapshot.LimitSource),
+		"heap_alloc_bytes":    snapshot.HeapAllocBytes,
+		"heap_live_bytes":     snapshot.HeapLiveBytes,
+		"heap_goal_bytes":     snapshot.HeapGoalBytes,
+		"heap_objects":        snapshot.HeapObjects,
+		"gc_cycles":           snapshot.GCCycles,
+		"memory_usage_ratio":  snapshot.MemoryUsageRatio,
+		"safety_factor":       snapshot.SafetyFactor,
+		"tuning_mode":         string(snapshot.Mode),
+		"strategy":            snapshot.Strategy,
+		"soft_limit_bytes":    snapshot.SoftMemoryLimit,
+		"tuner_enabled":       snapshot.Enabled,
 	}
 }
@@ -0,0 +1,646 @@
+package gogctuner
+
+import (
+	"math"
+	"math/rand"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+)
+
+// 模拟使用的内存限制：2000MB * 0.5 = 1000MB安全限制，便于手算GOGC
+const (
+	simMemoryLimit  = 2000 * mb
+	simSafetyFactor = 0.5
+)
+
+func simConfig() Config {
+	return Config{
+		MemoryHardLimit: simMemoryLimit,
+		SafetyFactor:    simSafetyFactor,
+	}
+}
+
+func TestHeapTargetTrajectory(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+
+	// GOGC = (安全限制 - 存活堆) / 存活堆 * 100，限制在[25, 500]
+	got := sim.replay(liveTrace(100, 200, 250, 260, 400, 1000, 1200, 100))
+	assertTrajectory(t, got, []int{500, 400, 300, 300, 150, 25, 25, 500})
+
+	adjustments := sim.tuner.Adjustments()
+	if last := adjustments[len(adjustments)-1]; last.Reason != "heap_target" || last.OldGOGC != 25 {
+		t.Fatalf("最后一次调整 = %+v", last)
+	}
+	var overLimit bool
+	for _, a := range adjustments {
+		overLimit = overLimit || a.Reason == "over_safety_limit"
+	}
+	if overLimit {
+		// 1000MB时已降到MinGOGC，1200MB时GOGC不变，不应产生调整记录
+		t.Fatalf("不应记录over_safety_limit调整: %+v", adjustments)
+	}
+}
+
+func TestScannableRootsLowerGOGC(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+
+	// 堆目标 = 存活堆 + (存活堆 + 栈 + 全局变量) * GOGC / 100
+	got := sim.step(traceStep{Live: 200 * mb, Stack: 50 * mb, Globals: 50 * mb})
+	if got != 266 {
+		t.Fatalf("GOGC = %d, want 266", got)
+	}
+}
+
+func TestHysteresis(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+
+	// 变化比例不超过10%时保持不变：284(-5.3%)、316(+5.3%)、270(-10%)都不调整
+	got := sim.replay(liveTrace(250, 260, 240, 250, 270, 275))
+	assertTrajectory(t, got, []int{300, 300, 300, 300, 300, 263})
+
+	if n := len(sim.tuner.Adjustments()); n != 2 {
+		t.Fatalf("调整次数 = %d, want 2: %+v", n, sim.tuner.Adjustments())
+	}
+}
+
+func TestHysteresisThreshold(t *testing.T) {
+	config := simConfig()
+	config.Strategy = HeapTargetStrategy{Hysteresis: 0.01}
+	sim := newSimulation(t, config)
+
+	got := sim.replay(liveTrace(250, 260, 240))
+	assertTrajectory(t, got, []int{300, 284, 316})
+}
+
+func TestGOGCBounds(t *testing.T) {
+	config := simConfig()
+	config.MinGOGC = 50
+	config.MaxGOGC = 200
+	sim := newSimulation(t, config)
+
+	rng := rand.New(rand.NewSource(1))
+	trace := make([]traceStep, 500)
+	for i := range trace {
+		trace[i] = traceStep{
+			Live:  uint64(rng.Int63n(1500*mb)) + 1,
+			Stack: uint64(rng.Int63n(10 * mb)),
+		}
+	}
+	for i, gogc := range sim.replay(trace) {
+		if gogc < config.MinGOGC || gogc > config.MaxGOGC {
+			t.Fatalf("第%d步 存活堆=%dMB GOGC=%d 超出[%d, %d]",
+				i, trace[i].Live/mb, gogc, config.MinGOGC, config.MaxGOGC)
+		}
+	}
+
+	assertTrajectory(t, sim.replay(liveTrace(1, 1500)), []int{200, 50})
+}
+
+func TestPeakOverride(t *testing.T) {
+	trace := liveTrace(200, 400, 600, 1200)
+
+	tests := []struct {
+		name     string
+		allow    bool
+		want     []int
+		wantWhy  string
+		peakMult float64
+	}{
+		{
+			name:    "disabled",
+			want:    []int{400, 150, 66, 25},
+			wantWhy: "heap_target",
+		},
+		{
+			// 存活堆低于安全限制一半时以1.5倍安全限制为目标
+			name:     "enabled",
+			allow:    true,
+			peakMult: 1.5,
+			want:     []int{650, 275, 66, 25},
+			wantWhy:  "peak_override",
+		},
+		{
+			// 未设置倍数时默认1.5
+			name:    "default threshold",
+			allow:   true,
+			want:    []int{650, 275, 66, 25},
+			wantWhy: "peak_override",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			config := simConfig()
+			config.MaxGOGC = 1000
+			config.AllowPeakOverride = tt.allow
+			config.PeakThreshold = tt.peakMult
+			sim := newSimulation(t, config)
+
+			assertTrajectory(t, sim.replay(trace), tt.want)
+			if why := sim.tuner.Adjustments()[0].Reason; why != tt.wantWhy {
+				t.Fatalf("首次调整原因 = %s, want %s", why, tt.wantWhy)
+			}
+		})
+	}
+}
+
+func TestStepStrategyTrajectory(t *testing.T) {
+	config := simConfig()
+	config.Strategy = StepStrategy{}
+	sim := newSimulation(t, config)
+
+	got := sim.replay(liveTrace(200, 400, 600, 800, 950, 100))
+	assertTrajectory(t, got, []int{400, 200, 100, 50, 25, 400})
+}
+
+func TestGCCPUTrajectory(t *testing.T) {
+	config := simConfig()
+	config.Mode = ModeGCCPU
+	sim := newSimulation(t, config)
+
+	// 目标5%：占比20%时GOGC翻倍，1%时减半，并受安全限制对应的GOGC(500)约束
+	got := sim.replay([]traceStep{
+		{Live: 100 * mb, GCCPU: 0.2},
+		{Live: 100 * mb, GCCPU: 0.2},
+		{Live: 100 * mb, GCCPU: 0.2},
+		{Live: 100 * mb, GCCPU: 0.01},
+		{Live: 100 * mb, GCCPU: 0.05},
+	})
+	assertTrajectory(t, got, []int{200, 400, 500, 250, 250})
+}
+
+func TestMemoryLimitTrajectory(t *testing.T) {
+	config := simConfig()
+	config.Mode = ModeMemoryLimit
+	config.LimitModeGOGC = GOGCOff
+	sim := newSimulation(t, config)
+
+	// 基准为安全限制1000MB，上限为硬限制2000MB，每步250MB
+	if got := sim.softLimit(); got != 1000*mb {
+		t.Fatalf("初始软限制 = %dMB, want 1000MB", got/mb)
+	}
+
+	steps := []struct {
+		gcCPU float64
+		want  int64
+	}{
+		{0.3, 1250},
+		{0.3, 1500},
+		{0.2, 1500},
+		{0.3, 1750},
+		{0.3, 2000},
+		{0.3, 2000},
+		{0.05, 1750},
+		{0.05, 1500},
+		{0.01, 1250},
+		{0.01, 1000},
+		{0.01, 1000},
+	}
+	for i, step := range steps {
+		gogc := sim.step(traceStep{Live: 900 * mb, GCCPU: step.gcCPU})
+		if gogc != GOGCOff {
+			t.Fatalf("第%d步 GOGC = %d, want GOGCOff", i, gogc)
+		}
+		if got := sim.softLimit(); got != step.want*mb {
+			t.Fatalf("第%d步 软限制 = %dMB, want %dMB", i, got/mb, step.want)
+		}
+	}
+}
+
+func TestStopRestoresRuntimeSettings(t *testing.T) {
+	config := simConfig()
+	config.Mode = ModeMemoryLimit
+	sim := newSimulation(t, config)
+
+	sim.tuner.Stop()
+	original := GCSettings{GOGC: 150, MemoryLimit: 3000 * mb}
+	sim.rt.settings = original
+
+	for i := 0; i < 2; i++ {
+		sim.tuner.Start()
+		sim.replay(liveTrace(100, 400))
+		if sim.rt.GCSettings() == original {
+			t.Fatalf("第%d次运行 调优器没有修改运行时设置", i)
+		}
+
+		sim.tuner.Stop()
+		if got := sim.rt.GCSettings(); got != original {
+			t.Fatalf("第%d次停止后 运行时设置 = %+v, want %+v", i, got, original)
+		}
+		if got := sim.tuner.GetCurrentGOGC(); got != original.GOGC {
+			t.Fatalf("第%d次停止后 GOGC = %d, want %d", i, got, original.GOGC)
+		}
+	}
+
+	// 停止后的调整不生效
+	sim.tuner.adjustGOGC()
+	if got := sim.rt.GCSettings(); got != original {
+		t.Fatalf("停止后调整修改了运行时设置: %+v", got)
+	}
+}
+
+func TestModeSwitchRestoresSoftLimit(t *testing.T) {
+	config := simConfig()
+	config.Mode = ModeMemoryLimit
+	sim := newSimulation(t, config)
+	sim.tuner.Stop()
+	// 启动前通过GOMEMLIMIT设置了软限制
+	sim.rt.settings.MemoryLimit = 3000 * mb
+	sim.tuner.Start()
+
+	sim.replay(liveTrace(100))
+	if got := sim.softLimit(); got != 1000*mb {
+		t.Fatalf("混合模式 软限制 = %dMB, want 1000MB", got/mb)
+	}
+
+	mode := ModeGOGC
+	if err := sim.tuner.ApplyPatch(ConfigPatch{Mode: &mode}); err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+	if got := sim.softLimit(); got != 3000*mb {
+		t.Fatalf("切换模式后 软限制 = %dMB, want 启动前的3000MB", got/mb)
+	}
+}
+
+func TestPauseAndPin(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+
+	assertTrajectory(t, sim.replay(liveTrace(200)), []int{400})
+
+	sim.tuner.Pause()
+	assertTrajectory(t, sim.replay(liveTrace(400, 600)), []int{400, 400})
+	sim.tuner.Resume()
+	if got := sim.tuner.GetCurrentGOGC(); got != 66 {
+		t.Fatalf("恢复后 GOGC = %d, want 66", got)
+	}
+
+	if err := sim.tuner.Pin(300, time.Hour); err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+	// 固定的GOGC不受MinGOGC/MaxGOGC和策略约束
+	assertTrajectory(t, sim.replay(liveTrace(900, 100)), []int{300, 300})
+	sim.tuner.Unpin()
+	if got := sim.tuner.GetCurrentGOGC(); got != 500 {
+		t.Fatalf("解除固定后 GOGC = %d, want 500", got)
+	}
+
+	for _, tc := range []struct {
+		gogc int
+		ttl  time.Duration
+	}{
+		{0, time.Minute},
+		{-2, time.Minute},
+		{100, 0},
+		{100, 25 * time.Hour},
+	} {
+		if err := sim.tuner.Pin(tc.gogc, tc.ttl); err == nil {
+			t.Fatalf("Pin(%d, %s) 应返回错误", tc.gogc, tc.ttl)
+		}
+	}
+}
+
+func TestForcedGC(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+	assertTrajectory(t, sim.replay(liveTrace(250)), []int{300})
+
+	// 长时间没有GC事件时，强制GC定时器先按当前存活堆调整再执行GC
+	sim.rt.mu.Lock()
+	sim.rt.stats.LiveBytes = 100 * mb
+	sim.rt.mu.Unlock()
+	sim.rt.advance(forcedGCInterval)
+	if got := sim.tuner.GetCurrentGOGC(); got != 500 {
+		t.Fatalf("强制GC后 GOGC = %d, want 500", got)
+	}
+	// 定时器每次触发后重新设置
+	sim.rt.advance(forcedGCInterval + time.Minute)
+	if sim.rt.gcCount != 2 {
+		t.Fatalf("强制GC次数 = %d, want 2", sim.rt.gcCount)
+	}
+
+	// 停止后不再强制GC
+	sim.tuner.Stop()
+	sim.rt.advance(10 * forcedGCInterval)
+	if sim.rt.gcCount != 2 || len(sim.rt.timers) != 0 {
+		t.Fatalf("停止后 强制GC次数 = %d, 定时器 = %d", sim.rt.gcCount, len(sim.rt.timers))
+	}
+}
+
+func TestPinExpiry(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+	assertTrajectory(t, sim.replay(liveTrace(250)), []int{300})
+
+	if err := sim.tuner.Pin(80, 10*time.Minute); err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+	sim.rt.advance(10*time.Minute - time.Second)
+	if got := sim.tuner.GetCurrentGOGC(); got != 80 {
+		t.Fatalf("到期前 GOGC = %d, want 80", got)
+	}
+	// 到期后自动解除并立即重新调整
+	sim.rt.advance(time.Second)
+	if got := sim.tuner.GetCurrentGOGC(); got != 300 {
+		t.Fatalf("到期后 GOGC = %d, want 300", got)
+	}
+	if snapshot := sim.tuner.Snapshot(); snapshot.PinnedUntil != nil {
+		t.Fatalf("到期后 PinnedUntil = %v", snapshot.PinnedUntil)
+	}
+
+	// 重新固定时按新的时长计时，旧的定时器不会提前解除
+	sim.tuner.Pin(50, 10*time.Minute)
+	sim.rt.advance(5 * time.Minute)
+	sim.tuner.Pin(60, 10*time.Minute)
+	sim.rt.advance(6 * time.Minute)
+	if got := sim.tuner.GetCurrentGOGC(); got != 60 {
+		t.Fatalf("重新固定后 GOGC = %d, want 60", got)
+	}
+	sim.rt.advance(4 * time.Minute)
+	if got := sim.tuner.GetCurrentGOGC(); got != 300 {
+		t.Fatalf("重新固定到期后 GOGC = %d, want 300", got)
+	}
+}
+
+func TestEmergency(t *testing.T) {
+	var events []EmergencyEvent
+	config := simConfig()
+	// 存活堆达到800MB进入紧急模式，降到600MB以下退出
+	config.EmergencyHeapRatio = 0.4
+	config.OnEmergency = func(e EmergencyEvent) { events = append(events, e) }
+	sim := newSimulation(t, config)
+
+	got := sim.replay([]traceStep{
+		{Live: 900 * mb},
+		// 仍高于退出阈值，冷却时间内不重复触发
+		{Live: 700 * mb},
+		{Live: 900 * mb},
+		// 超过冷却时间后再次触发
+		{Live: 900 * mb, Interval: 11 * time.Second},
+		{Live: 500 * mb},
+	})
+	assertTrajectory(t, got, []int{25, 25, 25, 25, 100})
+
+	if len(events) != 3 {
+		t.Fatalf("紧急事件 = %+v, want 3个", events)
+	}
+	if !events[0].Active || events[0].Repeat || events[0].Trigger != EmergencyTriggerHeap {
+		t.Fatalf("进入事件 = %+v", events[0])
+	}
+	if !events[1].Active || !events[1].Repeat {
+		t.Fatalf("重复事件 = %+v", events[1])
+	}
+	if events[2].Active || events[2].Duration != 14*time.Second {
+		t.Fatalf("退出事件 = %+v", events[2])
+	}
+	if sim.rt.freeOSMemory != 2 {
+		t.Fatalf("FreeOSMemory调用次数 = %d, want 2", sim.rt.freeOSMemory)
+	}
+
+	snapshot := sim.tuner.Snapshot()
+	if snapshot.Emergency || snapshot.EmergencyCount != 2 {
+		t.Fatalf("快照 Emergency=%v EmergencyCount=%d", snapshot.Emergency, snapshot.EmergencyCount)
+	}
+	if n := len(sim.tuner.EmergencyEvents()); n != 3 {
+		t.Fatalf("EmergencyEvents = %d, want 3", n)
+	}
+}
+
+func TestEmergencyWhileManual(t *testing.T) {
+	tests := []struct {
+		name   string
+		manual func(*Tuner)
+		want   int
+	}{
+		{"paused", func(tuner *Tuner) { tuner.Pause() }, 300},
+		{"pinned", func(tuner *Tuner) { tuner.Pin(200, time.Hour) }, 200},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var events []EmergencyEvent
+			config := simConfig()
+			config.EmergencyHeapRatio = 0.4
+			config.OnEmergency = func(e EmergencyEvent) { events = append(events, e) }
+			sim := newSimulation(t, config)
+			sim.step(traceStep{Live: 250 * mb})
+			tt.manual(sim.tuner)
+
+			// 人工设置的GOGC保持不变，但紧急模式照常进入和退出
+			got := sim.replay(liveTrace(900, 500))
+			assertTrajectory(t, got, []int{tt.want, tt.want})
+			if len(events) != 2 || !events[0].Active || events[1].Active {
+				t.Fatalf("紧急事件 = %+v", events)
+			}
+			if sim.rt.freeOSMemory != 1 {
+				t.Fatalf("FreeOSMemory调用次数 = %d, want 1", sim.rt.freeOSMemory)
+			}
+		})
+	}
+}
+
+func TestEmergencyRSS(t *testing.T) {
+	detector := fakeRoot(t, map[string]string{
+		"proc/self/status": "Name:\tapp\nVmRSS:\t 1843200 kB\n",
+	})
+	var events []EmergencyEvent
+	config := simConfig()
+	config.ProcRoot = detector.ProcRoot
+	config.EmergencyRSSRatio = 0.8
+	config.OnEmergency = func(e EmergencyEvent) { events = append(events, e) }
+	sim := newSimulation(t, config)
+
+	// RSS 1800MB超过2000MB的80%，存活堆未超过阈值
+	if got := sim.step(traceStep{Live: 200 * mb}); got != 25 {
+		t.Fatalf("GOGC = %d, want 25", got)
+	}
+	if len(events) != 1 || events[0].Trigger != EmergencyTriggerRSS || events[0].RSSBytes != 1800*mb {
+		t.Fatalf("紧急事件 = %+v", events)
+	}
+
+	// RSS降到退出阈值以下后退出
+	writeFile(t, filepath.Join(detector.ProcRoot, "self", "status"), "VmRSS:\t 1024000 kB\n")
+	if got := sim.step(traceStep{Live: 200 * mb}); got != 400 {
+		t.Fatalf("退出后 GOGC = %d, want 400", got)
+	}
+	if len(events) != 2 || events[1].Active {
+		t.Fatalf("紧急事件 = %+v", events)
+	}
+}
+
+func TestUpdateConfig(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+	assertTrajectory(t, sim.replay(liveTrace(1200)), []int{25})
+
+	config := sim.tuner.Config()
+	config.MinGOGC = 50
+	if err := sim.tuner.UpdateConfig(config); err != nil {
+		t.Fatalf("UpdateConfig: %v", err)
+	}
+	if got := sim.tuner.GetCurrentGOGC(); got != 50 {
+		t.Fatalf("更新后 GOGC = %d, want 50", got)
+	}
+
+	config.MinGOGC = 600
+	if err := sim.tuner.UpdateConfig(config); err == nil {
+		t.Fatal("MinGOGC大于MaxGOGC时应返回错误")
+	}
+	if got := sim.tuner.Config().MinGOGC; got != 50 {
+		t.Fatalf("非法配置不应生效, MinGOGC = %d", got)
+	}
+}
+
+func TestGCCPUFraction(t *testing.T) {
+	tracker := newGCCPUTracker(RuntimeStats{GCCPUSeconds: 1, TotalCPUSeconds: 10})
+
+	if got := tracker.Fraction(RuntimeStats{GCCPUSeconds: 1, TotalCPUSeconds: 10}); got != 0 {
+		t.Fatalf("没有新采样时 = %v, want 0", got)
+	}
+	if got := tracker.Fraction(RuntimeStats{GCCPUSeconds: 2, TotalCPUSeconds: 14}); got != 0.25 {
+		t.Fatalf("Fraction = %v, want 0.25", got)
+	}
+	if got := tracker.Fraction(RuntimeStats{GCCPUSeconds: 2, TotalCPUSeconds: 18}); got != 0 {
+		t.Fatalf("没有GC时 = %v, want 0", got)
+	}
+}
+
+func TestRuntimeStatsOverhead(t *testing.T) {
+	stats := RuntimeStats{
+		HeapObjectBytes: 100 * mb,
+		UnusedBytes:     10 * mb,
+		FreeBytes:       20 * mb,
+		TotalBytes:      200 * mb,
+		ReleasedBytes:   40 * mb,
+	}
+	if got := stats.MappedBytes(); got != 160*mb {
+		t.Fatalf("MappedBytes = %dMB, want 160MB", got/mb)
+	}
+	if got := stats.RuntimeOverheadBytes(); got != 30*mb {
+		t.Fatalf("RuntimeOverheadBytes = %dMB, want 30MB", got/mb)
+	}
+
+	if got := (RuntimeStats{ReleasedBytes: 1}).MappedBytes(); got != 0 {
+		t.Fatalf("异常数据时 MappedBytes = %d, want 0", got)
+	}
+}
+
+func TestDefaultRuntimeSettings(t *testing.T) {
+	settings := DefaultRuntime().GCSettings()
+	if settings.GOGC == 0 || settings.MemoryLimit <= 0 {
+		t.Fatalf("GCSettings = %+v", settings)
+	}
+	if settings.MemoryLimit != math.MaxInt64 && settings.MemoryLimit < mb {
+		t.Fatalf("GCSettings = %+v", settings)
+	}
+}
+
+func TestStartStopIdempotent(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+	sim.step(traceStep{Live: 250 * mb})
+
+	// 运行中再次Start不重新记录启动前的设置
+	sim.tuner.Start()
+	if got := sim.tuner.original.GOGC; got != 100 {
+		t.Fatalf("再次Start后 启动前GOGC = %d, want 100", got)
+	}
+	if got := sim.tuner.GetCurrentGOGC(); got != 300 {
+		t.Fatalf("再次Start后 GOGC = %d, want 300", got)
+	}
+
+	sim.tuner.Stop()
+	sim.tuner.Stop()
+	if got := sim.rt.GCSettings().GOGC; got != 100 {
+		t.Fatalf("停止后 GOGC = %d, want 100", got)
+	}
+	// 停止后GC事件不再触发调整
+	sim.step(traceStep{Live: 100 * mb})
+	if n := len(sim.tuner.Adjustments()); n != 1 {
+		t.Fatalf("调整次数 = %d, want 1", n)
+	}
+}
+
+func TestStopWaitsForAdjustment(t *testing.T) {
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	config := simConfig()
+	config.EmergencyHeapRatio = 0.4
+	config.OnEmergency = func(EmergencyEvent) {
+		close(entered)
+		<-release
+	}
+	sim := newSimulation(t, config)
+
+	// 紧急回调在GC事件触发的调整中执行，阻塞到release关闭
+	sim.rt.mu.Lock()
+	sim.rt.stats.LiveBytes = 900 * mb
+	sim.rt.mu.Unlock()
+	go sim.notifier.Notify(GCEvent{Cycles: 1})
+	<-entered
+
+	stopped := make(chan struct{})
+	go func() {
+		sim.tuner.Stop()
+		close(stopped)
+	}()
+	select {
+	case <-stopped:
+		t.Fatal("Stop没有等待进行中的调整")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	close(release)
+	<-stopped
+	if got := sim.rt.GCSettings().GOGC; got != 100 {
+		t.Fatalf("停止后 GOGC = %d, want 100", got)
+	}
+}
+
+func TestConcurrentStartStop(t *testing.T) {
+	sim := newSimulation(t, simConfig())
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			for j := 0; j < 50; j++ {
+				if (i+j)%2 == 0 {
+					sim.tuner.Start()
+				} else {
+					sim.tuner.Stop()
+				}
+				sim.notifier.Notify(GCEvent{Cycles: uint64(j)})
+			}
+		}(i)
+	}
+	wg.Wait()
+
+	sim.tuner.Stop()
+	if got := sim.rt.GCSettings().GOGC; got != 100 {
+		t.Fatalf("停止后 GOGC = %d, want 100", got)
+	}
+}
+
+func TestReadStatsWithoutLock(t *testing.T) {
+	config := simConfig()
+	config.EmergencyHeapRatio = 0.9
+	sim := newSimulation(t, config)
+	// 读取运行时指标可能较慢，不能在持有t.mu时进行
+	sim.rt.onReadStats = func() {
+		if !sim.tuner.mu.TryLock() {
+			t.Error("持有t.mu时读取了运行时指标")
+			return
+		}
+		sim.tuner.mu.Unlock()
+	}
+
+	sim.replay(liveTrace(200, 1900))
+	if err := sim.tuner.Pin(300, time.Hour); err != nil {
+		t.Fatalf("Pin: %v", err)
+	}
+	sim.replay(liveTrace(200))
+	sim.tuner.Unpin()
+	sim.tuner.Pause()
+	sim.tuner.Resume()
+	sim.tuner.Snapshot()
+	if err := sim.tuner.ApplyPatch(ConfigPatch{MaxGOGC: ptr(400)}); err != nil {
+		t.Fatalf("ApplyPatch: %v", err)
+	}
+}
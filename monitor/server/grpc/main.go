@@ -6,6 +6,8 @@ import (
 
 	"github.com/xyzbit/go-tuning-practice/monitor/middleware"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/health"
+	"google.golang.org/grpc/health/grpc_health_v1"
 )
 
 func main() {
@@ -29,6 +31,8 @@ func main() {
 
 	// 注册你的 gRPC 服务
 	pb.RegisterYourServiceServer(server, &YourService{})
+	// 注册健康检查服务，HTTP服务的 /rpc 接口通过它检查gRPC服务是否可用
+	grpc_health_v1.RegisterHealthServer(server, health.NewServer())
 
 	// 启动服务器
 	lis, err := net.Listen("tcp", ":50051")
@@ -2,11 +2,17 @@ package main
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
 	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
 	"time"
 
+	"github.com/xyzbit/go-tuning-practice/gogctuner"
 	"github.com/xyzbit/go-tuning-practice/monitor/middleware"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	"go.opentelemetry.io/otel"
@@ -16,6 +22,8 @@ import (
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	semconv "go.opentelemetry.io/otel/semconv/v1.12.0"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/health/grpc_health_v1"
 	"mosn.io/holmes"
 )
 
@@ -103,19 +111,39 @@ func leak(wr http.ResponseWriter, req *http.Request) {
 	go producer()
 }
 
-// 请求rpc
-func requestRpc() {
-	conn, err := grpc.Dial("localhost:50051", grpc.WithInsecure())
+// 请求rpc：调用gRPC服务(monitor/server/grpc)注册的健康检查服务
+func requestRpc(ctx context.Context) (grpc_health_v1.HealthCheckResponse_ServingStatus, error) {
+	conn, err := grpc.NewClient("localhost:50051", grpc.WithTransportCredentials(insecure.NewCredentials()))
 	if err != nil {
-		log.Fatalf("did not connect: %v", err)
+		return grpc_health_v1.HealthCheckResponse_UNKNOWN, err
 	}
 	defer conn.Close()
 
-	client := pb.NewYourServiceClient(conn)
+	client := grpc_health_v1.NewHealthClient(conn)
 
 	// 调用 RPC 方法
-	response, err := client.YourMethod(context.Background(), &pb.YourRequest{
-	
+	ctx, cancel := context.WithTimeout(ctx, time.Second)
+	defer cancel()
+	response, err := client.Check(ctx, &grpc_health_v1.HealthCheckRequest{})
+	if err != nil {
+		return grpc_health_v1.HealthCheckResponse_UNKNOWN, err
+	}
+	return response.GetStatus(), nil
+}
+
+// 模拟一个调用下游gRPC服务的业务逻辑
+func rpcHandler(w http.ResponseWriter, r *http.Request) {
+	ctx, span := otel.Tracer("my-service").Start(r.Context(), "rpcHandler")
+	defer span.End()
+
+	status, err := requestRpc(ctx)
+	if err != nil {
+		span.SetAttributes(attribute.String("result", "failed"))
+		http.Error(w, fmt.Sprintf("rpc failed: %v", err), http.StatusBadGateway)
+		return
+	}
+	span.SetAttributes(attribute.String("result", "completed"))
+	fmt.Fprintf(w, "rpc status: %s", status)
 }
 
 func main() {
@@ -156,11 +184,65 @@ func main() {
 	mux.HandleFunc("/alloc", allocHandler)
 	mux.HandleFunc("/make1gbslice", make1gbslice)
 	mux.HandleFunc("/leak", leak)
+	mux.HandleFunc("/rpc", rpcHandler)
 	handler := middleware.HTTPMiddleware(mux)
 
 	handler = otelhttp.NewHandler(handler, "my-http-service")
 
+	// 收到退出信号时取消ctx，依次关闭HTTP服务和GOGCTuner
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	tuner, err := gogctuner.NewTuner(gogctuner.Config{Logger: slog.Default()})
+	if err != nil {
+		panic(err)
+	}
+	tunerDone := make(chan error, 1)
+	go func() {
+		tunerDone <- tuner.Run(ctx)
+	}()
+
 	// 启动服务器
-	fmt.Println("Server started at :8080")
-	http.ListenAndServe(":8080", handler)
+	server := &http.Server{Addr: ":8080", Handler: handler}
+	serverDone := make(chan error, 1)
+	go func() {
+		fmt.Println("Server started at :8080")
+		serverDone <- server.ListenAndServe()
+	}()
+
+	serverExited := func(err error) {
+		if !errors.Is(err, http.ErrServerClosed) {
+			log.Printf("HTTP服务异常退出: %v", err)
+		}
+		stop()
+	}
+	select {
+	case err := <-serverDone:
+		serverExited(err)
+	case err := <-tunerDone:
+		// 调优器启动失败不影响服务，只记录日志，继续等待HTTP服务退出或退出信号
+		if err != nil {
+			log.Printf("GOGCTuner 启动失败: %v", err)
+		}
+		tunerDone = nil
+		select {
+		case err := <-serverDone:
+			serverExited(err)
+		case <-ctx.Done():
+		}
+	case <-ctx.Done():
+	}
+
+	// 优雅退出：等待进行中的请求完成，再等待调优器恢复GC设置
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := server.Shutdown(shutdownCtx); err != nil {
+		log.Printf("HTTP服务关闭失败: %v", err)
+	}
+	if tunerDone != nil {
+		select {
+		case <-tunerDone:
+		case <-shutdownCtx.Done():
+		}
+	}
 }